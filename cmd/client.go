@@ -1,11 +1,18 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 	"time"
 
 	"qback/grpc/client"
+	"qback/grpc/common"
+	"qback/pkg/qback"
 	"qback/utils"
 
 	"github.com/spf13/cobra"
@@ -14,6 +21,8 @@ import (
 var (
 	clientChunkTimeout int
 	clientFileChunk    int
+	clientProgress     string
+	clientLimitRate    string
 )
 
 func NewClient() *cobra.Command {
@@ -27,14 +36,39 @@ func NewClient() *cobra.Command {
 
 	cmd.PersistentFlags().IntVarP(&clientChunkTimeout, "ct", "", 15, "Connect Timeout")
 	cmd.PersistentFlags().IntVarP(&clientFileChunk, "chunksize", "c", 1048576, "File chunksize [byte]")
+	cmd.PersistentFlags().StringVarP(&clientProgress, "progress", "", common.ProgressAuto, "Progress output: auto, bar, plain, json or none")
+	cmd.PersistentFlags().StringVarP(&clientLimitRate, "limit-rate", "", "", "Limit transfer rate in bytes/s, e.g. 10M or 10M,08:00-18:00=2M (local time windows)")
 
 	cmd.AddCommand(NewCheckSubCmd())
 	cmd.AddCommand(NewTransferSubCmd())
 	cmd.AddCommand(NewListSubCmd())
+	cmd.AddCommand(NewStatsSubCmd())
+	cmd.AddCommand(NewStatusSubCmd())
+	cmd.AddCommand(NewDeleteSubCmd())
+	cmd.AddCommand(NewSyncSubCmd())
+	cmd.AddCommand(NewWatchSubCmd())
 
 	return cmd
 }
 
+// clientProgressMode 检查 --progress 参数
+func clientProgressMode() string {
+	mode, err := common.ParseProgressMode(clientProgress)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return mode
+}
+
+// clientRateLimit 解析 --limit-rate 参数
+func clientRateLimit() common.RateSchedule {
+	schedule, err := common.ParseRateSchedule(clientLimitRate)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return schedule
+}
+
 func NewCheckSubCmd() *cobra.Command {
 	var timeout int
 
@@ -45,19 +79,20 @@ func NewCheckSubCmd() *cobra.Command {
 			startTime := time.Now().UnixMilli()
 
 			qClient := client.ClientBasic{
-				ServerAddress: ServiceAddress,
-				ChunkTimeout:  clientChunkTimeout,
-				Secure:        ServiceWithSecure,
-				Debug:         ServiceDebug,
+				ServerAddress:  ServiceAddress,
+				ChunkTimeout:   clientChunkTimeout,
+				Secure:         ServiceWithSecure,
+				Logger:         Logger,
+				TracerProvider: TracerProvider,
 			}
 
 			err := qClient.ServerCheck(timeout)
 			if err != nil {
-				log.Printf("Server is down: %s\n", err.Error())
+				fmt.Printf("Server is down: %s\n", err)
 			} else {
 				endTime := time.Now().UnixMilli()
 				delay := endTime - startTime
-				log.Printf("Server is up [%d ms]\n", delay)
+				fmt.Printf("Server is up [%d ms]\n", delay)
 			}
 		},
 	}
@@ -73,97 +108,535 @@ func NewTransferSubCmd() *cobra.Command {
 	var remoteName string
 	var localFile string
 	var localDir string
+	var contentDefined bool
+	var delta bool
+	var versionID string
+	var onConflict string
+	var outputPath string
+	var force bool
+	var skipExisting bool
+	var verifyExisting bool
+	var downloadAll bool
+	var jobs int
 
 	cmd := &cobra.Command{
 		Use:   "transfer",
 		Short: "Transfer file",
 		Run: func(cmd *cobra.Command, args []string) {
 			if reverse {
-				if localDir == "" {
-					log.Fatal("Error: --src flag is required when using --reverse")
+				if localDir == "" && outputPath == "" {
+					log.Fatal("Error: --src or --output flag is required when using --reverse")
+				}
+				if remoteName == "" && !downloadAll {
+					log.Fatal("Error: --name or --all flag is required when using --reverse")
 				}
 			} else {
 				if localFile == "" {
 					log.Fatal("Error: --file flag is required for normal transfer")
 				}
+				if contentDefined && delta {
+					log.Fatal("Error: --cdc and --delta cannot be used together")
+				}
+				if localFile == common.StdioPath && (contentDefined || delta) {
+					log.Fatal("Error: --cdc and --delta cannot be used with stdin")
+				}
+			}
+
+			conflict, err := qback.ParseConflictPolicy(onConflict)
+			if err != nil {
+				log.Fatalf("Error: %v", err)
 			}
 
 			qClient := client.ClientBasic{
-				ServerAddress: ServiceAddress,
-				ChunkTimeout:  clientChunkTimeout,
-				Secure:        ServiceWithSecure,
-				Chunksize:     clientFileChunk,
-				Debug:         ServiceDebug,
+				ServerAddress:  ServiceAddress,
+				ChunkTimeout:   clientChunkTimeout,
+				Secure:         ServiceWithSecure,
+				Chunksize:      clientFileChunk,
+				Logger:         Logger,
+				TracerProvider: TracerProvider,
+				ContentDefined: contentDefined,
+				Delta:          delta,
+				Conflict:       conflict,
+				Progress:       clientProgressMode(),
+				RateLimit:      clientRateLimit(),
+			}
+
+			switch {
+			case force:
+				qClient.OnExisting = client.ExistingForce
+			case skipExisting:
+				qClient.OnExisting = client.ExistingSkip
+			case verifyExisting:
+				qClient.OnExisting = client.ExistingVerify
 			}
 
 			if reverse {
-				log.Printf("Starting reverse transfer: server to client\n")
-				result, err := qClient.DownloadFile(remoteTag, remoteName, localDir)
+				Logger.Info("starting transfer", "direction", "server to client", "tag", remoteTag, "name", remoteName)
+				if downloadAll || client.IsGlobPattern(remoteName) {
+					if outputPath == common.StdioPath {
+						log.Fatal("Error: --output - cannot be used with batch download")
+					}
+					if outputPath == "" {
+						outputPath = utils.FileSuite.JoinPath(localDir, remoteTag)
+					}
+					results, err := qClient.DownloadBatch(remoteTag, remoteName, outputPath, jobs)
+					if err != nil {
+						log.Fatal(err)
+					}
+					if printBatchSummary(results) > 0 {
+						os.Exit(1)
+					}
+					return
+				}
+				if outputPath == "" {
+					outputPath, err = common.SetTargetFilePath(localDir, remoteTag, remoteName)
+					if err != nil {
+						log.Fatal(err)
+					}
+				}
+				result, err := qClient.DownloadFile(remoteTag, remoteName, versionID, outputPath)
 				if err != nil {
 					log.Fatal(err)
 				}
-				log.Println(result)
+				// 输出到标准输出时结果写到标准错误，避免混入文件内容
+				if outputPath == common.StdioPath {
+					fmt.Fprintln(os.Stderr, result)
+				} else {
+					fmt.Println(result)
+				}
 				return
 			}
 
-			log.Printf("Starting transfer: client to server\n")
+			Logger.Info("starting transfer", "direction", "client to server", "tag", remoteTag, "file", localFile)
+			if localFile == common.StdioPath {
+				if remoteName == "" {
+					log.Fatal("Error: --name flag is required when uploading from stdin")
+				}
+				result, err := qClient.UploadStream(remoteTag, remoteName, os.Stdin)
+				if err != nil {
+					log.Fatal(err)
+				}
+				fmt.Println(result)
+				return
+			}
 			result, err := qClient.UploadFile(remoteTag, localFile)
 			if err != nil {
 				log.Fatal(err)
 			}
-			log.Println(result)
+			fmt.Println(result)
 		},
 	}
 
 	cmd.Flags().StringVarP(&remoteTag, "tag", "t", "", "Remote tag")
 	cmd.Flags().StringVarP(&remoteName, "name", "n", "", "Remote file name")
-	cmd.Flags().StringVarP(&localFile, "file", "f", "", "Local file")
+	cmd.Flags().StringVarP(&localFile, "file", "f", "", "Local file, - for stdin (requires --name)")
 	cmd.Flags().StringVarP(&localDir, "src", "", "", "Local directory")
 	cmd.Flags().BoolVarP(&reverse, "reverse", "r", false, "Reverse transfer (server to client)")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "Download target path, - for stdout (with --reverse)")
+	cmd.Flags().BoolVarP(&force, "force", "", false, "Overwrite existing local file (with --reverse)")
+	cmd.Flags().BoolVarP(&skipExisting, "skip-existing", "", false, "Skip download if local file exists (with --reverse)")
+	cmd.Flags().BoolVarP(&verifyExisting, "verify-existing", "", false, "Skip download if local file matches server hash (with --reverse)")
+	cmd.Flags().BoolVarP(&downloadAll, "all", "", false, "Download all files of the tag matching --name (with --reverse)")
+	cmd.Flags().IntVarP(&jobs, "jobs", "j", client.DefaultBatchWorkers, "Concurrent downloads for batch download")
+	cmd.Flags().StringVarP(&versionID, "version", "", "", "Download a previous version (with --reverse)")
+	cmd.Flags().BoolVarP(&contentDefined, "cdc", "", false, "Content-defined chunking, only send chunks missing on server")
+	cmd.Flags().BoolVarP(&delta, "delta", "", false, "Send only the difference against the existing server copy")
+	cmd.Flags().StringVarP(&onConflict, "on-conflict", "", "", "Existing file policy: fail, skip-if-identical, overwrite, rename-with-suffix (default: fail if identical, overwrite otherwise)")
 	cmd.MarkFlagRequired("tag")
+	cmd.MarkFlagsMutuallyExclusive("force", "skip-existing", "verify-existing")
 
 	return cmd
 
 }
 
+// printBatchSummary 打印批量下载结果，返回失败数量
+func printBatchSummary(results []client.BatchResult) int {
+	var downloaded, skipped, failed int
+	var totalSize int64
+
+	fmt.Println(">> summary")
+	for _, result := range results {
+		message := ""
+		switch result.Status {
+		case client.BatchDownloaded:
+			downloaded++
+			totalSize += result.Size
+		case client.BatchSkipped:
+			skipped++
+		case client.BatchFailed:
+			failed++
+			message = result.Err.Error()
+		}
+		fmt.Printf(
+			"%-24s  %10s  %-10s  %8s  %s\n",
+			result.Name,
+			utils.PrettySize(result.Size),
+			result.Status,
+			result.Elapsed.Round(time.Millisecond),
+			message,
+		)
+	}
+	fmt.Printf("total=%d downloaded=%d (%s) skipped=%d failed=%d\n", len(results), downloaded, utils.PrettySize(totalSize), skipped, failed)
+	fmt.Println("<<")
+
+	return failed
+}
+
 func NewListSubCmd() *cobra.Command {
 	var remoteTag string
+	var withVersions bool
 
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List server files",
+		Long:  "List server files. A tag that does not exist yet is listed as empty instead of reporting an error, so sync can target a new tag.",
 		Run: func(cmd *cobra.Command, args []string) {
 			qClient := client.ClientBasic{
-				ServerAddress: ServiceAddress,
-				Secure:        ServiceWithSecure,
-				Debug:         ServiceDebug,
+				ServerAddress:  ServiceAddress,
+				Secure:         ServiceWithSecure,
+				Logger:         Logger,
+				TracerProvider: TracerProvider,
 			}
 
-			files, err := qClient.ListFiles(remoteTag)
+			files, err := qClient.ListFiles(remoteTag, withVersions)
 			if err != nil {
 				log.Fatal(err)
 			}
 
 			fmt.Printf(">> tag=%s\n", remoteTag)
 			if len(files) == 0 {
-				log.Println("No files found")
+				fmt.Println("No files found")
+				fmt.Println("<<")
 				return
 			}
 
+			// 版本号为 26 个字符，显示版本时加宽文件名列以便对齐
+			nameWidth := 24
+			if withVersions {
+				nameWidth = 29
+			}
 			for _, file := range files {
 				fmt.Printf(
-					"%-24s  %10s  %-12s  %s\n",
-					file.GetName(),
-					utils.PrettySize(file.GetSize()),
-					utils.PrettyHash(file.GetHash()),
-					time.Unix(file.GetModifiedTime(), 0).Format("2006-01-02 15:04"),
+					"%-*s  %10s  %-12s  %s\n",
+					nameWidth, file.Name,
+					utils.PrettySize(file.Size),
+					utils.PrettyHash(file.Hash),
+					file.ModTime.Format("2006-01-02 15:04"),
 				)
+				for _, version := range file.Versions {
+					fmt.Printf(
+						"  @%-26s  %10s  %-12s  %s\n",
+						version.ID,
+						utils.PrettySize(version.Size),
+						utils.PrettyHash(version.Hash),
+						version.ModTime.Format("2006-01-02 15:04"),
+					)
+				}
 			}
 			fmt.Println("<<")
 		},
 	}
 
 	cmd.Flags().StringVarP(&remoteTag, "tag", "t", "", "Source tag")
+	cmd.Flags().BoolVarP(&withVersions, "versions", "", false, "Show version history")
+	cmd.MarkFlagRequired("tag")
+
+	return cmd
+}
+
+func NewDeleteSubCmd() *cobra.Command {
+	var remoteTag string
+	var remoteName string
+
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete server file",
+		Run: func(cmd *cobra.Command, args []string) {
+			qClient := client.ClientBasic{
+				ServerAddress:  ServiceAddress,
+				Secure:         ServiceWithSecure,
+				Logger:         Logger,
+				TracerProvider: TracerProvider,
+			}
+
+			result, err := qClient.DeleteFile(remoteTag, remoteName)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println(result)
+		},
+	}
+
+	cmd.Flags().StringVarP(&remoteTag, "tag", "t", "", "Remote tag")
+	cmd.Flags().StringVarP(&remoteName, "name", "n", "", "Remote file name")
+	cmd.MarkFlagRequired("tag")
+	cmd.MarkFlagRequired("name")
+
+	return cmd
+}
+
+func NewStatsSubCmd() *cobra.Command {
+	var remoteTag string
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show server storage usage",
+		Run: func(cmd *cobra.Command, args []string) {
+			qClient := client.ClientBasic{
+				ServerAddress:  ServiceAddress,
+				Secure:         ServiceWithSecure,
+				Logger:         Logger,
+				TracerProvider: TracerProvider,
+			}
+
+			stats, err := qClient.StorageStats(remoteTag)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Printf(">> disk total=%s free=%s\n", utils.PrettySize(stats.DiskTotal), utils.PrettySize(stats.DiskFree))
+			if len(stats.Tags) == 0 {
+				fmt.Println("No tags found")
+				fmt.Println("<<")
+				return
+			}
+
+			for _, usage := range stats.Tags {
+				quota := "-"
+				if usage.Quota > 0 {
+					quota = utils.PrettySize(usage.Quota)
+				}
+				fmt.Printf(
+					"%-24s  %10s  %10s  %6d files\n",
+					usage.Tag,
+					utils.PrettySize(usage.Used),
+					quota,
+					usage.Files,
+				)
+			}
+			fmt.Println("<<")
+		},
+	}
+
+	cmd.Flags().StringVarP(&remoteTag, "tag", "t", "", "Source tag (all tags if empty)")
+
+	return cmd
+}
+
+func NewStatusSubCmd() *cobra.Command {
+	var remoteTag string
+	var watch bool
+	var interval time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show active transfers on the server",
+		Run: func(cmd *cobra.Command, args []string) {
+			qClient := client.ClientBasic{
+				ServerAddress:  ServiceAddress,
+				Secure:         ServiceWithSecure,
+				Logger:         Logger,
+				TracerProvider: TracerProvider,
+			}
+
+			if !watch {
+				transfers, err := qClient.ListTransfers(remoteTag)
+				if err != nil {
+					log.Fatal(err)
+				}
+				printTransfers(transfers)
+				return
+			}
+
+			if interval <= 0 {
+				log.Fatal("Error: --interval must be positive")
+			}
+			if err := qClient.Dial(); err != nil {
+				log.Fatal(err)
+			}
+			defer qClient.Close()
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			// 输出到终端时每次刷新前清屏
+			clearScreen := common.IsTerminal(os.Stdout)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				transfers, err := qClient.ListTransfers(remoteTag)
+				if err != nil {
+					log.Fatal(err)
+				}
+				if clearScreen {
+					fmt.Print("\033[H\033[2J")
+				}
+				printTransfers(transfers)
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&remoteTag, "tag", "t", "", "Only transfers of this tag (all tags if empty)")
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "Refresh until interrupted")
+	cmd.Flags().DurationVarP(&interval, "interval", "", 2*time.Second, "Refresh interval in watch mode")
+
+	return cmd
+}
+
+func printTransfers(transfers []qback.Transfer) {
+	fmt.Printf(">> %s transfers=%d\n", time.Now().Format(time.DateTime), len(transfers))
+	for _, t := range transfers {
+		progress := utils.PrettySize(t.Done)
+		if t.Total > 0 {
+			progress = fmt.Sprintf("%s/%s %3.0f%%", utils.PrettySize(t.Done), utils.PrettySize(t.Total), float64(t.Done)/float64(t.Total)*100)
+		}
+		fmt.Printf(
+			"%-8s  %-16s  %-24s  %-21s  %-26s  %12s  %8s  %s\n",
+			t.Op,
+			t.Tag,
+			t.Name,
+			t.Peer,
+			progress,
+			common.FormatSpeed(t.Rate),
+			time.Since(t.Start).Round(time.Second),
+			t.ID,
+		)
+	}
+	fmt.Println("<<")
+}
+
+func NewSyncSubCmd() *cobra.Command {
+	var localDir string
+	var remoteTag string
+	var direction string
+	var deleteExtra bool
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Sync a local directory with a tag",
+		Run: func(cmd *cobra.Command, args []string) {
+			if deleteExtra && direction == client.SyncBoth {
+				log.Fatal("Error: --delete cannot be used with --direction both")
+			}
+
+			qClient := client.ClientBasic{
+				ServerAddress:  ServiceAddress,
+				ChunkTimeout:   clientChunkTimeout,
+				Secure:         ServiceWithSecure,
+				Chunksize:      clientFileChunk,
+				Logger:         Logger,
+				TracerProvider: TracerProvider,
+				Progress:       clientProgressMode(),
+				RateLimit:      clientRateLimit(),
+			}
+
+			if err := qClient.Dial(); err != nil {
+				log.Fatal(err)
+			}
+			defer qClient.Close()
+
+			actions, err := qClient.PlanSync(localDir, remoteTag, direction, deleteExtra)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Printf(">> sync dir=%s tag=%s direction=%s\n", localDir, remoteTag, direction)
+			if len(actions) == 0 {
+				fmt.Println("Already in sync")
+				fmt.Println("<<")
+				return
+			}
+
+			if dryRun {
+				for _, action := range actions {
+					fmt.Printf("%-14s  %-24s  %10s  %s\n", action.Op, action.Name, utils.PrettySize(action.Size), action.Reason)
+				}
+				fmt.Printf("total=%d (dry run)\n", len(actions))
+				fmt.Println("<<")
+				return
+			}
+
+			var failed int
+			for _, result := range qClient.ApplySync(localDir, remoteTag, actions) {
+				status := "ok"
+				if result.Err != nil {
+					status = result.Err.Error()
+					failed++
+				}
+				fmt.Printf("%-14s  %-24s  %10s  %8s  %s\n", result.Op, result.Name, utils.PrettySize(result.Size), result.Elapsed.Round(time.Millisecond), status)
+			}
+			fmt.Printf("total=%d failed=%d\n", len(actions), failed)
+			fmt.Println("<<")
+			if failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&localDir, "dir", "", "", "Local directory")
+	cmd.Flags().StringVarP(&remoteTag, "tag", "t", "", "Remote tag")
+	cmd.Flags().StringVarP(&direction, "direction", "", client.SyncUp, "Sync direction: up, down, both")
+	cmd.Flags().BoolVarP(&deleteExtra, "delete", "", false, "Delete files missing on the source side")
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "", false, "Print the plan without transferring")
+	cmd.MarkFlagRequired("dir")
+	cmd.MarkFlagRequired("tag")
+
+	return cmd
+}
+
+func NewWatchSubCmd() *cobra.Command {
+	var localDir string
+	var remoteTag string
+	var queuePath string
+	var stableFor time.Duration
+	var onConflict string
+
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Upload files as they appear in a directory",
+		Run: func(cmd *cobra.Command, args []string) {
+			conflict, err := qback.ParseConflictPolicy(onConflict)
+			if err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+			if queuePath == "" {
+				queuePath = filepath.Join(localDir, ".qback-queue.json")
+			}
+
+			qClient := client.ClientBasic{
+				ServerAddress:  ServiceAddress,
+				ChunkTimeout:   clientChunkTimeout,
+				Secure:         ServiceWithSecure,
+				Chunksize:      clientFileChunk,
+				Logger:         Logger,
+				TracerProvider: TracerProvider,
+				Conflict:       conflict,
+				Progress:       clientProgressMode(),
+				RateLimit:      clientRateLimit(),
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if err := qClient.WatchDir(ctx, localDir, remoteTag, queuePath, stableFor); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&localDir, "dir", "", "", "Local directory to watch")
+	cmd.Flags().StringVarP(&remoteTag, "tag", "t", "", "Remote tag")
+	cmd.Flags().StringVarP(&queuePath, "queue", "", "", "Pending upload queue file (default <dir>/.qback-queue.json)")
+	cmd.Flags().DurationVarP(&stableFor, "stable", "", client.DefaultStableFor, "Upload a file after it is unchanged for this long")
+	cmd.Flags().StringVarP(&onConflict, "on-conflict", "", "", "Existing file policy: fail, skip-if-identical, overwrite, rename-with-suffix (default: fail if identical, overwrite otherwise)")
+	cmd.MarkFlagRequired("dir")
 	cmd.MarkFlagRequired("tag")
 
 	return cmd
@@ -1,18 +1,32 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
+	"time"
 
+	"qback/grpc/common"
 	"qback/utils"
 
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
 	ServiceAddress    string
 	ServiceWithSecure bool
 	ServiceDebug      bool
+	LogFormat         string
+	LogLevel          string
+	// Logger 根据 --log-format 和 --log-level 创建，同时设置为 slog 默认日志
+	Logger        *slog.Logger
+	TraceExporter string
+	TraceEndpoint string
+	// TracerProvider 根据 --trace 创建，未开启时为空
+	TracerProvider trace.TracerProvider
+	shutdownTracer func(context.Context) error
 )
 
 func NewCmd() *cobra.Command {
@@ -20,6 +34,20 @@ func NewCmd() *cobra.Command {
 		Use:     "qback",
 		Short:   "qback is a File Transfer Service",
 		Version: utils.VERSION,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := setupLogger(); err != nil {
+				return err
+			}
+			return setupTracing(cmd)
+		},
+		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+			if shutdownTracer == nil {
+				return nil
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			return shutdownTracer(ctx)
+		},
 		Run: func(cmd *cobra.Command, args []string) {
 			cmd.Help()
 		},
@@ -27,7 +55,11 @@ func NewCmd() *cobra.Command {
 
 	cmd.PersistentFlags().StringVarP(&ServiceAddress, "address", "a", "127.0.0.1:20000", "Server Address")
 	cmd.PersistentFlags().BoolVarP(&ServiceWithSecure, "secure", "s", false, "With TLS")
-	cmd.PersistentFlags().BoolVarP(&ServiceDebug, "debug", "d", false, "Enable debug mode")
+	cmd.PersistentFlags().BoolVarP(&ServiceDebug, "debug", "d", false, "Enable debug mode, same as --log-level debug")
+	cmd.PersistentFlags().StringVar(&LogFormat, "log-format", utils.LogFormatText, "Log format: text or json")
+	cmd.PersistentFlags().StringVar(&LogLevel, "log-level", "info", "Log level: debug, info, warn or error")
+	cmd.PersistentFlags().StringVar(&TraceExporter, "trace", "", "Enable tracing: otlp or stdout")
+	cmd.PersistentFlags().StringVar(&TraceEndpoint, "trace-endpoint", "", "OTLP gRPC endpoint URL, e.g. http://localhost:4317 (default from OTEL_EXPORTER_OTLP_* env)")
 
 	cmd.CompletionOptions.DisableDefaultCmd = true
 	cmd.AddCommand(NewServer(), NewClient())
@@ -35,6 +67,41 @@ func NewCmd() *cobra.Command {
 	return cmd
 }
 
+// setupLogger 创建输出到标准错误的日志，标准库 log 的输出也会经过它
+func setupLogger() error {
+	level, err := utils.ParseLogLevel(LogLevel)
+	if err != nil {
+		return err
+	}
+	if ServiceDebug {
+		level = slog.LevelDebug
+	}
+	logger, err := utils.NewLogger(os.Stderr, LogFormat, level)
+	if err != nil {
+		return err
+	}
+	Logger = logger
+	slog.SetDefault(logger)
+	return nil
+}
+
+// setupTracing 根据 --trace 创建链路追踪，服务名为 qback-server 或 qback-client
+func setupTracing(cmd *cobra.Command) error {
+	if TraceExporter == "" {
+		return nil
+	}
+	for cmd.HasParent() && cmd.Parent().HasParent() {
+		cmd = cmd.Parent()
+	}
+	tp, err := common.NewTracerProvider(context.Background(), TraceExporter, TraceEndpoint, "qback-"+cmd.Name())
+	if err != nil {
+		return err
+	}
+	TracerProvider = tp
+	shutdownTracer = tp.Shutdown
+	return nil
+}
+
 func Execute() {
 	if err := NewCmd().Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -2,9 +2,18 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"qback/grpc/common"
 	"qback/grpc/server"
+	"qback/utils"
 
 	"github.com/spf13/cobra"
 )
@@ -12,6 +21,23 @@ import (
 func NewServer() *cobra.Command {
 	var savePath string
 	var memoryMode bool
+	var memoryStoreSize string
+	var quotaRules []string
+	var dedup bool
+	var chunkTTL time.Duration
+	var keepVersions int
+	var versionMaxAge time.Duration
+	var webhookURL string
+	var uploadCommand []string
+	var hookTimeout time.Duration
+	var hookRetries int
+	var metricsAddress string
+	var auditPath string
+	var auditMaxSize string
+	var auditKeep int
+	var limitRate string
+	var peerLimitRate string
+	var concurrency server.ConcurrencyLimits
 
 	cmd := &cobra.Command{
 		Use:   "server",
@@ -20,16 +46,84 @@ func NewServer() *cobra.Command {
 			if !memoryMode && savePath == "" {
 				log.Fatal("flag required: --output (-o) is required when memory mode is disabled")
 			}
+			if memoryMode && dedup {
+				log.Fatal("flag conflict: --dedup cannot be used with memory mode")
+			}
+			if !memoryMode && memoryStoreSize != "" {
+				log.Fatal("flag required: --memory-store requires --memory (-m)")
+			}
+
+			var memoryStoreLimit int64
+			if memoryStoreSize != "" {
+				limit, err := utils.ParseSize(memoryStoreSize)
+				if err != nil {
+					log.Fatal(err)
+				}
+				memoryStoreLimit = limit
+			}
+
+			quotas := make(map[string]int64)
+			for _, rule := range quotaRules {
+				tag, size, ok := strings.Cut(rule, "=")
+				if !ok || tag == "" {
+					log.Fatalf("invalid quota %q, use: tag=size", rule)
+				}
+				limit, err := utils.ParseSize(size)
+				if err != nil {
+					log.Fatal(err)
+				}
+				quotas[tag] = limit
+			}
+
+			var auditLimit int64
+			if auditMaxSize != "" {
+				limit, err := utils.ParseSize(auditMaxSize)
+				if err != nil {
+					log.Fatal(err)
+				}
+				auditLimit = limit
+			}
+
+			rateLimit, err := common.ParseRateSchedule(limitRate)
+			if err != nil {
+				log.Fatal(err)
+			}
+			peerRateLimit, err := common.ParseRateSchedule(peerLimitRate)
+			if err != nil {
+				log.Fatal(err)
+			}
 
-			ctx, cancel := context.WithCancel(context.Background())
-			defer cancel()
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
 
 			qServer := server.ServerBasic{
-				ListenAddress: ServiceAddress,
-				Secure:        ServiceWithSecure,
-				SavePath:      savePath,
-				MemoryMode:    memoryMode,
-				Debug:         ServiceDebug,
+				ListenAddress:   ServiceAddress,
+				Secure:          ServiceWithSecure,
+				SavePath:        savePath,
+				MemoryMode:      memoryMode,
+				MemoryStoreSize: memoryStoreLimit,
+				Logger:          Logger,
+				TracerProvider:  TracerProvider,
+				Quotas:          quotas,
+				Dedup:           dedup,
+				ChunkTTL:        chunkTTL,
+				KeepVersions:    keepVersions,
+				VersionMaxAge:   versionMaxAge,
+				MetricsAddress:  metricsAddress,
+				AuditPath:       auditPath,
+				AuditMaxSize:    auditLimit,
+				AuditKeep:       auditKeep,
+				RateLimit:       rateLimit,
+				PeerRateLimit:   peerRateLimit,
+				Concurrency:     concurrency,
+			}
+
+			qServer.Notifier = &server.Notifier{
+				WebhookURL: webhookURL,
+				Command:    uploadCommand,
+				Timeout:    hookTimeout,
+				Retries:    hookRetries,
+				Logger:     Logger,
 			}
 
 			if err := qServer.Run(ctx); err != nil {
@@ -40,6 +134,86 @@ func NewServer() *cobra.Command {
 
 	cmd.Flags().StringVarP(&savePath, "output", "o", "", "Output Directory")
 	cmd.Flags().BoolVarP(&memoryMode, "memory", "m", false, "Memory Mode")
+	cmd.Flags().StringVarP(&memoryStoreSize, "memory-store", "", "", "Keep uploads in memory up to this total size, evicting least recently used files (requires --memory)")
+	cmd.Flags().BoolVarP(&dedup, "dedup", "", false, "Content-addressed deduplicated storage")
+	cmd.Flags().DurationVarP(&chunkTTL, "chunk-ttl", "", 7*24*time.Hour, "Prune content-defined chunks unused for this long")
+	cmd.Flags().IntVarP(&keepVersions, "keep-versions", "", 0, "Number of old versions kept per file (0 = overwrite)")
+	cmd.Flags().DurationVarP(&versionMaxAge, "version-max-age", "", 0, "Remove old versions older than this (0 = no limit)")
+	cmd.Flags().StringVarP(&metricsAddress, "metrics", "", "", "Serve Prometheus metrics on this address, e.g. :9090")
+	cmd.Flags().StringVarP(&webhookURL, "webhook", "", "", "POST a JSON event to this URL after each upload")
+	cmd.Flags().StringArrayVarP(&uploadCommand, "on-upload-cmd", "", nil, "Command run after each upload, one argument per flag (repeatable, not parsed by a shell), event passed as QBACK_* env and JSON on stdin")
+	cmd.Flags().DurationVarP(&hookTimeout, "hook-timeout", "", 10*time.Second, "Timeout for each webhook or command attempt")
+	cmd.Flags().IntVarP(&hookRetries, "hook-retries", "", 3, "Retries for a failed webhook or command")
+	cmd.Flags().StringArrayVarP(&quotaRules, "quota", "q", nil, "Tag quota, e.g. nightly=50G (repeatable)")
+	cmd.Flags().StringVarP(&limitRate, "limit-rate", "", "", "Server-wide transfer rate limit in bytes/s, e.g. 50M or 50M,08:00-18:00=10M (local time windows)")
+	cmd.Flags().StringVarP(&peerLimitRate, "peer-limit-rate", "", "", "Transfer rate limit per client IP, same format as --limit-rate")
+	cmd.Flags().IntVarP(&concurrency.Uploads, "max-uploads", "", 0, "Maximum concurrent uploads (0 = unlimited)")
+	cmd.Flags().IntVarP(&concurrency.Downloads, "max-downloads", "", 0, "Maximum concurrent downloads (0 = unlimited)")
+	cmd.Flags().IntVarP(&concurrency.PeerUploads, "peer-max-uploads", "", 0, "Maximum concurrent uploads per client IP (0 = unlimited)")
+	cmd.Flags().IntVarP(&concurrency.PeerDownloads, "peer-max-downloads", "", 0, "Maximum concurrent downloads per client IP (0 = unlimited)")
+	cmd.Flags().BoolVarP(&concurrency.Queue, "queue", "", false, "Queue transfers over the concurrency limits instead of rejecting them")
+	cmd.Flags().StringVarP(&auditPath, "audit-log", "", "", "Append an audit record of each upload, download, list and delete to this JSON-lines file")
+	cmd.Flags().StringVarP(&auditMaxSize, "audit-max-size", "", "100M", "Rotate the audit log when it reaches this size")
+	cmd.Flags().IntVarP(&auditKeep, "audit-keep", "", 10, "Number of rotated audit logs kept")
+
+	cmd.AddCommand(NewAuditSubCmd())
 
 	return cmd
 }
+
+func NewAuditSubCmd() *cobra.Command {
+	var auditPath string
+	var tag string
+	var since string
+	var until string
+
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Query the audit log by tag and time range",
+		Run: func(cmd *cobra.Command, args []string) {
+			now := time.Now()
+			filter := server.AuditFilter{Tag: tag}
+			var err error
+			if filter.Since, err = parseAuditTime(since, now); err != nil {
+				log.Fatal(err)
+			}
+			if filter.Until, err = parseAuditTime(until, now); err != nil {
+				log.Fatal(err)
+			}
+
+			records, err := server.QueryAuditLog(auditPath, filter)
+			if err != nil {
+				log.Fatal(err)
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			for _, rec := range records {
+				if err := encoder.Encode(rec); err != nil {
+					log.Fatal(err)
+				}
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&auditPath, "audit-log", "", "", "Audit log file")
+	cmd.Flags().StringVarP(&tag, "tag", "t", "", "Only records of this tag")
+	cmd.Flags().StringVarP(&since, "since", "", "", "Start time, RFC3339 or a duration ago, e.g. 24h")
+	cmd.Flags().StringVarP(&until, "until", "", "", "End time, RFC3339 or a duration ago")
+	cmd.MarkFlagRequired("audit-log")
+
+	return cmd
+}
+
+// parseAuditTime 解析 RFC3339 时间或相对 now 之前的时长，为空时不限制
+func parseAuditTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, use RFC3339 or a duration such as 24h", value)
+	}
+	return t, nil
+}
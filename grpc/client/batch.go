@@ -0,0 +1,169 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"qback/grpc/common"
+	"qback/pkg/qback"
+	"qback/utils"
+)
+
+// DefaultBatchWorkers 批量下载默认并发数
+const DefaultBatchWorkers = 4
+
+// 批量下载中单个文件的结果
+const (
+	BatchDownloaded = "downloaded"
+	BatchSkipped    = "skipped"
+	BatchFailed     = "failed"
+)
+
+// BatchResult 批量下载中单个文件的结果
+type BatchResult struct {
+	Name    string
+	Size    int64
+	Status  string
+	Path    string
+	Elapsed time.Duration
+	Err     error
+}
+
+// IsGlobPattern 判断文件名是否包含通配符
+func IsGlobPattern(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+// DownloadBatch 列出标签下匹配 pattern 的文件并并发下载到 outputDir，本地哈希一致的文件跳过
+func (c *ClientBasic) DownloadBatch(fileTag, pattern, outputDir string, workers int) ([]BatchResult, error) {
+	if pattern == "" {
+		pattern = "*"
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	if workers <= 0 {
+		workers = DefaultBatchWorkers
+	}
+
+	release, err := c.openSession()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	files, err := c.ListFiles(fileTag, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []qback.FileInfo
+	for _, file := range files {
+		if ok, _ := path.Match(pattern, file.Name); ok {
+			matched = append(matched, file)
+		}
+	}
+	c.logger().Info("batch download", "tag", fileTag, "pattern", pattern, "matched", len(matched), "files", len(files), "workers", workers, "output", outputDir)
+
+	results := make([]BatchResult, len(matched))
+	jobs := make(chan int)
+	batch := c
+	if workers > 1 && len(matched) > 1 {
+		batch = c.worker()
+		batch.Progress = concurrentProgress(c.Progress)
+	}
+
+	var wg sync.WaitGroup
+	for range min(workers, len(matched)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = batch.downloadItem(fileTag, matched[i], outputDir)
+			}
+		}()
+	}
+
+	for i := range matched {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, nil
+}
+
+// worker 复制一份客户端配置并共用当前连接，用于需要单独修改策略的批量任务
+func (c *ClientBasic) worker() *ClientBasic {
+	return &ClientBasic{
+		sess:           c.shared(),
+		ChunkTimeout:   c.ChunkTimeout,
+		Chunksize:      c.Chunksize,
+		ServerAddress:  c.ServerAddress,
+		Secure:         c.Secure,
+		Logger:         c.Logger,
+		TracerProvider: c.TracerProvider,
+		ContentDefined: c.ContentDefined,
+		Delta:          c.Delta,
+		Conflict:       c.Conflict,
+		OnExisting:     c.OnExisting,
+		Progress:       c.Progress,
+		ProgressOutput: c.ProgressOutput,
+		RateLimit:      c.RateLimit,
+	}
+}
+
+// concurrentProgress 多个传输同时进行时单行进度条会互相覆盖，改为逐行输出
+func concurrentProgress(mode string) string {
+	if mode == "" || mode == common.ProgressAuto || mode == common.ProgressBar {
+		return common.ProgressPlain
+	}
+	return mode
+}
+
+func (c *ClientBasic) downloadItem(fileTag string, file qback.FileInfo, outputDir string) (result BatchResult) {
+	result = BatchResult{
+		Name: file.Name,
+		Size: file.Size,
+	}
+	startTime := time.Now()
+	defer func() {
+		result.Elapsed = time.Since(startTime)
+	}()
+
+	// 文件名来自服务端，不能写到输出目录之外
+	if !common.IsValidName(file.Name) {
+		c.logger().Warn("batch skip invalid file name", "tag", fileTag, "name", file.Name)
+		result.Status = BatchFailed
+		result.Err = fmt.Errorf("invalid file name %q", file.Name)
+		return result
+	}
+	result.Path = utils.FileSuite.JoinPath(outputDir, file.Name)
+
+	if _, err := os.Stat(result.Path); err == nil {
+		localHash, err := common.CalcBlake3(result.Path)
+		if err == nil && localHash == file.Hash {
+			c.logger().Debug("batch skip identical file", "path", result.Path)
+			result.Status = BatchSkipped
+			return result
+		}
+		if c.OnExisting == ExistingSkip {
+			result.Status = BatchSkipped
+			return result
+		}
+	}
+
+	if _, err := c.DownloadFile(fileTag, file.Name, "", result.Path); err != nil {
+		c.logger().Error("batch download failed", "tag", fileTag, "name", file.Name, "err", err)
+		result.Status = BatchFailed
+		result.Err = err
+		return result
+	}
+
+	result.Status = BatchDownloaded
+	return result
+}
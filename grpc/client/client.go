@@ -10,674 +10,496 @@
 	1.计算待发送文件的哈希值(hash)
 	2.根据分片大小计算分片数量(chunks)
 	3.分片大小默认为 1MB
+
+传输协议由 pkg/qback 实现，这里负责命令行相关的连接管理、本地文件处理和日志输出。
 */
 package client
 
 import (
-	"bufio"
 	"context"
-	"crypto/tls"
 	"fmt"
 	"io"
-	"log"
-	"math"
+	"log/slog"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"qback/grpc/common"
-	transferv1 "qback/internal/pb/qmeta/transfer/v1"
-	"qback/utils"
+	"qback/pkg/qback"
 
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/credentials/insecure"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// ClientBasic 客户端，调用 Dial 后所有操作共用一个连接，可以并发使用
+// 未调用 Dial 时每个操作单独建立连接
 type ClientBasic struct {
-	conn          *grpc.ClientConn
-	ctx           context.Context
-	cancel        context.CancelFunc
+	mu            sync.Mutex
+	sess          *session
 	ChunkTimeout  int
 	Chunksize     int
 	ServerAddress string
 	Secure        bool
-	Debug         bool
+	// Logger 客户端日志，为空时使用 slog.Default()
+	Logger *slog.Logger
+	// TracerProvider 链路追踪，为空时不记录
+	TracerProvider trace.TracerProvider
+	// ContentDefined 使用内容定义分片上传，只发送服务端缺少的分片
+	ContentDefined bool
+	// Delta 服务端已有不同版本时只发送增量数据
+	Delta bool
+	// Conflict 服务端已有同名文件时的处理策略
+	Conflict qback.ConflictPolicy
+	// OnExisting 下载目标已存在时的处理方式
+	OnExisting ExistingPolicy
+	// Progress 进度输出方式，见 common.ProgressAuto 等，为空时为 auto
+	Progress string
+	// ProgressOutput 进度输出位置，为空时为标准输出，下载到标准输出时改用标准错误
+	ProgressOutput io.Writer
+	// RateLimit 上传和下载的限速计划，同一连接上的传输共用
+	RateLimit common.RateSchedule
 }
 
-func (c *ClientBasic) logDebug(format string, v ...any) {
-	if c.Debug {
-		utils.LogDebug(format, v...)
-	}
+// session 一个 SDK 客户端及其生命周期
+type session struct {
+	sdk    *qback.Client
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (s *session) close() {
+	s.cancel()
+	_ = s.sdk.Close()
 }
 
-func (c *ClientBasic) shouldLogChunk(chunk, total int64) bool {
-	if total <= 10 {
-		return true
+// ExistingPolicy 下载目标文件已存在时的处理方式
+type ExistingPolicy int
+
+const (
+	// ExistingFail 目标已存在时报错
+	ExistingFail ExistingPolicy = iota
+	// ExistingForce 覆盖目标文件
+	ExistingForce
+	// ExistingSkip 目标已存在时跳过下载
+	ExistingSkip
+	// ExistingVerify 与服务端哈希一致时跳过，否则覆盖
+	ExistingVerify
+)
+
+func (c *ClientBasic) logger() *slog.Logger {
+	if c.Logger == nil {
+		return slog.Default()
 	}
-	return chunk == 1 || chunk == total || chunk%100 == 0
+	return c.Logger
 }
 
-func (c *ClientBasic) defaultTimeout() {
+func (c *ClientBasic) chunkTimeout() int {
 	if c.ChunkTimeout == 0 {
-		c.ChunkTimeout = 30
+		return 30
 	}
+	return c.ChunkTimeout
 }
 
-func (c *ClientBasic) connect() (transferv1.FileTransferServiceClient, error) {
-	log.Printf("Connecting on %s\n", c.ServerAddress)
-	c.defaultTimeout()
-	c.logDebug("connect config: address=%s secure=%t chunk_timeout=%ds chunksize=%d", c.ServerAddress, c.Secure, c.ChunkTimeout, c.Chunksize)
+// showProgress 创建单个传输的进度输出，返回的选项将 SDK 的进度回调转给它
+// toStdout 表示数据写到标准输出，此时进度改为输出到标准错误
+func (c *ClientBasic) showProgress(op, fileTag, fileName, transferID string, toStdout bool) (*common.ProgressReporter, qback.CallOption) {
+	w := c.ProgressOutput
+	if w == nil {
+		w = os.Stdout
+	}
+	if toStdout && w == io.Writer(os.Stdout) {
+		w = os.Stderr
+	}
+	reporter := common.NewProgressReporter(w, c.Progress, op, fileTag, fileName, transferID)
+	return reporter, qback.WithProgress(func(p qback.Progress) {
+		reporter.Update(p.Done, p.Total)
+	})
+}
+
+// newSession 建立新的连接
+func (c *ClientBasic) newSession() (*session, error) {
+	c.logger().Info("connecting", "address", c.ServerAddress)
+	c.logger().Debug("connect config", "secure", c.Secure, "chunk_timeout", c.chunkTimeout(), "chunksize", c.Chunksize)
+
+	opts := []qback.Option{
+		qback.WithChunkSize(c.Chunksize),
+		qback.WithChunkTimeout(time.Duration(c.chunkTimeout()) * time.Second),
+		qback.WithLogger(c.logger()),
+	}
+	if c.TracerProvider != nil {
+		opts = append(opts, qback.WithTracerProvider(c.TracerProvider))
+	}
+	if limiter := common.NewRateLimiter(c.RateLimit); limiter != nil {
+		c.logger().Info("rate limit enabled", "rate", c.RateLimit.Rate, "windows", len(c.RateLimit.Windows))
+		opts = append(opts, qback.WithRateLimiter(limiter))
+	}
 
-	var cred credentials.TransportCredentials
-	var tlsConfig *tls.Config
 	if c.Secure {
-		log.Println("TLS ON")
-		tlsCfg, err := common.GenTLSInfo("client", true)
+		c.logger().Info("tls enabled")
+		tlsConfig, err := common.GenTLSInfo("client", true)
 		if err != nil {
 			return nil, err
 		}
-		tlsCfg.ServerName = "127.0.0.1"
-		cred = credentials.NewTLS(tlsCfg)
-		tlsConfig = tlsCfg
+		tlsConfig.ServerName = "127.0.0.1"
+		opts = append(opts, qback.WithTLS(tlsConfig))
+
+		go common.ProbeTLSConnection(c.logger(), c.ServerAddress, tlsConfig)
+		c.logger().Debug("started tls probe", "address", c.ServerAddress)
 	} else {
-		log.Println("TLS OFF")
-		cred = insecure.NewCredentials()
+		c.logger().Info("tls disabled")
 	}
 
-	callOpt := grpc.WithDefaultCallOptions(
-		grpc.MaxCallRecvMsgSize(common.MaxMsgSize),
-		grpc.MaxCallSendMsgSize(common.MaxMsgSize),
-	)
-
-	serverOpt := grpc.WithDefaultServiceConfig(common.RetryPolicy)
-
-	opts := []grpc.DialOption{
-		grpc.WithTransportCredentials(cred),
-		callOpt,
-		serverOpt,
+	sdk, err := qback.New(c.ServerAddress, opts...)
+	if err != nil {
+		c.logger().Debug("connect failed", "err", err)
+		return nil, err
 	}
 
-	c.logDebug("timeout=%ds", c.ChunkTimeout)
-	c.logDebug("call message size=%d Bytes", common.MaxMsgSize)
-	c.logDebug("service config retry:\n%s", common.RetryPolicy)
+	ctx, cancel := context.WithCancel(context.Background())
+	return &session{sdk: sdk, ctx: ctx, cancel: cancel}, nil
+}
 
-	conn, err := grpc.NewClient(c.ServerAddress, opts...)
+// Dial 建立共享连接，之后的操作都复用该连接，直到调用 Close
+func (c *ClientBasic) Dial() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.sess != nil {
+		return nil
+	}
+	sess, err := c.newSession()
 	if err != nil {
-		c.logDebug("connect failed: %v", err)
-		return nil, err
+		return err
 	}
+	c.sess = sess
+	return nil
+}
 
-	if c.Secure {
-		go common.ProbeTLSConnection(c.ServerAddress, tlsConfig)
-		c.logDebug("started tls probe for %s", c.ServerAddress)
+// Close 关闭共享连接，正在进行的操作会被取消
+func (c *ClientBasic) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.sess == nil {
+		return nil
 	}
+	c.logger().Debug("closing client resources")
+	c.sess.close()
+	c.sess = nil
+	return nil
+}
 
-	c.conn = conn
-	c.ctx, c.cancel = context.WithCancel(context.Background())
-	c.logDebug("grpc client connection ready")
-	client := transferv1.NewFileTransferServiceClient(c.conn)
+// shared 返回当前的共享连接，未调用 Dial 时为 nil
+func (c *ClientBasic) shared() *session {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sess
+}
 
-	return client, nil
+// openSession 批量操作使用，未调用 Dial 时建立共享连接，返回的函数只关闭本次建立的连接
+func (c *ClientBasic) openSession() (func(), error) {
+	if c.shared() != nil {
+		return func() {}, nil
+	}
+	if err := c.Dial(); err != nil {
+		return nil, err
+	}
+	return func() { _ = c.Close() }, nil
 }
 
-func (c *ClientBasic) close() {
-	c.logDebug("closing client resources")
-	if c.cancel != nil {
-		c.cancel()
+// connect 返回本次操作使用的客户端和上下文，有共享连接时复用，否则建立临时连接
+// 操作结束后调用返回的 release
+func (c *ClientBasic) connect() (*qback.Client, context.Context, func(), error) {
+	if sess := c.shared(); sess != nil {
+		ctx, cancel := context.WithCancel(sess.ctx)
+		return sess.sdk, ctx, cancel, nil
 	}
-	if c.conn != nil {
-		_ = c.conn.Close()
+
+	sess, err := c.newSession()
+	if err != nil {
+		return nil, nil, nil, err
 	}
+	return sess.sdk, sess.ctx, func() {
+		c.logger().Debug("closing client resources")
+		sess.close()
+	}, nil
 }
 
 func (c *ClientBasic) ServerCheck(timeout int) error {
-	client, err := c.connect()
+	sdk, ctx, release, err := c.connect()
 	if err != nil {
 		return err
 	}
-	defer c.close()
+	defer release()
 
-	checkCtx, checkCancel := context.WithTimeout(c.ctx, time.Duration(timeout)*time.Second)
+	checkCtx, checkCancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
 	defer checkCancel()
 
-	checkReq := &transferv1.ServerCheckRequest{}
-	checkReq.SetStatus(true)
-	c.logDebug("sending server check request")
-	_, err = client.ServerCheck(checkCtx, checkReq)
-	if err != nil {
-		c.logDebug("server check failed: %v", err)
+	c.logger().Debug("sending server check request")
+	if err := sdk.Ping(checkCtx); err != nil {
+		c.logger().Debug("server check failed", "err", err)
 		return err
 	}
-	c.logDebug("server check succeeded")
+	c.logger().Debug("server check succeeded")
 	return nil
 }
 
+// UploadFile 上传本地文件，benchmark://name/size 形式的路径上传指定大小的全零数据用于测速
 func (c *ClientBasic) UploadFile(fileTag, filePath string) (string, error) {
-	client, err := c.connect()
-	if err != nil {
-		return "", err
-	}
-	defer c.close()
-
-	var fileName string
-	var fileSize int64
-	var fileHash string
-	var isBenchmark bool
-
 	if strings.HasPrefix(filePath, "benchmark://") {
-		isBenchmark = true
 		parts := strings.Split(strings.TrimPrefix(filePath, "benchmark://"), "/")
 		if len(parts) != 2 {
 			return "", fmt.Errorf("invalid benchmark file format, use: benchmark://filename/size")
 		}
-		baseFileName := parts[0]
-		fileName = fmt.Sprintf("%s_%d", baseFileName, time.Now().UnixNano())
-		fileSize, err = strconv.ParseInt(parts[1], 10, 64)
+		fileSize, err := strconv.ParseInt(parts[1], 10, 64)
 		if err != nil {
 			return "", fmt.Errorf("invalid file size: %w", err)
 		}
-		fileHash, err = c.calcVirtualHash(fileSize)
-		if err != nil {
-			return "", fmt.Errorf("failed to calc virtual hash: %w", err)
-		}
-		log.Printf("[Upload] Benchmark file: name=%s, size=%d\n", fileName, fileSize)
-		c.logDebug("upload source resolved: benchmark path=%s generated_name=%s", filePath, fileName)
-	} else {
-		fileInfo, err := os.Stat(filePath)
-		if err != nil {
-			return "", err
-		}
-		fileName = fileInfo.Name()
-		fileSize = fileInfo.Size()
-		fileHash, err = common.CalcBlake3(filePath)
-		if err != nil {
-			return "", err
-		}
+		fileName := fmt.Sprintf("%s_%d", parts[0], time.Now().UnixNano())
+		c.logger().Info("benchmark upload", "name", fileName, "size", fileSize)
 
-		log.Printf("[Upload] Real file: name=%s, size=%d\n", fileName, fileSize)
-		c.logDebug("upload source resolved: path=%s name=%s size=%d", filePath, fileName, fileSize)
+		return c.upload(fileTag, fileName, func(ctx context.Context, sdk *qback.Client, opts []qback.CallOption) (*qback.UploadResult, error) {
+			return sdk.Upload(ctx, fileTag, fileName, io.LimitReader(zeroReader{}, fileSize), opts...)
+		})
 	}
 
-	fileChunks := int64(math.Ceil(float64(fileSize) / float64(c.Chunksize)))
-	c.logDebug("upload metadata prepared: tag=%s name=%s chunks=%d hash=%s", fileTag, fileName, fileChunks, fileHash)
-
-	log.Printf("[Upload] Metadata tag=%s, name=%s, size=%d, chunks=%d x %d Byte, hash=%s\n",
-		fileTag, fileName, fileSize, fileChunks, c.Chunksize, fileHash)
-
-	stream, err := client.UploadFile(c.ctx)
+	fileInfo, err := os.Stat(filePath)
 	if err != nil {
-		c.logDebug("failed to create upload stream: %v", err)
 		return "", err
 	}
-	c.logDebug("upload stream created")
-
-	// 1. send metadata
-	fileMetadata := &transferv1.FileMetadata{}
-	fileMetadata.SetTag(fileTag)
-	fileMetadata.SetName(fileName)
-	fileMetadata.SetSize(fileSize)
-	fileMetadata.SetChunks(fileChunks)
-	fileMetadata.SetChunksize(int64(c.Chunksize))
-	fileMetadata.SetHash(fileHash)
-
-	uploadReq := &transferv1.UploadFileRequest{}
-	uploadReq.SetMetadata(fileMetadata)
-
-	c.logDebug("sending upload metadata to server")
-	if err := stream.Send(uploadReq); err != nil {
-		stream.CloseSend()
-		c.logDebug("send metadata failed: %v", err)
-		return "", fmt.Errorf("failed to send metadata: %w", err)
-	}
+	fileName := fileInfo.Name()
+	c.logger().Info("upload file", "path", filePath, "name", fileName, "size", fileInfo.Size())
+	c.logger().Debug("upload options", "content_defined", c.ContentDefined, "delta", c.Delta)
 
-	ack, err := stream.Recv()
-	if err != nil {
-		stream.CloseSend()
-		c.logDebug("receive metadata ack failed: %v", err)
-		return "", fmt.Errorf("failed to receive ack: %w", err)
-	}
-
-	// 2. check ack
-	metaAck := ack.GetMetaAck()
-	if metaAck == nil || !metaAck.GetAllowUpload() {
-		message := "server rejected upload"
-		if metaAck != nil {
-			message = metaAck.GetMessage()
-		} else if result := ack.GetResult(); result != nil {
-			message = result.GetMessage()
+	return c.upload(fileTag, fileName, func(ctx context.Context, sdk *qback.Client, opts []qback.CallOption) (*qback.UploadResult, error) {
+		if c.ContentDefined {
+			opts = append(opts, qback.WithContentDefined())
+		} else if c.Delta {
+			opts = append(opts, qback.WithDelta())
 		}
-		stream.CloseSend()
-		c.logDebug("server rejected upload: %s", message)
-		return message, nil
-	}
-
-	log.Printf("[Upload] Server ack: %s\n", metaAck.GetMessage())
-	log.Printf("[Upload] Server allowed, starting transfer\n")
-	c.logDebug("server ack details: allow=%t message=%s", metaAck.GetAllowUpload(), metaAck.GetMessage())
-
-	startTime := time.Now()
-	buffer := make([]byte, c.Chunksize)
-	var totalSent int64 = 0
-
-	if isBenchmark {
-		for chunk := int64(1); chunk <= fileChunks; chunk++ {
-			chunkCtx, chunkCancel := context.WithTimeout(c.ctx, time.Duration(c.ChunkTimeout)*time.Second)
-
-			remainingSize := fileSize - (chunk-1)*int64(c.Chunksize)
-			chunkSize := int64(c.Chunksize)
-			if remainingSize < chunkSize {
-				chunkSize = remainingSize
-			}
-			if c.shouldLogChunk(chunk, fileChunks) {
-				c.logDebug("sending benchmark chunk=%d/%d bytes=%d", chunk, fileChunks, chunkSize)
-			}
-
-			sendErr := make(chan error, 1)
-			go func(data []byte, chunkNum int64) {
-				chunk := &transferv1.ChunkData{}
-				chunk.SetChunk(chunkNum)
-				chunk.SetData(data)
-				uploadReq := &transferv1.UploadFileRequest{}
-				uploadReq.SetChunk(chunk)
-				sendErr <- stream.Send(uploadReq)
-			}(buffer[:chunkSize], chunk)
-
-			select {
-			case <-chunkCtx.Done():
-				chunkCancel()
-				stream.CloseSend()
-				c.logDebug("benchmark chunk timeout: chunk=%d/%d timeout=%ds", chunk, fileChunks, c.ChunkTimeout)
-				return "", fmt.Errorf("chunk %d/%d send timeout after %ds", chunk, fileChunks, c.ChunkTimeout)
-			case err := <-sendErr:
-				chunkCancel()
-				if err != nil {
-					stream.CloseSend()
-					c.logDebug("benchmark chunk send failed: chunk=%d/%d err=%v", chunk, fileChunks, err)
-					return "", fmt.Errorf("failed to send chunk %d/%d: %w", chunk, fileChunks, err)
-				}
-				totalSent += chunkSize
-			}
-
-			common.ShowProgress(chunk, fileChunks)
-		}
-	} else {
-		// 3. send file chunks
-		fileBody, err := os.Open(filePath)
-		if err != nil {
-			stream.CloseSend()
-			return "", err
-		}
-		defer fileBody.Close()
-
-		for chunk := int64(1); chunk <= fileChunks; chunk++ {
-			chunkCtx, chunkCancel := context.WithTimeout(c.ctx, time.Duration(c.ChunkTimeout)*time.Second)
-
-			// 计算分片偏移量
-			fileChunkOffset := (chunk - 1) * int64(c.Chunksize)
-			if _, err := fileBody.Seek(fileChunkOffset, 0); err != nil {
-				chunkCancel()
-				stream.CloseSend()
-				return "", fmt.Errorf("failed to seek file at chunk %d: %w", chunk, err)
-			}
-
-			// 调整最后一个分片的大小
-			remainingSize := fileSize - fileChunkOffset
-			readBuffer := buffer
-			if int64(len(buffer)) > remainingSize {
-				readBuffer = buffer[:remainingSize]
-			}
-
-			// 读取数据
-			n, err := fileBody.Read(readBuffer)
-			if err != nil {
-				chunkCancel()
-				stream.CloseSend()
-				c.logDebug("read file chunk failed: chunk=%d err=%v", chunk, err)
-				return "", fmt.Errorf("failed to read file at chunk %d: %w", chunk, err)
-			}
-			if c.shouldLogChunk(chunk, fileChunks) {
-				c.logDebug("sending file chunk=%d/%d offset=%d bytes=%d", chunk, fileChunks, fileChunkOffset, n)
-			}
-
-			sendErr := make(chan error, 1)
-			go func() {
-				chunkData := &transferv1.ChunkData{}
-				chunkData.SetChunk(chunk)
-				chunkData.SetData(readBuffer[:n])
-
-				uploadReq := &transferv1.UploadFileRequest{}
-				uploadReq.SetChunk(chunkData)
-
-				sendErr <- stream.Send(uploadReq)
-			}()
-
-			select {
-			case <-chunkCtx.Done():
-				chunkCancel()
-				stream.CloseSend()
-				c.logDebug("file chunk timeout: chunk=%d/%d timeout=%ds", chunk, fileChunks, c.ChunkTimeout)
-				return "", fmt.Errorf("chunk %d/%d send timeout after %ds", chunk, fileChunks, c.ChunkTimeout)
-			case err := <-sendErr:
-				chunkCancel()
-				if err != nil {
-					stream.CloseSend()
-					c.logDebug("file chunk send failed: chunk=%d/%d err=%v", chunk, fileChunks, err)
-					return "", fmt.Errorf("failed to send chunk %d/%d: %w", chunk, fileChunks, err)
-				}
-				totalSent += int64(n)
-			}
-
-			// 显示进度
-			common.ShowProgress(chunk, fileChunks)
-		}
-	}
-
-	if err := stream.CloseSend(); err != nil {
-		c.logDebug("close upload stream failed: %v", err)
-		return "", fmt.Errorf("failed to close send: %w", err)
-	}
-	c.logDebug("upload stream closed, waiting for final response")
-
-	resp, err := stream.Recv()
-	if err != nil {
-		c.logDebug("receive upload final response failed: %v", err)
-		return "", fmt.Errorf("failed to receive final response: %w", err)
-	}
-
-	result := resp.GetResult()
-	if result == nil {
-		return "", fmt.Errorf("unexpected response type: result is nil")
-	}
+		return sdk.UploadFile(ctx, fileTag, fileName, filePath, opts...)
+	})
+}
 
-	if !result.GetStatus() {
-		c.logDebug("upload finished with server failure: %s", result.GetMessage())
-		return "", fmt.Errorf("upload failed: %s", result.GetMessage())
+// UploadStream 从 r 流式上传数据，大小和哈希在发送完所有分片后通过结尾消息告知服务端
+func (c *ClientBasic) UploadStream(fileTag, fileName string, r io.Reader) (string, error) {
+	if fileName == "" {
+		return "", fmt.Errorf("file name is required for streaming upload")
 	}
+	c.logger().Info("streaming upload", "tag", fileTag, "name", fileName, "chunksize", c.Chunksize)
 
-	elapsed := time.Since(startTime)
-	speed := float64(totalSent) / elapsed.Seconds()
-	speedStr := common.FormatSpeed(speed)
-
-	log.Printf("[Upload] Complete: %s, elapsed=%d, speed=%s (sent: %d bytes)\n", fileName, elapsed.Milliseconds(), speedStr, totalSent)
-	log.Printf("[Upload] Success: %s\n", result.GetMessage())
-	c.logDebug("upload completed successfully: file=%s elapsed_ms=%d sent=%d", fileName, elapsed.Milliseconds(), totalSent)
-	return result.GetMessage(), nil
+	return c.upload(fileTag, fileName, func(ctx context.Context, sdk *qback.Client, opts []qback.CallOption) (*qback.UploadResult, error) {
+		return sdk.Upload(ctx, fileTag, fileName, r, opts...)
+	})
 }
 
-func (c *ClientBasic) DownloadFile(fileTag, fileName, savePath string) (string, error) {
-	ok, err := common.FileIsExist(savePath, fileTag, fileName, "")
-
-	if err != nil {
-		return "", fmt.Errorf("check file exist failed: %w", err)
-	} else if ok {
-		log.Printf("[Download] File already exists: %s\n", fileName)
-		c.logDebug("download skipped because target exists: tag=%s name=%s save_path=%s", fileTag, fileName, savePath)
-		return "", fmt.Errorf("file already exists: %s", fileName)
-	}
-
-	client, err := c.connect()
+// upload 执行上传并输出结果
+func (c *ClientBasic) upload(fileTag, fileName string, send func(context.Context, *qback.Client, []qback.CallOption) (*qback.UploadResult, error)) (string, error) {
+	sdk, ctx, release, err := c.connect()
 	if err != nil {
 		return "", err
 	}
-	defer c.close()
-
-	log.Printf("[Download] Request: tag=%s, name=%s, chunksize=%d\n", fileTag, fileName, c.Chunksize)
-	c.logDebug("download request prepared: tag=%s name=%s save_path=%s", fileTag, fileName, savePath)
+	defer release()
 
-	downloadReq := &transferv1.DownloadFileRequest{}
-	downloadReq.SetTag(fileTag)
-	downloadReq.SetName(fileName)
-	downloadReq.SetChunksize(int64(c.Chunksize))
+	transferID := common.NewTransferID()
+	logger := c.logger().With("transfer_id", transferID, "tag", fileTag)
+	progress, progressOpt := c.showProgress("upload", fileTag, fileName, transferID, false)
 
-	stream, err := client.DownloadFile(c.ctx, downloadReq)
+	startTime := time.Now()
+	result, err := send(ctx, sdk, []qback.CallOption{qback.WithConflict(c.Conflict), qback.WithTransferID(transferID), progressOpt})
+	progress.Finish(err)
 	if err != nil {
-		c.logDebug("failed to create download stream: %v", err)
-		return "", fmt.Errorf("failed to create download stream: %w", err)
+		logger.Error("upload failed", "name", fileName, "err", err)
+		return "", fmt.Errorf("upload failed: %w", err)
 	}
-	c.logDebug("download stream created")
 
-	// 1. 接收元数据
-	resp, err := stream.Recv()
-	if err != nil {
-		c.logDebug("failed to receive download metadata: %v", err)
-		return "", fmt.Errorf("failed to receive metadata: %w", err)
+	if result.Outcome != qback.OutcomeUnknown {
+		logger.Info("conflict outcome", "outcome", result.Outcome, "name", result.Name)
 	}
-
-	// 处理响应
-	if !resp.HasPayload() {
-		return "", fmt.Errorf("unexpected response type: payload is nil")
+	if result.Skipped {
+		logger.Info("server already has the content, transfer skipped", "name", result.Name, "message", result.Message)
+		return result.Message, nil
 	}
-
-	metadata := resp.GetMetadata()
-	if metadata == nil {
-		if result := resp.GetResult(); result != nil {
-			return "", fmt.Errorf("server error: %s", result.GetMessage())
-		}
-		return "", fmt.Errorf("missing metadata")
+	if result.Reused > 0 {
+		logger.Info("reused data on server", "reused", result.Reused)
 	}
 
-	fileSize := metadata.GetSize()
-	fileChunks := metadata.GetChunks()
-	fileHash := metadata.GetHash()
-
-	log.Printf("[Download] Metadata: size=%d, chunks=%d x %d Byte, hash=%s\n",
-		fileSize, fileChunks, metadata.GetChunksize(), fileHash)
-	c.logDebug("download metadata received: chunks=%d chunksize=%d hash=%s", fileChunks, metadata.GetChunksize(), fileHash)
-
-	dstFilePath, err := common.SetTargetFilePath(savePath, fileTag, fileName)
-	if err != nil {
-		return "", fmt.Errorf("create target file path failed: %w", err)
-	}
-	c.logDebug("download target path resolved: %s", dstFilePath)
-
-	recFile, err := common.OpenTargetFile(dstFilePath, common.FileWrite)
-	if err != nil {
-		return "", fmt.Errorf("failed to open target file: %w", err)
-	}
-	defer recFile.Close()
-
-	recFilePath := dstFilePath
-
-	bufWriter := bufio.NewWriterSize(recFile, 64*1024)
-	var receivedChunks int64
-	var totalReceived int64 = 0
-	startTime := time.Now()
-
-	log.Println("[Download] Start receiving data")
-
-	for {
-		chunkCtx, chunkCancel := context.WithTimeout(c.ctx, time.Duration(c.ChunkTimeout)*time.Second)
-
-		respChan := make(chan *transferv1.DownloadFileResponse, 1)
-		errChan := make(chan error, 1)
+	elapsed := time.Since(startTime)
+	speed := float64(result.Sent) / elapsed.Seconds()
 
-		go func() {
-			resp, err := stream.Recv()
-			if err != nil {
-				errChan <- err
-				return
-			}
-			respChan <- resp
-		}()
+	logger.Info("upload complete", "name", result.Name, "size", result.Size, "hash", result.Hash, "elapsed_ms", elapsed.Milliseconds(), "sent", result.Sent, "speed", common.FormatSpeed(speed), "message", result.Message)
+	return result.Message, nil
+}
 
-		var resp *transferv1.DownloadFileResponse
-		var err error
+func (c *ClientBasic) DownloadFile(fileTag, fileName, versionID, outputPath string) (string, error) {
+	toStdout := outputPath == common.StdioPath
 
-		select {
-		case <-chunkCtx.Done():
-			chunkCancel()
-			if bufWriter != nil {
-				_ = bufWriter.Flush()
-			}
-			if recFile != nil {
-				_ = recFile.Close()
-			}
-			_ = os.Remove(recFilePath)
-			c.logDebug("download chunk receive timeout after %ds, removed partial file=%s", c.ChunkTimeout, recFilePath)
-			return "", fmt.Errorf("chunk receive timeout after %ds", c.ChunkTimeout)
-		case err = <-errChan:
-			chunkCancel()
-			if err == io.EOF {
-				c.logDebug("download stream reached EOF")
-				break
+	var localExists bool
+	if !toStdout {
+		info, err := os.Stat(outputPath)
+		if err == nil {
+			if info.IsDir() {
+				return "", fmt.Errorf("output path is a directory: %s", outputPath)
 			}
-			if bufWriter != nil {
-				_ = bufWriter.Flush()
-			}
-			if recFile != nil {
-				_ = recFile.Close()
-			}
-			_ = os.Remove(recFilePath)
-			c.logDebug("failed to receive download chunk: %v, removed partial file=%s", err, recFilePath)
-			return "", fmt.Errorf("failed to receive chunk: %w", err)
-		case resp = <-respChan:
-			chunkCancel()
-		}
-
-		if err == io.EOF {
-			break
+			localExists = true
+		} else if !os.IsNotExist(err) {
+			return "", fmt.Errorf("check file exist failed: %w", err)
 		}
+	}
 
-		// 检查是否是结果消息
-		if result := resp.GetResult(); result != nil {
-			if !result.GetStatus() {
-				if bufWriter != nil {
-					_ = bufWriter.Flush()
-				}
-				if recFile != nil {
-					_ = recFile.Close()
-				}
-				os.Remove(recFilePath)
-				c.logDebug("download failed by server result: %s, removed partial file=%s", result.GetMessage(), recFilePath)
-				return "", fmt.Errorf("download failed: %s", result.GetMessage())
+	opts := []qback.CallOption{qback.WithVersion(versionID)}
+	if localExists {
+		switch c.OnExisting {
+		case ExistingSkip:
+			c.logger().Info("file already exists, skipped", "tag", fileTag, "name", fileName, "output", outputPath)
+			return outputPath, nil
+		case ExistingVerify:
+			localHash, err := common.CalcBlake3(outputPath)
+			if err != nil {
+				return "", fmt.Errorf("hash local file failed: %w", err)
 			}
-			log.Printf("[Download] Server confirmed: %s\n", result.GetMessage())
-			c.logDebug("download server result received: %s", result.GetMessage())
-			break
-		}
-
-		chunk := resp.GetChunk()
-		if chunk == nil {
-			continue
+			opts = append(opts, qback.WithSkipIfHash(localHash))
+		case ExistingForce:
+		default:
+			c.logger().Warn("file already exists", "tag", fileTag, "name", fileName, "output", outputPath)
+			return "", fmt.Errorf("file already exists: %s", outputPath)
 		}
+	}
 
-		data := chunk.GetData()
-		if len(data) == 0 {
-			continue
-		}
-		if c.shouldLogChunk(chunk.GetChunk(), fileChunks) {
-			c.logDebug("received file chunk=%d/%d bytes=%d", chunk.GetChunk(), fileChunks, len(data))
-		}
+	sdk, ctx, release, err := c.connect()
+	if err != nil {
+		return "", err
+	}
+	defer release()
 
-		totalReceived += int64(len(data))
+	transferID := common.NewTransferID()
+	progress, progressOpt := c.showProgress("download", fileTag, fileName, transferID, toStdout)
+	opts = append(opts, qback.WithTransferID(transferID), progressOpt)
+	logger := c.logger().With("transfer_id", transferID, "tag", fileTag, "name", fileName)
+	logger.Info("download request", "version", versionID, "chunksize", c.Chunksize)
 
-		// 写入数据
-		if _, err := bufWriter.Write(data); err != nil {
-			if bufWriter != nil {
-				_ = bufWriter.Flush()
-			}
-			if recFile != nil {
-				_ = recFile.Close()
-			}
-			os.Remove(recFilePath)
-			c.logDebug("failed to write download chunk=%d: %v, removed partial file=%s", chunk.GetChunk(), err, recFilePath)
-			return "", fmt.Errorf("failed to write chunk: %w", err)
+	// 文件先写入同目录下的临时文件，校验通过后替换目标文件
+	var out io.Writer = os.Stdout
+	var recFile *os.File
+	if !toStdout {
+		recFile, err = common.CreatePartFile(outputPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to open target file: %w", err)
 		}
-
-		receivedChunks = chunk.GetChunk()
-		common.ShowProgress(receivedChunks, fileChunks)
+		defer recFile.Close()
+		out = recFile
+		logger.Debug("download target path resolved", "output", outputPath, "part", recFile.Name())
 	}
 
-	if err := bufWriter.Flush(); err != nil {
+	cleanup := func() {
 		if recFile != nil {
 			_ = recFile.Close()
+			_ = os.Remove(recFile.Name())
 		}
-		_ = os.Remove(recFilePath)
-		c.logDebug("flush download file failed: %v, removed partial file=%s", err, recFilePath)
-		return "", fmt.Errorf("failed to flush: %w", err)
 	}
 
-	if err := recFile.Sync(); err != nil {
-		if recFile != nil {
-			_ = recFile.Close()
-		}
-		_ = os.Remove(recFilePath)
-		c.logDebug("sync download file failed: %v, removed partial file=%s", err, recFilePath)
-		return "", fmt.Errorf("failed to sync: %w", err)
+	startTime := time.Now()
+	result, err := sdk.Download(ctx, fileTag, fileName, out, opts...)
+	if err == nil && recFile != nil && !result.Skipped {
+		err = recFile.Sync()
 	}
-
-	if err := common.ValidateFileIntegrity(common.FileValidationInfo{
-		FilePath:     recFilePath,
-		ExpectedSize: fileSize,
-		ExpectedHash: fileHash,
-	}); err != nil {
-		if recFile != nil {
-			_ = recFile.Close()
+	progress.Finish(err)
+	if err != nil {
+		// 输出到 stdout 时数据已写出，校验失败只能返回错误
+		cleanup()
+		logger.Error("download failed", "err", err)
+		return "", fmt.Errorf("download failed: %w", err)
+	}
+	if result.Skipped {
+		cleanup()
+		logger.Info("local file is identical, skipped", "output", outputPath)
+		return outputPath, nil
+	}
+
+	savedPath := "stdout"
+	if recFile != nil {
+		_ = recFile.Close()
+		if err := os.Rename(recFile.Name(), outputPath); err != nil {
+			_ = os.Remove(recFile.Name())
+			return "", fmt.Errorf("failed to save file: %w", err)
 		}
-		_ = os.Remove(recFilePath)
-		c.logDebug("download validation failed: %v, removed partial file=%s", err, recFilePath)
-		return "", fmt.Errorf("validation error: %w", err)
+		savedPath = outputPath
 	}
 
 	elapsed := time.Since(startTime)
-	speed := float64(totalReceived) / elapsed.Seconds()
-	speedStr := common.FormatSpeed(speed)
+	speed := float64(result.Size) / elapsed.Seconds()
 
-	log.Printf("[Download] Complete: %s, elapsed: %d, received=%d bytes, speed=%s\n", fileName, elapsed.Milliseconds(), totalReceived, speedStr)
-	log.Printf("[Download] Saved to: %s\n", recFilePath)
-	c.logDebug("download completed successfully: file=%s elapsed_ms=%d received=%d", fileName, elapsed.Milliseconds(), totalReceived)
-
-	return recFilePath, nil
+	logger.Info("download complete", "size", result.Size, "hash", result.Hash, "elapsed_ms", elapsed.Milliseconds(), "speed", common.FormatSpeed(speed), "output", savedPath)
+	return savedPath, nil
 }
 
-func (c *ClientBasic) ListFiles(fileTag string) ([]*transferv1.ListFileItem, error) {
-	client, err := c.connect()
+func (c *ClientBasic) ListFiles(fileTag string, withVersions bool) ([]qback.FileInfo, error) {
+	sdk, ctx, release, err := c.connect()
 	if err != nil {
 		return nil, err
 	}
-	defer c.close()
+	defer release()
 
-	checkCtx, checkCancel := context.WithTimeout(c.ctx, 5*time.Second)
-	defer checkCancel()
+	timeout := 5 * time.Second
+	var opts []qback.CallOption
+	if withVersions {
+		timeout = 30 * time.Second
+		opts = append(opts, qback.WithVersions())
+	}
+	listCtx, listCancel := context.WithTimeout(ctx, timeout)
+	defer listCancel()
 
-	listReq := &transferv1.ListFilesRequest{}
-	listReq.SetTag(fileTag)
-	c.logDebug("listing files for tag=%s", fileTag)
-	response, err := client.ListFiles(checkCtx, listReq)
+	return sdk.List(listCtx, fileTag, opts...)
+}
+
+func (c *ClientBasic) DeleteFile(fileTag, fileName string) (string, error) {
+	sdk, ctx, release, err := c.connect()
 	if err != nil {
-		c.logDebug("list files request failed: %v", err)
-		return nil, err
+		return "", err
 	}
+	defer release()
 
-	if response.GetStatus() {
-		c.logDebug("list files succeeded: count=%d", len(response.GetFiles()))
-		return response.GetFiles(), nil
+	deleteCtx, deleteCancel := context.WithTimeout(ctx, 30*time.Second)
+	defer deleteCancel()
 
+	if err := sdk.Delete(deleteCtx, fileTag, fileName); err != nil {
+		return "", err
 	}
-
-	c.logDebug("list files rejected: %s", response.GetMessage())
-	return nil, fmt.Errorf("%s", response.GetMessage())
+	return fmt.Sprintf("File deleted: %s/%s", fileTag, fileName), nil
 }
 
-func (c *ClientBasic) calcVirtualHash(fileSize int64) (string, error) {
-	buffer := make([]byte, c.Chunksize)
+func (c *ClientBasic) StorageStats(fileTag string) (*qback.Stats, error) {
+	sdk, ctx, release, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
-	var data []byte
-	var written int64
+	statsCtx, statsCancel := context.WithTimeout(ctx, 30*time.Second)
+	defer statsCancel()
 
-	for written < fileSize {
-		remainingSize := fileSize - written
-		if remainingSize < int64(c.Chunksize) {
-			data = append(data, buffer[:remainingSize]...)
-			written += remainingSize
-		} else {
-			data = append(data, buffer...)
-			written += int64(c.Chunksize)
-		}
+	return sdk.Stats(statsCtx, fileTag)
+}
+
+// ListTransfers 查询服务端正在进行的传输
+func (c *ClientBasic) ListTransfers(fileTag string) ([]qback.Transfer, error) {
+	sdk, ctx, release, err := c.connect()
+	if err != nil {
+		return nil, err
 	}
+	defer release()
+
+	listCtx, listCancel := context.WithTimeout(ctx, 30*time.Second)
+	defer listCancel()
+
+	return sdk.Transfers(listCtx, fileTag)
+}
+
+// zeroReader 无限输出零字节
+type zeroReader struct{}
 
-	c.logDebug("virtual hash input generated: size=%d buffer=%d", fileSize, c.Chunksize)
-	return common.CalcBlake3FromBytes(data)
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
 }
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// uploadQueue 保存在磁盘上的待上传文件队列，重启后继续上传
+type uploadQueue struct {
+	path  string
+	mu    sync.Mutex
+	items []string
+}
+
+// loadUploadQueue 读取队列文件，文件不存在时返回空队列
+func loadUploadQueue(path string) (*uploadQueue, error) {
+	q := &uploadQueue{path: path}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read queue failed: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &q.items); err != nil {
+			return nil, fmt.Errorf("parse queue %s failed: %w", path, err)
+		}
+	}
+	return q, nil
+}
+
+// Add 加入队列，已存在时忽略
+func (q *uploadQueue) Add(item string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if slices.Contains(q.items, item) {
+		return nil
+	}
+	q.items = append(q.items, item)
+	return q.save()
+}
+
+// Remove 从队列中移除
+func (q *uploadQueue) Remove(item string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	i := slices.Index(q.items, item)
+	if i < 0 {
+		return nil
+	}
+	q.items = slices.Delete(q.items, i, i+1)
+	return q.save()
+}
+
+// Items 返回队列内容的副本
+func (q *uploadQueue) Items() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return slices.Clone(q.items)
+}
+
+// save 先写临时文件再替换，避免中断时损坏队列
+func (q *uploadQueue) save() error {
+	data, err := json.Marshal(q.items)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(q.path), 0755); err != nil {
+		return fmt.Errorf("create queue folder failed: %w", err)
+	}
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("write queue failed: %w", err)
+	}
+	if err := os.Rename(tmp, q.path); err != nil {
+		return fmt.Errorf("save queue failed: %w", err)
+	}
+	return nil
+}
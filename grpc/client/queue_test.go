@@ -0,0 +1,54 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUploadQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "queue.json")
+
+	queue, err := loadUploadQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items := queue.Items(); len(items) != 0 {
+		t.Fatalf("expected empty queue, got %v", items)
+	}
+
+	for _, item := range []string{"/a", "/b", "/a", "/c"} {
+		if err := queue.Add(item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := queue.Remove("/b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := queue.Remove("/missing"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新加载后保留未上传的文件和顺序
+	reloaded, err := loadUploadQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items := reloaded.Items(); !reflect.DeepEqual(items, []string{"/a", "/c"}) {
+		t.Fatalf("unexpected items after reload: %v", items)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("temp queue file left behind: %v", err)
+	}
+}
+
+func TestUploadQueueCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadUploadQueue(path); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
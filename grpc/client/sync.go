@@ -0,0 +1,223 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"qback/grpc/common"
+	"qback/pkg/qback"
+	"qback/utils"
+)
+
+// 同步方向
+const (
+	SyncUp   = "up"
+	SyncDown = "down"
+	SyncBoth = "both"
+)
+
+// 同步操作
+const (
+	SyncUpload       = "upload"
+	SyncDownload     = "download"
+	SyncDeleteLocal  = "delete-local"
+	SyncDeleteRemote = "delete-remote"
+)
+
+// SyncAction 同步计划中的一项操作
+type SyncAction struct {
+	Op     string
+	Name   string
+	Size   int64
+	Reason string
+}
+
+// SyncResult 同步操作的执行结果
+type SyncResult struct {
+	SyncAction
+	Elapsed time.Duration
+	Err     error
+}
+
+type localFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// PlanSync 按文件大小和哈希比较本地目录与标签，生成同步计划
+// 双向同步时内容不同的文件以修改时间较新的一方为准，deleteExtra 只在单向同步时生效
+func (c *ClientBasic) PlanSync(localDir, fileTag, direction string, deleteExtra bool) ([]SyncAction, error) {
+	if direction != SyncUp && direction != SyncDown && direction != SyncBoth {
+		return nil, fmt.Errorf("invalid direction %q, use up, down or both", direction)
+	}
+
+	locals, err := scanLocalDir(localDir)
+	if os.IsNotExist(err) && direction != SyncUp {
+		locals = map[string]localFile{}
+	} else if err != nil {
+		return nil, fmt.Errorf("read dir %s failed: %w", localDir, err)
+	}
+
+	files, err := c.ListFiles(fileTag, false)
+	if err != nil {
+		return nil, err
+	}
+	remotes := make(map[string]qback.FileInfo, len(files))
+	for _, file := range files {
+		// 文件名来自服务端，不能写到本地目录之外
+		if !common.IsValidName(file.Name) {
+			c.logger().Warn("sync skip invalid remote file name", "tag", fileTag, "name", file.Name)
+			continue
+		}
+		remotes[file.Name] = file
+	}
+	c.logger().Debug("sync plan", "dir", localDir, "tag", fileTag, "direction", direction, "local", len(locals), "remote", len(remotes))
+
+	var actions []SyncAction
+	for name, local := range locals {
+		remote, ok := remotes[name]
+		if !ok {
+			switch direction {
+			case SyncUp, SyncBoth:
+				actions = append(actions, SyncAction{Op: SyncUpload, Name: name, Size: local.size, Reason: "missing on server"})
+			case SyncDown:
+				if deleteExtra {
+					actions = append(actions, SyncAction{Op: SyncDeleteLocal, Name: name, Size: local.size, Reason: "not on server"})
+				}
+			}
+			continue
+		}
+
+		reason := "size differs"
+		if local.size == remote.Size {
+			localHash, err := common.CalcBlake3(local.path)
+			if err != nil {
+				return nil, fmt.Errorf("hash %s failed: %w", local.path, err)
+			}
+			if localHash == remote.Hash {
+				continue
+			}
+			reason = "hash differs"
+		}
+
+		upload := direction == SyncUp
+		if direction == SyncBoth {
+			upload = local.modTime.Unix() > remote.ModTime.Unix()
+			if upload {
+				reason += ", local newer"
+			} else {
+				reason += ", server newer"
+			}
+		}
+		if upload {
+			actions = append(actions, SyncAction{Op: SyncUpload, Name: name, Size: local.size, Reason: reason})
+		} else {
+			actions = append(actions, SyncAction{Op: SyncDownload, Name: name, Size: remote.Size, Reason: reason})
+		}
+	}
+
+	for name, remote := range remotes {
+		if _, ok := locals[name]; ok {
+			continue
+		}
+		switch direction {
+		case SyncDown, SyncBoth:
+			actions = append(actions, SyncAction{Op: SyncDownload, Name: name, Size: remote.Size, Reason: "missing locally"})
+		case SyncUp:
+			if deleteExtra {
+				actions = append(actions, SyncAction{Op: SyncDeleteRemote, Name: name, Size: remote.Size, Reason: "not in local dir"})
+			}
+		}
+	}
+
+	sort.Slice(actions, func(i, j int) bool {
+		if actions[i].Name != actions[j].Name {
+			return actions[i].Name < actions[j].Name
+		}
+		return actions[i].Op < actions[j].Op
+	})
+	return actions, nil
+}
+
+// ApplySync 依次执行同步计划，上传和下载都会覆盖对方已有的文件
+func (c *ClientBasic) ApplySync(localDir, fileTag string, actions []SyncAction) []SyncResult {
+	release, err := c.openSession()
+	if err != nil {
+		results := make([]SyncResult, 0, len(actions))
+		for _, action := range actions {
+			results = append(results, SyncResult{SyncAction: action, Err: err})
+		}
+		return results
+	}
+	defer release()
+
+	worker := c.worker()
+	worker.Conflict = qback.ConflictOverwrite
+	worker.OnExisting = ExistingForce
+
+	results := make([]SyncResult, 0, len(actions))
+	for _, action := range actions {
+		if !common.IsValidName(action.Name) {
+			err := fmt.Errorf("invalid file name %q", action.Name)
+			c.logger().Error("sync action failed", "tag", fileTag, "op", action.Op, "name", action.Name, "err", err)
+			results = append(results, SyncResult{SyncAction: action, Err: err})
+			continue
+		}
+
+		startTime := time.Now()
+		localPath := utils.FileSuite.JoinPath(localDir, action.Name)
+
+		var err error
+		switch action.Op {
+		case SyncUpload:
+			_, err = worker.UploadFile(fileTag, localPath)
+		case SyncDownload:
+			_, err = worker.DownloadFile(fileTag, action.Name, "", localPath)
+		case SyncDeleteLocal:
+			err = os.Remove(localPath)
+		case SyncDeleteRemote:
+			_, err = worker.DeleteFile(fileTag, action.Name)
+		default:
+			err = fmt.Errorf("unknown sync op: %s", action.Op)
+		}
+		if err != nil {
+			c.logger().Error("sync action failed", "tag", fileTag, "op", action.Op, "name", action.Name, "err", err)
+		}
+
+		results = append(results, SyncResult{
+			SyncAction: action,
+			Elapsed:    time.Since(startTime),
+			Err:        err,
+		})
+	}
+	return results
+}
+
+// scanLocalDir 列出目录下的普通文件，忽略子目录和隐藏文件
+func scanLocalDir(localDir string) (map[string]localFile, error) {
+	entries, err := os.ReadDir(localDir)
+	if err != nil {
+		return nil, err
+	}
+
+	locals := make(map[string]localFile, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || name[0] == '.' {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		locals[name] = localFile{
+			path:    utils.FileSuite.JoinPath(localDir, name),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		}
+	}
+	return locals, nil
+}
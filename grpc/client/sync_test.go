@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"qback/grpc/common"
+	transferv1 "qback/internal/pb/qmeta/transfer/v1"
+	"qback/internal/servertest"
+
+	"google.golang.org/grpc"
+)
+
+// startTestServer 启动保存到临时目录的服务端，返回客户端和保存目录
+func startTestServer(t *testing.T) (*ClientBasic, string) {
+	t.Helper()
+	address, savePath := servertest.Start(t)
+	return &ClientBasic{ServerAddress: address, Progress: common.ProgressNone}, savePath
+}
+
+// listService 只实现文件列表的假服务端，可以返回正常服务端不会产生的文件名
+type listService struct {
+	transferv1.UnimplementedFileTransferServiceServer
+	names []string
+}
+
+func (s *listService) Register(r grpc.ServiceRegistrar) {
+	transferv1.RegisterFileTransferServiceServer(r, s)
+}
+
+func (s *listService) ListFiles(context.Context, *transferv1.ListFilesRequest) (*transferv1.ListFilesResponse, error) {
+	listRes := &transferv1.ListFilesResponse{}
+	listRes.SetStatus(true)
+	for _, name := range s.names {
+		item := &transferv1.ListFileItem{}
+		item.SetName(name)
+		item.SetSize(1)
+		listRes.SetFiles(append(listRes.GetFiles(), item))
+	}
+	return listRes, nil
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPlanSync(t *testing.T) {
+	c, savePath := startTestServer(t)
+	writeFiles(t, filepath.Join(savePath, "sync"), map[string]string{
+		"same.txt":        "same",
+		"size.txt":        "server version",
+		"hash.txt":        "server",
+		"remote-only.txt": "remote",
+	})
+	localDir := t.TempDir()
+	writeFiles(t, localDir, map[string]string{
+		"same.txt":       "same",
+		"size.txt":       "local",
+		"hash.txt":       "local!",
+		"local-only.txt": "local",
+		".hidden":        "ignored",
+	})
+	// 双向同步时 size.txt 本地较新，hash.txt 服务端较新
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(localDir, "hash.txt"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(localDir, "size.txt"), future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		direction   string
+		deleteExtra bool
+		want        []SyncAction
+	}{
+		{SyncUp, false, []SyncAction{
+			{Op: SyncUpload, Name: "hash.txt", Size: 6, Reason: "hash differs"},
+			{Op: SyncUpload, Name: "local-only.txt", Size: 5, Reason: "missing on server"},
+			{Op: SyncUpload, Name: "size.txt", Size: 5, Reason: "size differs"},
+		}},
+		{SyncUp, true, []SyncAction{
+			{Op: SyncUpload, Name: "hash.txt", Size: 6, Reason: "hash differs"},
+			{Op: SyncUpload, Name: "local-only.txt", Size: 5, Reason: "missing on server"},
+			{Op: SyncDeleteRemote, Name: "remote-only.txt", Size: 6, Reason: "not in local dir"},
+			{Op: SyncUpload, Name: "size.txt", Size: 5, Reason: "size differs"},
+		}},
+		{SyncDown, true, []SyncAction{
+			{Op: SyncDownload, Name: "hash.txt", Size: 6, Reason: "hash differs"},
+			{Op: SyncDeleteLocal, Name: "local-only.txt", Size: 5, Reason: "not on server"},
+			{Op: SyncDownload, Name: "remote-only.txt", Size: 6, Reason: "missing locally"},
+			{Op: SyncDownload, Name: "size.txt", Size: 14, Reason: "size differs"},
+		}},
+		{SyncBoth, true, []SyncAction{
+			{Op: SyncDownload, Name: "hash.txt", Size: 6, Reason: "hash differs, server newer"},
+			{Op: SyncUpload, Name: "local-only.txt", Size: 5, Reason: "missing on server"},
+			{Op: SyncDownload, Name: "remote-only.txt", Size: 6, Reason: "missing locally"},
+			{Op: SyncUpload, Name: "size.txt", Size: 5, Reason: "size differs, local newer"},
+		}},
+	} {
+		actions, err := c.PlanSync(localDir, "sync", tc.direction, tc.deleteExtra)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(actions, tc.want) {
+			t.Errorf("direction=%s delete=%v\n got %+v\nwant %+v", tc.direction, tc.deleteExtra, actions, tc.want)
+		}
+	}
+
+	if _, err := c.PlanSync(localDir, "sync", "sideways", false); err == nil {
+		t.Fatal("expected invalid direction error")
+	}
+
+	// 服务端返回的文件名不能指向本地目录之外
+	fake := &ClientBasic{
+		ServerAddress: servertest.Listen(t, &listService{names: []string{"../escape.txt", ".hidden", "ok.txt"}}),
+		Progress:      common.ProgressNone,
+	}
+	actions, err := fake.PlanSync(localDir, "sync", SyncDown, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SyncAction{{Op: SyncDownload, Name: "ok.txt", Size: 1, Reason: "missing locally"}}
+	if !reflect.DeepEqual(actions, want) {
+		t.Fatalf("got %+v, want %+v", actions, want)
+	}
+	results := c.ApplySync(localDir, "sync", []SyncAction{{Op: SyncDownload, Name: "../escape.txt"}})
+	if len(results) != 1 || results[0].Err == nil {
+		t.Fatalf("expected invalid name error, got %+v", results)
+	}
+	if _, err := os.Stat(filepath.Join(localDir, "..", "escape.txt")); !os.IsNotExist(err) {
+		t.Fatalf("file written outside local dir: %v", err)
+	}
+}
+
+func TestPlanSyncNewTag(t *testing.T) {
+	c, _ := startTestServer(t)
+	localDir := t.TempDir()
+	writeFiles(t, localDir, map[string]string{"a.txt": "a"})
+
+	// 标签还不存在时视为空标签
+	actions, err := c.PlanSync(localDir, "new", SyncUp, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SyncAction{{Op: SyncUpload, Name: "a.txt", Size: 1, Reason: "missing on server"}}
+	if !reflect.DeepEqual(actions, want) {
+		t.Fatalf("got %+v, want %+v", actions, want)
+	}
+
+	// 本地目录不存在时视为空目录
+	actions, err = c.PlanSync(filepath.Join(localDir, "missing"), "new", SyncDown, false)
+	if err != nil || len(actions) != 0 {
+		t.Fatalf("unexpected plan %+v, err=%v", actions, err)
+	}
+}
@@ -0,0 +1,219 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"qback/pkg/qback"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// DefaultStableFor 文件在此时间内没有变化才认为已写完
+	DefaultStableFor   = 5 * time.Second
+	watchCheckInterval = time.Second
+	watchRetryInterval = 30 * time.Second
+)
+
+// watchState 记录待上传文件最近一次观察到的状态
+type watchState struct {
+	size    int64
+	modTime time.Time
+	since   time.Time
+	retryAt time.Time
+	// uploading 已交给上传协程，dirty 表示上传期间文件又有变化，需要再次上传
+	uploading bool
+	dirty     bool
+}
+
+// watchResult 上传协程的结果，done 为 true 表示可以移出队列
+type watchResult struct {
+	path string
+	done bool
+}
+
+// WatchDir 监听目录中新出现或被写入的文件，文件在 stableFor 内大小和修改时间不变后上传
+// 待上传文件先写入 queuePath 队列，上传成功后才移除，重启后会继续上传
+func (c *ClientBasic) WatchDir(ctx context.Context, localDir, fileTag, queuePath string, stableFor time.Duration) error {
+	if stableFor <= 0 {
+		stableFor = DefaultStableFor
+	}
+
+	localDir, err := filepath.Abs(localDir)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(localDir); err != nil {
+		return err
+	} else if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", localDir)
+	}
+
+	queue, err := loadUploadQueue(queuePath)
+	if err != nil {
+		return err
+	}
+
+	release, err := c.openSession()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create watcher failed: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(localDir); err != nil {
+		return fmt.Errorf("watch %s failed: %w", localDir, err)
+	}
+
+	now := time.Now()
+	states := make(map[string]*watchState)
+	for _, item := range queue.Items() {
+		states[item] = &watchState{since: now}
+	}
+	c.logger().Info("watching", "dir", localDir, "tag", fileTag, "stable", stableFor, "queued", len(states))
+	c.logger().Debug("watch queue file", "path", queuePath)
+
+	// 上传在单独的协程中进行，避免长时间上传阻塞事件处理导致事件丢失
+	jobs := make(chan string)
+	results := make(chan watchResult, 1)
+	workerDone := make(chan struct{})
+	go func() {
+		defer close(workerDone)
+		for path := range jobs {
+			results <- watchResult{path: path, done: c.uploadWatched(fileTag, path)}
+		}
+	}()
+	defer func() {
+		close(jobs)
+		<-workerDone
+	}()
+
+	ticker := time.NewTicker(watchCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.logger().Info("watch stopped", "pending", len(states))
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			if strings.HasPrefix(filepath.Base(event.Name), ".") {
+				continue
+			}
+			c.logger().Debug("watch event", "event", event)
+
+			if st, ok := states[event.Name]; ok {
+				st.since = time.Now()
+				if st.uploading {
+					st.dirty = true
+				}
+				continue
+			}
+			if err := queue.Add(event.Name); err != nil {
+				c.logger().Error("watch queue failed", "err", err)
+				continue
+			}
+			states[event.Name] = &watchState{since: time.Now()}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			c.logger().Error("watcher failed", "err", err)
+
+		case result := <-results:
+			st := states[result.path]
+			st.uploading = false
+			if !result.done {
+				st.retryAt = time.Now().Add(watchRetryInterval)
+				continue
+			}
+			if st.dirty {
+				// 上传期间文件有变化，稳定后再上传一次
+				st.dirty = false
+				continue
+			}
+			delete(states, result.path)
+			if err := queue.Remove(result.path); err != nil {
+				c.logger().Error("watch queue failed", "err", err)
+			}
+
+		case <-ticker.C:
+			for path, st := range states {
+				if st.uploading {
+					continue
+				}
+				ready, gone := c.checkWatched(path, st, stableFor)
+				if gone {
+					delete(states, path)
+					if err := queue.Remove(path); err != nil {
+						c.logger().Error("watch queue failed", "err", err)
+					}
+					continue
+				}
+				if !ready {
+					continue
+				}
+				// 上传协程忙时下次再检查
+				select {
+				case jobs <- path:
+					st.uploading = true
+				default:
+				}
+			}
+		}
+	}
+}
+
+// checkWatched 检查文件是否已稳定可以上传，gone 表示文件已不存在，可以移出队列
+func (c *ClientBasic) checkWatched(path string, st *watchState, stableFor time.Duration) (ready, gone bool) {
+	now := time.Now()
+
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		c.logger().Info("dropped, file is gone or not a regular file", "path", path)
+		return false, true
+	}
+
+	if info.Size() != st.size || !info.ModTime().Equal(st.modTime) {
+		st.size = info.Size()
+		st.modTime = info.ModTime()
+		st.since = now
+		return false, false
+	}
+	return now.Sub(st.since) >= stableFor && !now.Before(st.retryAt), false
+}
+
+// uploadWatched 上传文件，返回 true 表示可以移出队列
+func (c *ClientBasic) uploadWatched(fileTag, path string) bool {
+	c.logger().Info("uploading", "path", path)
+	result, err := c.UploadFile(fileTag, path)
+	if errors.Is(err, qback.ErrExists) {
+		c.logger().Info("already on server", "path", path)
+		return true
+	}
+	if err != nil {
+		c.logger().Error("upload failed, will retry", "path", path, "retry_in", watchRetryInterval, "err", err)
+		return false
+	}
+	c.logger().Info("uploaded", "path", path, "result", result)
+	return true
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchDir(t *testing.T) {
+	c, savePath := startTestServer(t)
+	localDir := t.TempDir()
+	queuePath := filepath.Join(t.TempDir(), "queue.json")
+
+	// 上次运行留在队列中的文件会继续上传
+	writeFiles(t, localDir, map[string]string{"queued.txt": "queued"})
+	queue, err := loadUploadQueue(queuePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := queue.Add(filepath.Join(localDir, "queued.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- c.WatchDir(ctx, localDir, "watch", queuePath, 100*time.Millisecond)
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	writeFiles(t, localDir, map[string]string{"new.txt": "new", ".hidden": "ignored"})
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		_, errQueued := os.Stat(filepath.Join(savePath, "watch", "queued.txt"))
+		_, errNew := os.Stat(filepath.Join(savePath, "watch", "new.txt"))
+		if errQueued == nil && errNew == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("watched files were not uploaded")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// 上传成功后移出队列
+	for time.Now().Before(deadline) {
+		queue, err := loadUploadQueue(queuePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(queue.Items()) == 0 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	queue, err = loadUploadQueue(queuePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items := queue.Items(); len(items) != 0 {
+		t.Fatalf("queue not drained: %v", items)
+	}
+	if _, err := os.Stat(filepath.Join(savePath, "watch", ".hidden")); !os.IsNotExist(err) {
+		t.Fatal("hidden file was uploaded")
+	}
+}
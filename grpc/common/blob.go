@@ -0,0 +1,207 @@
+package common
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"qback/utils"
+)
+
+const (
+	BlobDirName = ".blobs"
+	blobTmpName = "tmp"
+)
+
+// BlobStore 按内容哈希存储文件，标签下的文件以硬链接引用 blob，
+// blob 的引用计数即硬链接数减一
+type BlobStore struct {
+	root string
+	mu   sync.Mutex
+}
+
+// NewBlobStore 在保存目录下初始化 blob 存储
+func NewBlobStore(savePath string) (*BlobStore, error) {
+	root := utils.FileSuite.JoinPath(savePath, BlobDirName)
+	if _, err := utils.FileSuite.Mkdir(utils.FileSuite.JoinPath(root, blobTmpName)); err != nil {
+		return nil, fmt.Errorf("create blob folder failed: %w", err)
+	}
+	return &BlobStore{root: root}, nil
+}
+
+// IsValidHash 检查是否为 blake3-256 十六进制哈希
+func IsValidHash(hash string) bool {
+	if len(hash) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+func (b *BlobStore) blobPath(hash string) string {
+	return utils.FileSuite.JoinPath(b.root, hash[:2], hash)
+}
+
+// CreateTemp 创建用于接收数据的临时文件
+func (b *BlobStore) CreateTemp() (*os.File, error) {
+	f, err := os.CreateTemp(utils.FileSuite.JoinPath(b.root, blobTmpName), "upload-*")
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return f, nil
+}
+
+// Has 检查 blob 是否存在
+func (b *BlobStore) Has(hash string) bool {
+	return IsValidHash(hash) && utils.FileSuite.Exists(b.blobPath(hash))
+}
+
+// LinkExisting 如果 blob 已存在则链接到目标路径，检查和链接在同一个锁内完成
+// beforeReplace 在链接建立之后、替换目标之前执行，用于归档目标上的旧文件，返回错误时不替换
+func (b *BlobStore) LinkExisting(hash, targetPath string, beforeReplace func() error) (bool, error) {
+	if !IsValidHash(hash) {
+		return false, fmt.Errorf("invalid hash: %s", hash)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	blob := b.blobPath(hash)
+	if !utils.FileSuite.Exists(blob) {
+		return false, nil
+	}
+
+	// 先链接到临时路径，旧文件在归档之前保持不变
+	tmpLink := utils.FileSuite.JoinPath(b.root, blobTmpName, "link-"+hash)
+	os.Remove(tmpLink)
+	if err := os.Link(blob, tmpLink); err != nil {
+		return false, fmt.Errorf("link blob failed: %w", err)
+	}
+	if beforeReplace != nil {
+		if err := beforeReplace(); err != nil {
+			os.Remove(tmpLink)
+			return false, err
+		}
+	}
+	if err := os.Rename(tmpLink, targetPath); err != nil {
+		os.Remove(tmpLink)
+		return false, fmt.Errorf("replace file failed: %w", err)
+	}
+	return true, nil
+}
+
+// Commit 将已校验的临时文件存入 blob 并链接到目标路径，blob 已存在时丢弃临时文件
+func (b *BlobStore) Commit(tmpPath, hash, targetPath string) error {
+	if !IsValidHash(hash) {
+		return fmt.Errorf("invalid hash: %s", hash)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	blob := b.blobPath(hash)
+	if utils.FileSuite.Exists(blob) {
+		if err := os.Remove(tmpPath); err != nil {
+			return fmt.Errorf("remove temp file failed: %w", err)
+		}
+	} else {
+		if _, err := utils.FileSuite.Mkdir(filepath.Dir(blob)); err != nil {
+			return fmt.Errorf("create blob folder failed: %w", err)
+		}
+		if err := os.Rename(tmpPath, blob); err != nil {
+			return fmt.Errorf("store blob failed: %w", err)
+		}
+	}
+
+	return replaceLink(blob, targetPath)
+}
+
+// Remove 删除目标文件的引用，并回收不再被引用的 blob
+func (b *BlobStore) Remove(targetPath string) error {
+	if err := os.Remove(targetPath); err != nil {
+		return fmt.Errorf("remove file failed: %w", err)
+	}
+
+	_, _, err := b.GC()
+	return err
+}
+
+// GC 删除引用计数为 0 的 blob，返回删除数量和释放的字节数
+func (b *BlobStore) GC() (int, int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var removed int
+	var freed int64
+	err := filepath.WalkDir(b.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == blobTmpName {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		links, err := linkCount(path)
+		if err != nil {
+			return err
+		}
+		if links > 1 {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+		freed += info.Size()
+		return nil
+	})
+	if err != nil {
+		return removed, freed, fmt.Errorf("blob gc failed: %w", err)
+	}
+
+	return removed, freed, nil
+}
+
+// CleanTemp 清理中断上传遗留的临时文件，仅在没有上传进行时调用
+func (b *BlobStore) CleanTemp() error {
+	tmpDir := utils.FileSuite.JoinPath(b.root, blobTmpName)
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(utils.FileSuite.JoinPath(tmpDir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceLink 创建指向 blob 的硬链接，目标已存在时替换
+func replaceLink(blob, targetPath string) error {
+	if utils.FileSuite.Exists(targetPath) {
+		if err := os.Remove(targetPath); err != nil {
+			return fmt.Errorf("remove existing file failed: %w", err)
+		}
+	}
+	if err := os.Link(blob, targetPath); err != nil {
+		return fmt.Errorf("link blob failed: %w", err)
+	}
+	return nil
+}
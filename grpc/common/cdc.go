@@ -0,0 +1,123 @@
+package common
+
+import (
+	"io"
+	"math/bits"
+)
+
+// gearTable 内容定义分片使用的随机表，由固定种子生成，保证分片边界稳定
+var gearTable = func() [256]uint64 {
+	var table [256]uint64
+	seed := uint64(0x71626163_6b636463)
+	for i := range table {
+		// splitmix64
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return table
+}()
+
+// CDCChunk 内容定义分片，记录分片在文件中的位置和哈希
+type CDCChunk struct {
+	Offset int64
+	Size   int
+	Hash   string
+}
+
+// Chunker 基于 gear 滚动哈希的内容定义分片器 (FastCDC)
+type Chunker struct {
+	r       io.Reader
+	buf     []byte
+	start   int
+	end     int
+	eof     bool
+	minSize int
+	avgSize int
+	maxSize int
+	maskS   uint64
+	maskL   uint64
+}
+
+// NewChunker 以平均分片大小创建分片器，最小为 1/4，最大为 4 倍
+func NewChunker(r io.Reader, avgSize int) *Chunker {
+	if avgSize < 256 {
+		avgSize = 256
+	}
+	maxSize := avgSize * 4
+	if maxSize > MaxMsgSize/2 {
+		maxSize = MaxMsgSize / 2
+	}
+
+	b := bits.Len(uint(avgSize)) - 1
+	return &Chunker{
+		r:       r,
+		buf:     make([]byte, maxSize*2),
+		minSize: avgSize / 4,
+		avgSize: avgSize,
+		maxSize: maxSize,
+		maskS:   topMask(b + 1),
+		maskL:   topMask(b - 1),
+	}
+}
+
+func topMask(n int) uint64 {
+	return ((uint64(1) << n) - 1) << (64 - n)
+}
+
+// Next 返回下一个分片，数据在下次调用前有效，结束时返回 io.EOF
+func (c *Chunker) Next() ([]byte, error) {
+	if c.end-c.start < c.maxSize && !c.eof {
+		copy(c.buf, c.buf[c.start:c.end])
+		c.end -= c.start
+		c.start = 0
+
+		for c.end < len(c.buf) && !c.eof {
+			n, err := c.r.Read(c.buf[c.end:])
+			c.end += n
+			if err == io.EOF {
+				c.eof = true
+			} else if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if c.start == c.end {
+		return nil, io.EOF
+	}
+
+	cut := c.cutPoint(c.buf[c.start:c.end])
+	chunk := c.buf[c.start : c.start+cut]
+	c.start += cut
+	return chunk, nil
+}
+
+func (c *Chunker) cutPoint(data []byte) int {
+	n := len(data)
+	if n <= c.minSize {
+		return n
+	}
+	if n > c.maxSize {
+		n = c.maxSize
+	}
+	normal := min(c.avgSize, n)
+
+	var fp uint64
+	i := c.minSize
+	for ; i < normal; i++ {
+		fp = (fp << 1) + gearTable[data[i]]
+		if fp&c.maskS == 0 {
+			return i + 1
+		}
+	}
+	for ; i < n; i++ {
+		fp = (fp << 1) + gearTable[data[i]]
+		if fp&c.maskL == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
@@ -0,0 +1,65 @@
+package common
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func chunkHashes(t *testing.T, data []byte, avgSize int) []string {
+	t.Helper()
+	chunker := NewChunker(bytes.NewReader(data), avgSize)
+	var hashes []string
+	var total int
+	for {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(chunk) > avgSize*4 {
+			t.Fatalf("chunk of %d bytes exceeds max size", len(chunk))
+		}
+		hash, err := CalcBlake3FromBytes(chunk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hash)
+		total += len(chunk)
+	}
+	if total != len(data) {
+		t.Fatalf("chunks cover %d bytes, want %d", total, len(data))
+	}
+	return hashes
+}
+
+func TestChunkerBoundaryStability(t *testing.T) {
+	data := make([]byte, 256*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	// 在中间插入数据后，只有插入位置附近的分片变化
+	edited := append(append(append([]byte{}, data[:100000]...), []byte("inserted bytes")...), data[100000:]...)
+
+	original := chunkHashes(t, data, 4096)
+	changed := chunkHashes(t, edited, 4096)
+
+	known := make(map[string]bool, len(original))
+	for _, hash := range original {
+		known[hash] = true
+	}
+	var reused int
+	for _, hash := range changed {
+		if known[hash] {
+			reused++
+		}
+	}
+	if len(original) < 16 {
+		t.Fatalf("expected many chunks, got %d", len(original))
+	}
+	if reused < len(original)-3 {
+		t.Fatalf("only %d of %d chunks reused after insert", reused, len(original))
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"time"
 
@@ -113,10 +113,10 @@ func GenTLSInfo(certType string, mTLS bool) (*tls.Config, error) {
 	return tlsConfig, nil
 }
 
-func ProbeTLSConnection(address string, tlsConfig *tls.Config) {
+func ProbeTLSConnection(logger *slog.Logger, address string, tlsConfig *tls.Config) {
 	probe, err := tls.Dial("tcp", address, tlsConfig)
 	if err != nil {
-		log.Printf("TLS probe failed: %v", err)
+		logger.Warn("tls probe failed", "err", err)
 		return
 	}
 	defer probe.Close()
@@ -138,15 +138,15 @@ func ProbeTLSConnection(address string, tlsConfig *tls.Config) {
 		daysLeft = "unknown"
 	}
 
-	log.Printf("TLS Connected: ServerName=%s, Version=%s, CipherSuite=%s, Curve=%s", state.ServerName, ver, cipher, curve)
+	logger.Info("tls connected", "server_name", state.ServerName, "version", ver, "cipher_suite", cipher, "curve", curve)
 
 	if !notAfter.IsZero() {
-		log.Printf("TLS Cert: Subject=%s, Issuer=%s, NotBefore=%s, NotAfter=%s (left: %s)",
-			state.PeerCertificates[0].Subject.CommonName,
-			state.PeerCertificates[0].Issuer.CommonName,
-			state.PeerCertificates[0].NotBefore.Format(time.RFC3339),
-			notAfter.Format(time.RFC3339),
-			daysLeft,
+		logger.Info("tls certificate",
+			"subject", state.PeerCertificates[0].Subject.CommonName,
+			"issuer", state.PeerCertificates[0].Issuer.CommonName,
+			"not_before", state.PeerCertificates[0].NotBefore.Format(time.RFC3339),
+			"not_after", notAfter.Format(time.RFC3339),
+			"left", daysLeft,
 		)
 	}
 }
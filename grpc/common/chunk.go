@@ -0,0 +1,136 @@
+package common
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"qback/utils"
+)
+
+const ChunkDirName = ".chunks"
+
+// ChunkStore 按哈希保存内容定义分片，用于增量上传时重建文件
+// 分片由所有标签共用，不计入标签用量，上传时分片副本与文件一起计入磁盘空间和配额，超过保留时间后清理
+type ChunkStore struct {
+	root string
+}
+
+// NewChunkStore 在保存目录下初始化分片存储
+func NewChunkStore(savePath string) (*ChunkStore, error) {
+	root := utils.FileSuite.JoinPath(savePath, ChunkDirName)
+	if _, err := utils.FileSuite.Mkdir(root); err != nil {
+		return nil, fmt.Errorf("create chunk folder failed: %w", err)
+	}
+	return &ChunkStore{root: root}, nil
+}
+
+func (c *ChunkStore) chunkPath(hash string) string {
+	return utils.FileSuite.JoinPath(c.root, hash[:2], hash)
+}
+
+// Has 检查分片是否存在，存在时刷新访问时间以免被清理
+func (c *ChunkStore) Has(hash string) bool {
+	if !IsValidHash(hash) {
+		return false
+	}
+
+	path := c.chunkPath(hash)
+	if !utils.FileSuite.Exists(path) {
+		return false
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return true
+}
+
+// Put 校验分片哈希后写入存储
+func (c *ChunkStore) Put(hash string, data []byte) error {
+	if !IsValidHash(hash) {
+		return fmt.Errorf("invalid hash: %s", hash)
+	}
+
+	actual, err := CalcBlake3FromBytes(data)
+	if err != nil {
+		return err
+	}
+	if actual != hash {
+		return fmt.Errorf("chunk hash mismatch: expected=%s got=%s", hash, actual)
+	}
+
+	path := c.chunkPath(hash)
+	if utils.FileSuite.Exists(path) {
+		return nil
+	}
+
+	dir := filepath.Dir(path)
+	if _, err := utils.FileSuite.Mkdir(dir); err != nil {
+		return fmt.Errorf("create chunk folder failed: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, hash+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create chunk file failed: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write chunk failed: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("close chunk failed: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("store chunk failed: %w", err)
+	}
+
+	return nil
+}
+
+// Open 打开分片用于读取
+func (c *ChunkStore) Open(hash string) (*os.File, error) {
+	if !IsValidHash(hash) {
+		return nil, fmt.Errorf("invalid hash: %s", hash)
+	}
+	return os.Open(c.chunkPath(hash))
+}
+
+// Prune 删除超过 maxAge 未被使用的分片，返回删除数量和释放的字节数
+func (c *ChunkStore) Prune(maxAge time.Duration) (int, int64, error) {
+	var removed int
+	var freed int64
+	deadline := time.Now().Add(-maxAge)
+
+	err := filepath.WalkDir(c.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(deadline) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+		freed += info.Size()
+		return nil
+	})
+	if err != nil {
+		return removed, freed, fmt.Errorf("chunk prune failed: %w", err)
+	}
+
+	return removed, freed, nil
+}
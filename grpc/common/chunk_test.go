@@ -0,0 +1,74 @@
+package common
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestChunkStorePut(t *testing.T) {
+	store, err := NewChunkStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte("chunk data")
+	hash, err := CalcBlake3FromBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := CalcBlake3FromBytes([]byte("other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Put("../escape", data); err == nil {
+		t.Fatal("expected invalid hash error")
+	}
+	if err := store.Put(other, data); err == nil {
+		t.Fatal("expected hash mismatch error")
+	}
+	if store.Has(other) {
+		t.Fatal("mismatched chunk was stored")
+	}
+	if err := store.Put(hash, data); err != nil {
+		t.Fatal(err)
+	}
+	if !store.Has(hash) {
+		t.Fatal("expected chunk to be stored")
+	}
+}
+
+func TestChunkStorePrune(t *testing.T) {
+	store, err := NewChunkStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hashes []string
+	for _, data := range []string{"old chunk", "new chunk"} {
+		hash, err := CalcBlake3FromBytes([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Put(hash, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hash)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(store.chunkPath(hashes[0]), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, freed, err := store.Prune(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 || freed != int64(len("old chunk")) {
+		t.Fatalf("unexpected prune result: removed=%d freed=%d", removed, freed)
+	}
+	if store.Has(hashes[0]) || !store.Has(hashes[1]) {
+		t.Fatal("prune removed the wrong chunk")
+	}
+}
@@ -0,0 +1,33 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"qback/utils"
+)
+
+// maxRenameSuffix 重命名时尝试的最大后缀序号
+const maxRenameSuffix = 10000
+
+// SuffixedFileName 为同名文件生成未被占用的文件名，格式为 name_N.ext
+// 同时创建空文件占位，避免并发上传得到相同的文件名，上传失败时由调用方删除占位文件
+func SuffixedFileName(savePath, fileTag, fileName string) (string, error) {
+	ext := filepath.Ext(fileName)
+	base := strings.TrimSuffix(fileName, ext)
+	for i := 1; i <= maxRenameSuffix; i++ {
+		candidate := fmt.Sprintf("%s_%d%s", base, i, ext)
+		f, err := os.OpenFile(utils.FileSuite.JoinPath(savePath, fileTag, candidate), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("reserve %s failed: %w", candidate, err)
+		}
+		f.Close()
+		return candidate, nil
+	}
+	return "", fmt.Errorf("no free name for %s after %d attempts", fileName, maxRenameSuffix)
+}
@@ -0,0 +1,50 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestSuffixedFileNameConcurrent(t *testing.T) {
+	savePath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(savePath, "tag"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(savePath, "tag", "a.txt"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	const workers = 16
+	names := make(chan string, workers)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			name, err := SuffixedFileName(savePath, "tag", "a.txt")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			names <- name
+		}()
+	}
+	wg.Wait()
+	close(names)
+
+	seen := make(map[string]bool)
+	for name := range names {
+		if seen[name] {
+			t.Fatalf("name %s handed out twice", name)
+		}
+		seen[name] = true
+		if _, err := os.Stat(filepath.Join(savePath, "tag", name)); err != nil {
+			t.Fatalf("name %s not reserved: %v", name, err)
+		}
+	}
+	if len(seen) != workers || !seen["a_1.txt"] {
+		t.Fatalf("unexpected names: %v", seen)
+	}
+}
@@ -0,0 +1,256 @@
+package common
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	transferv1 "qback/internal/pb/qmeta/transfer/v1"
+
+	"github.com/qmaru/minitools/v2/hashx/blake3"
+)
+
+const (
+	deltaMinBlockSize = 2 * 1024
+	deltaMaxBlockSize = 1024 * 1024
+	strongHashSize    = 16
+)
+
+// DeltaInstruction 增量指令，Count 大于 0 时复制旧文件中从 Block 开始的块，
+// 否则为新文件中从 Offset 开始长度为 Length 的新数据
+type DeltaInstruction struct {
+	Block  int64
+	Count  int64
+	Offset int64
+	Length int64
+}
+
+// DeltaBlockSize 根据文件大小计算签名块大小，约为文件大小的平方根
+func DeltaBlockSize(fileSize int64) int {
+	size := int(math.Sqrt(float64(fileSize)))
+	size = (size + 1023) / 1024 * 1024
+	return min(max(size, deltaMinBlockSize), deltaMaxBlockSize)
+}
+
+// WeakChecksum 计算 rsync 弱校验和
+func WeakChecksum(data []byte) uint32 {
+	var a, b uint32
+	for i, x := range data {
+		a += uint32(x)
+		b += uint32(len(data)-i) * uint32(x)
+	}
+	return (a & 0xffff) | (b&0xffff)<<16
+}
+
+// StrongChecksum 计算块的强校验和 (blake3-128)
+func StrongChecksum(data []byte) string {
+	hasher := blake3.New()
+	hasher.SetSize(strongHashSize)
+	hasher.Write(data)
+	return hasher.SumStream().ToHex()
+}
+
+// CalcBlockSignatures 计算文件每个块的弱校验和与强校验和
+func CalcBlockSignatures(filePath string) (*transferv1.BlockSignatures, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	blockSize := DeltaBlockSize(info.Size())
+	reader := bufio.NewReaderSize(f, 1024*1024)
+	buffer := make([]byte, blockSize)
+
+	var blocks []*transferv1.BlockSignature
+	for {
+		n, err := io.ReadFull(reader, buffer)
+		if n > 0 {
+			block := &transferv1.BlockSignature{}
+			block.SetWeak(WeakChecksum(buffer[:n]))
+			block.SetStrong(StrongChecksum(buffer[:n]))
+			blocks = append(blocks, block)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	signatures := &transferv1.BlockSignatures{}
+	signatures.SetBlockSize(int64(blockSize))
+	signatures.SetFileSize(info.Size())
+	signatures.SetBlocks(blocks)
+	return signatures, nil
+}
+
+// ComputeDelta 以滚动校验和对比新文件与旧文件签名，生成增量指令
+func ComputeDelta(r io.Reader, signatures *transferv1.BlockSignatures) ([]DeltaInstruction, error) {
+	blockSize := int(signatures.GetBlockSize())
+	if blockSize <= 0 {
+		return nil, fmt.Errorf("invalid block size: %d", blockSize)
+	}
+
+	index := make(map[uint32][]int64)
+	blocks := signatures.GetBlocks()
+	for i, block := range blocks {
+		index[block.GetWeak()] = append(index[block.GetWeak()], int64(i))
+	}
+	lastBlockSize := int(signatures.GetFileSize() - int64(len(blocks)-1)*int64(blockSize))
+
+	var instructions []DeltaInstruction
+	addLiteral := func(offset, end int64) {
+		if end <= offset {
+			return
+		}
+		if n := len(instructions); n > 0 {
+			last := &instructions[n-1]
+			if last.Count == 0 && last.Offset+last.Length == offset {
+				last.Length += end - offset
+				return
+			}
+		}
+		instructions = append(instructions, DeltaInstruction{Offset: offset, Length: end - offset})
+	}
+	addCopy := func(block int64) {
+		if n := len(instructions); n > 0 {
+			last := &instructions[n-1]
+			if last.Count > 0 && last.Block+last.Count == block {
+				last.Count++
+				return
+			}
+		}
+		instructions = append(instructions, DeltaInstruction{Block: block, Count: 1})
+	}
+	match := func(window []byte, weak uint32) (int64, bool) {
+		candidates, ok := index[weak]
+		if !ok {
+			return 0, false
+		}
+		strong := StrongChecksum(window)
+		for _, block := range candidates {
+			size := blockSize
+			if block == int64(len(blocks)-1) {
+				size = lastBlockSize
+			}
+			if size == len(window) && blocks[block].GetStrong() == strong {
+				return block, true
+			}
+		}
+		return 0, false
+	}
+
+	// buf 保存文件中 [base, base+len(data)) 范围的数据
+	buf := make([]byte, max(4*blockSize, 1024*1024))
+	var data []byte
+	var base int64
+	eof := false
+	ensure := func(pos int64, n int) error {
+		for !eof && pos+int64(n) > base+int64(len(data)) {
+			keep := data[pos-base:]
+			copy(buf, keep)
+			data = buf[:len(keep)]
+			base = pos
+			m, err := io.ReadFull(r, buf[len(data):])
+			data = buf[:len(data)+m]
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				eof = true
+			} else if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var pos, literalStart int64
+	var a, b uint32
+	rolling := false
+	for {
+		if err := ensure(pos, blockSize+1); err != nil {
+			return nil, err
+		}
+		available := base + int64(len(data)) - pos
+		if available < int64(blockSize) {
+			break
+		}
+
+		window := data[pos-base : pos-base+int64(blockSize)]
+		if !rolling {
+			weak := WeakChecksum(window)
+			a, b = weak&0xffff, weak>>16
+			rolling = true
+		}
+
+		if block, ok := match(window, a|b<<16); ok {
+			addLiteral(literalStart, pos)
+			addCopy(block)
+			pos += int64(blockSize)
+			literalStart = pos
+			rolling = false
+			continue
+		}
+
+		if available == int64(blockSize) {
+			break
+		}
+
+		out := uint32(window[0])
+		in := uint32(data[pos-base+int64(blockSize)])
+		a = (a - out + in) & 0xffff
+		b = (b - uint32(blockSize)*out + a) & 0xffff
+		pos++
+	}
+
+	end := base + int64(len(data))
+	if tail := data[pos-base:]; len(tail) > 0 && len(tail) < blockSize {
+		if block, ok := match(tail, WeakChecksum(tail)); ok {
+			addLiteral(literalStart, pos)
+			addCopy(block)
+			literalStart = end
+		}
+	}
+	addLiteral(literalStart, end)
+
+	return instructions, nil
+}
+
+// DeltaOpSize 返回增量指令写入的字节数，复制指令的块范围无效时返回错误
+func DeltaOpSize(signatures *transferv1.BlockSignatures, op *transferv1.DeltaOp) (int64, error) {
+	if data := op.GetData(); len(data) > 0 {
+		return int64(len(data)), nil
+	}
+
+	block, count := op.GetBlock(), op.GetCount()
+	numBlocks := int64(len(signatures.GetBlocks()))
+	if block < 0 || count <= 0 || block+count > numBlocks {
+		return 0, fmt.Errorf("invalid delta block range: block=%d count=%d total=%d", block, count, numBlocks)
+	}
+
+	// 最后一块可能不满一个块大小
+	blockSize := signatures.GetBlockSize()
+	return min((block+count)*blockSize, signatures.GetFileSize()) - block*blockSize, nil
+}
+
+// ApplyDeltaOp 将增量指令写入目标，复制指令从旧文件读取数据
+func ApplyDeltaOp(w io.Writer, basis *os.File, signatures *transferv1.BlockSignatures, op *transferv1.DeltaOp) (int64, error) {
+	if data := op.GetData(); len(data) > 0 {
+		n, err := w.Write(data)
+		return int64(n), err
+	}
+
+	size, err := DeltaOpSize(signatures, op)
+	if err != nil {
+		return 0, err
+	}
+	section := io.NewSectionReader(basis, op.GetBlock()*signatures.GetBlockSize(), size)
+	return io.Copy(w, section)
+}
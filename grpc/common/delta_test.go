@@ -0,0 +1,110 @@
+package common
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	transferv1 "qback/internal/pb/qmeta/transfer/v1"
+)
+
+// applyDelta 按增量指令从旧文件和新数据重建文件，返回重建结果和发送的新数据量
+func applyDelta(t *testing.T, basisPath string, signatures *transferv1.BlockSignatures, target []byte, instructions []DeltaInstruction) ([]byte, int64) {
+	t.Helper()
+	basis, err := os.Open(basisPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer basis.Close()
+
+	var out bytes.Buffer
+	var literal int64
+	for _, ins := range instructions {
+		op := &transferv1.DeltaOp{}
+		if ins.Count > 0 {
+			op.SetBlock(ins.Block)
+			op.SetCount(ins.Count)
+		} else {
+			op.SetData(target[ins.Offset : ins.Offset+ins.Length])
+			literal += ins.Length
+		}
+		if _, err := ApplyDeltaOp(&out, basis, signatures, op); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return out.Bytes(), literal
+}
+
+func TestDeltaRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	original := make([]byte, 512*1024+123)
+	rng.Read(original)
+	insert := []byte("inserted in the middle")
+
+	splice := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+	for _, tc := range []struct {
+		name   string
+		target []byte
+	}{
+		{"identical", original},
+		{"insert", splice(original[:200000], insert, original[200000:])},
+		{"delete", splice(original[:100000], original[150000:])},
+		{"append", splice(original, []byte("appended tail"))},
+		{"prepend", splice(insert, original)},
+		{"truncate", original[:300001]},
+		{"edits", splice(original[:1000], insert, original[5000:400000], original[410000:], insert)},
+		{"empty", nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			basisPath := filepath.Join(t.TempDir(), "basis")
+			if err := os.WriteFile(basisPath, original, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			signatures, err := CalcBlockSignatures(basisPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			instructions, err := ComputeDelta(bytes.NewReader(tc.target), signatures)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rebuilt, literal := applyDelta(t, basisPath, signatures, tc.target, instructions)
+			if !bytes.Equal(rebuilt, tc.target) {
+				t.Fatalf("rebuilt %d bytes, want %d", len(rebuilt), len(tc.target))
+			}
+
+			// 小范围修改只应发送少量新数据
+			if limit := int64(len(tc.target)) / 10; len(tc.target) > 0 && literal > limit {
+				t.Fatalf("sent %d literal bytes for a small edit", literal)
+			}
+		})
+	}
+}
+
+func TestApplyDeltaOpInvalidRange(t *testing.T) {
+	basisPath := filepath.Join(t.TempDir(), "basis")
+	if err := os.WriteFile(basisPath, make([]byte, 10000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	signatures, err := CalcBlockSignatures(basisPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	basis, err := os.Open(basisPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer basis.Close()
+
+	op := &transferv1.DeltaOp{}
+	op.SetBlock(int64(len(signatures.GetBlocks())))
+	op.SetCount(1)
+	if _, err := ApplyDeltaOp(&bytes.Buffer{}, basis, signatures, op); err == nil {
+		t.Fatal("expected out of range block error")
+	}
+}
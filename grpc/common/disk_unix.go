@@ -0,0 +1,16 @@
+//go:build unix
+
+package common
+
+import "syscall"
+
+// DiskUsage 获取路径所在磁盘的总容量和可用空间
+func DiskUsage(path string) (total int64, free int64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+
+	bsize := int64(st.Bsize)
+	return int64(st.Blocks) * bsize, int64(st.Bavail) * bsize, nil
+}
@@ -0,0 +1,20 @@
+//go:build windows
+
+package common
+
+import "golang.org/x/sys/windows"
+
+// DiskUsage 获取路径所在磁盘的总容量和可用空间
+func DiskUsage(path string) (total int64, free int64, err error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var freeAvailable, totalBytes, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &freeAvailable, &totalBytes, &totalFree); err != nil {
+		return 0, 0, err
+	}
+
+	return int64(totalBytes), int64(freeAvailable), nil
+}
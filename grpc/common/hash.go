@@ -3,7 +3,7 @@ package common
 import (
 	"os"
 
-	"qback/utils"
+	"github.com/qmaru/minitools/v2/hashx/blake3"
 )
 
 // CalcBlake3 计算文件 blake3-256
@@ -14,25 +14,33 @@ func CalcBlake3(filepath string) (string, error) {
 	}
 	defer f.Close()
 
-	utils.Blake3Suite.Reset()
+	hasher := blake3.New()
 
-	_, err = utils.Blake3Suite.WriteFrom(f)
+	_, err = hasher.WriteFrom(f)
 	if err != nil {
 		return "", err
 	}
 
-	bhash := utils.Blake3Suite.SumStream()
+	bhash := hasher.SumStream()
 	return bhash.ToHex(), nil
 }
 
 func CalcBlake3FromBytes(data []byte) (string, error) {
-	utils.Blake3Suite.Reset()
+	hasher := blake3.New()
 
-	_, err := utils.Blake3Suite.Write(data)
+	_, err := hasher.Write(data)
 	if err != nil {
 		return "", err
 	}
 
-	bhash := utils.Blake3Suite.SumStream()
+	bhash := hasher.SumStream()
 	return bhash.ToHex(), nil
 }
+
+// NewHasher 创建独立的 blake3-256 流式哈希，用于边传输边计算
+// 先写入空数据完成初始化，保证空文件也能得到正确的哈希
+func NewHasher() *blake3.Blake3Basic {
+	hasher := blake3.New()
+	hasher.Write(nil)
+	return hasher
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+package common
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// linkCount 获取文件的硬链接数
+func linkCount(path string) (uint64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, fmt.Errorf("unsupported file info for %s", path)
+	}
+	return uint64(st.Nlink), nil
+}
@@ -1,16 +1,205 @@
 package common
 
 import (
+	"encoding/json"
 	"fmt"
-	"log"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"qback/utils"
+)
+
+// 进度输出方式
+const (
+	// ProgressAuto 输出到终端时显示进度条，否则定期打印一行
+	ProgressAuto  = "auto"
+	ProgressBar   = "bar"
+	ProgressPlain = "plain"
+	// ProgressJSON 每行一个 JSON 事件，供其他工具解析
+	ProgressJSON = "json"
+	ProgressNone = "none"
 )
 
-// ShowProgress 显示进度条
-func ShowProgress(chunk int64, filechunks int64) {
-	progress := float64(chunk) / float64(filechunks) * 100
-	if progress != 0 {
-		log.Printf("Progress: %.2f%%\n", progress)
+const (
+	barInterval   = 200 * time.Millisecond
+	plainInterval = 5 * time.Second
+	jsonInterval  = 500 * time.Millisecond
+	barWidth      = 24
+)
+
+// ParseProgressMode 检查进度输出方式，为空时使用 auto
+func ParseProgressMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return ProgressAuto, nil
+	case ProgressAuto, ProgressBar, ProgressPlain, ProgressJSON, ProgressNone:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid progress mode %q, use auto, bar, plain, json or none", mode)
+}
+
+// IsTerminal 判断 w 是否为终端
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// ProgressEvent json 方式输出的事件，Event 为 progress、done 或 error
+type ProgressEvent struct {
+	Event      string  `json:"event"`
+	Op         string  `json:"op"`
+	Tag        string  `json:"tag"`
+	Name       string  `json:"name"`
+	TransferID string  `json:"transfer_id,omitempty"`
+	Done       int64   `json:"done"`
+	Total      int64   `json:"total,omitempty"`
+	Rate       float64 `json:"rate"`
+	ETA        float64 `json:"eta_seconds,omitempty"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// ProgressReporter 输出单个传输的进度，Update 按输出方式限制频率，结束时调用 Finish
+type ProgressReporter struct {
+	w     io.Writer
+	mode  string
+	event ProgressEvent
+
+	mu       sync.Mutex
+	start    time.Time
+	last     time.Time
+	printed  bool
+	finished bool
+	// lastDone 最后一次输出时的进度
+	lastDone int64
+}
+
+// NewProgressReporter 创建进度输出，auto 根据 w 是否为终端选择 bar 或 plain
+func NewProgressReporter(w io.Writer, mode, op, tag, name, transferID string) *ProgressReporter {
+	if mode == "" || mode == ProgressAuto {
+		mode = ProgressPlain
+		if IsTerminal(w) {
+			mode = ProgressBar
+		}
+	}
+	return &ProgressReporter{
+		w:     w,
+		mode:  mode,
+		event: ProgressEvent{Op: op, Tag: tag, Name: name, TransferID: transferID},
+		start: time.Now(),
+	}
+}
+
+// Update 更新进度，total 为 0 表示总大小未知
+func (p *ProgressReporter) Update(done, total int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.finished || p.mode == ProgressNone {
+		return
+	}
+	p.event.Done = done
+	p.event.Total = total
+
+	now := time.Now()
+	interval := barInterval
+	switch p.mode {
+	case ProgressPlain:
+		interval = plainInterval
+	case ProgressJSON:
+		interval = jsonInterval
+	}
+	if p.printed && now.Sub(p.last) < interval && (total == 0 || done < total) {
+		return
+	}
+	p.last = now
+	p.printed = true
+	p.write("progress", now)
+}
+
+// Finish 输出最终进度，err 不为空时记为失败
+func (p *ProgressReporter) Finish(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.finished || p.mode == ProgressNone {
+		return
+	}
+	p.finished = true
+
+	event := "done"
+	if err != nil {
+		event = "error"
+		p.event.Error = err.Error()
+	}
+	switch p.mode {
+	case ProgressBar:
+		// 进度条已显示时换行保留最后的状态
+		if p.printed {
+			p.write(event, time.Now())
+			fmt.Fprintln(p.w)
+		}
+	case ProgressPlain:
+		if err == nil && p.printed && p.lastDone != p.event.Done {
+			p.write(event, time.Now())
+		}
+	default:
+		p.write(event, time.Now())
+	}
+}
+
+func (p *ProgressReporter) write(event string, now time.Time) {
+	elapsed := now.Sub(p.start).Seconds()
+	p.lastDone = p.event.Done
+	p.event.Event = event
+	p.event.Rate = 0
+	p.event.ETA = 0
+	if elapsed > 0 {
+		p.event.Rate = float64(p.event.Done) / elapsed
+	}
+	if p.event.Rate > 0 && p.event.Total > p.event.Done {
+		p.event.ETA = float64(p.event.Total-p.event.Done) / p.event.Rate
+	}
+
+	switch p.mode {
+	case ProgressJSON:
+		line, _ := json.Marshal(p.event)
+		fmt.Fprintf(p.w, "%s\n", line)
+	case ProgressBar:
+		fmt.Fprintf(p.w, "\r\033[K%s", p.line(true))
+	default:
+		fmt.Fprintln(p.w, p.line(false))
+	}
+}
+
+// line 返回一行进度，如 "upload db.tar [=====>    ]  45% 12 MB/27 MB 5.20 MB/s ETA 3s"
+func (p *ProgressReporter) line(bar bool) string {
+	e := p.event
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s ", e.Op, e.Name)
+	if e.Total > 0 {
+		percent := float64(e.Done) / float64(e.Total)
+		if bar {
+			filled := min(int(percent*barWidth), barWidth)
+			b.WriteString("[" + strings.Repeat("=", filled))
+			if filled < barWidth {
+				b.WriteString(">" + strings.Repeat(" ", barWidth-filled-1))
+			}
+			b.WriteString("] ")
+		}
+		fmt.Fprintf(&b, "%3.0f%% %s/%s", percent*100, utils.PrettySize(e.Done), utils.PrettySize(e.Total))
+	} else {
+		b.WriteString(utils.PrettySize(e.Done))
+	}
+	fmt.Fprintf(&b, " %s", FormatSpeed(e.Rate))
+	if e.ETA > 0 {
+		fmt.Fprintf(&b, " ETA %s", (time.Duration(e.ETA) * time.Second).String())
 	}
+	return b.String()
 }
 
 func FormatSpeed(bytesPerSecond float64) string {
@@ -0,0 +1,118 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// progressLines 返回输出中的非空行，进度条的 \r 刷新也算作一行
+func progressLines(buf *bytes.Buffer) []string {
+	var lines []string
+	for _, line := range strings.FieldsFunc(buf.String(), func(r rune) bool { return r == '\n' || r == '\r' }) {
+		if line = strings.TrimPrefix(line, "\033[K"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func TestProgressThrottle(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewProgressReporter(&buf, ProgressJSON, "upload", "t", "a.bin", "id")
+
+	p.Update(10, 100)
+	p.Update(20, 100)
+	p.Update(30, 100)
+	if n := len(progressLines(&buf)); n != 1 {
+		t.Fatalf("expected updates within the interval to be dropped, got %d lines", n)
+	}
+
+	// 间隔过后再次输出
+	p.last = p.last.Add(-jsonInterval)
+	p.Update(40, 100)
+	// 完成时不受间隔限制
+	p.Update(100, 100)
+
+	lines := progressLines(&buf)
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %q", lines)
+	}
+	var event ProgressEvent
+	if err := json.Unmarshal([]byte(lines[2]), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Event != "progress" || event.Done != 100 || event.Total != 100 || event.TransferID != "id" || event.ETA != 0 {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+}
+
+func TestProgressUnknownTotal(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewProgressReporter(&buf, ProgressPlain, "upload", "t", "stdin", "")
+
+	// 总大小未知时每次都受间隔限制
+	p.Update(10, 0)
+	p.Update(20, 0)
+	if lines := progressLines(&buf); len(lines) != 1 || strings.Contains(lines[0], "%") || strings.Contains(lines[0], "ETA") {
+		t.Fatalf("unexpected output: %q", lines)
+	}
+
+	p.Finish(nil)
+	lines := progressLines(&buf)
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "upload stdin 20 B") {
+		t.Fatalf("expected final line with latest size, got %q", lines)
+	}
+}
+
+func TestProgressFinish(t *testing.T) {
+	cases := []struct {
+		name    string
+		mode    string
+		updates []int64
+		err     error
+		lines   int
+		last    string
+	}{
+		{"bar without updates", ProgressBar, nil, nil, 0, ""},
+		{"bar keeps last state", ProgressBar, []int64{10, 20}, nil, 2, "upload a.bin ["},
+		{"bar error", ProgressBar, []int64{10}, errors.New("boom"), 2, "upload a.bin ["},
+		{"plain without updates", ProgressPlain, nil, nil, 0, ""},
+		{"plain already printed", ProgressPlain, []int64{10}, nil, 1, "upload a.bin  10%"},
+		{"plain prints latest", ProgressPlain, []int64{10, 20}, nil, 2, "upload a.bin  20%"},
+		{"plain error", ProgressPlain, []int64{10, 20}, errors.New("boom"), 1, "upload a.bin  10%"},
+		{"json done", ProgressJSON, nil, nil, 1, `{"event":"done"`},
+		{"json error", ProgressJSON, []int64{10}, errors.New("boom"), 2, `{"event":"error"`},
+		{"none", ProgressNone, []int64{10}, errors.New("boom"), 0, ""},
+		{"auto is plain for buffers", ProgressAuto, []int64{10, 20}, nil, 2, "upload a.bin  20%"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			p := NewProgressReporter(&buf, tc.mode, "upload", "t", "a.bin", "")
+			for _, done := range tc.updates {
+				p.Update(done, 100)
+			}
+			p.Finish(tc.err)
+			// 结束后的调用不再输出
+			p.Finish(nil)
+			p.Update(100, 100)
+
+			lines := progressLines(&buf)
+			if len(lines) != tc.lines {
+				t.Fatalf("expected %d lines, got %q", tc.lines, lines)
+			}
+			if tc.lines > 0 && !strings.HasPrefix(lines[len(lines)-1], tc.last) {
+				t.Fatalf("expected last line to start with %q, got %q", tc.last, lines[len(lines)-1])
+			}
+			if tc.mode == ProgressJSON && tc.err != nil && !strings.Contains(lines[len(lines)-1], `"error":"boom"`) {
+				t.Fatalf("expected error message, got %q", lines[len(lines)-1])
+			}
+			if tc.mode == ProgressBar && tc.lines > 0 && !strings.HasSuffix(buf.String(), "\n") {
+				t.Fatal("expected bar to end with a newline")
+			}
+		})
+	}
+}
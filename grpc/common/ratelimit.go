@@ -0,0 +1,162 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"qback/utils"
+
+	"golang.org/x/time/rate"
+)
+
+// RateWindow 每天 Start 到 End 之间使用的速率，End 小于 Start 时跨过零点
+type RateWindow struct {
+	Start time.Duration
+	End   time.Duration
+	// Rate 字节每秒，为 0 时不限制
+	Rate int64
+}
+
+func (w RateWindow) contains(t time.Time) bool {
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if w.Start <= w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// RateSchedule 限速计划，不在任何时间段内时使用 Rate，为 0 表示不限制
+type RateSchedule struct {
+	Rate    int64
+	Windows []RateWindow
+}
+
+// ParseRateSchedule 解析限速参数，如 "10M" 或 "10M,08:00-18:00=2M,22:00-06:00=0"
+// 第一项为默认速率，可以省略，之后每项为一个时间段，使用本地时间，按顺序匹配
+func ParseRateSchedule(value string) (RateSchedule, error) {
+	var schedule RateSchedule
+	if value == "" {
+		return schedule, nil
+	}
+	for i, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		period, limit, ok := strings.Cut(item, "=")
+		if !ok {
+			if i > 0 {
+				return schedule, fmt.Errorf("invalid rate window %q, use HH:MM-HH:MM=rate", item)
+			}
+			rate, err := utils.ParseSize(item)
+			if err != nil {
+				return schedule, fmt.Errorf("invalid rate %q: %w", item, err)
+			}
+			schedule.Rate = rate
+			continue
+		}
+
+		start, end, ok := strings.Cut(period, "-")
+		if !ok {
+			return schedule, fmt.Errorf("invalid rate window %q, use HH:MM-HH:MM=rate", item)
+		}
+		window := RateWindow{}
+		var err error
+		if window.Start, err = parseClock(start); err != nil {
+			return schedule, err
+		}
+		if window.End, err = parseClock(end); err != nil {
+			return schedule, err
+		}
+		if window.Rate, err = utils.ParseSize(limit); err != nil {
+			return schedule, fmt.Errorf("invalid rate %q: %w", limit, err)
+		}
+		schedule.Windows = append(schedule.Windows, window)
+	}
+	return schedule, nil
+}
+
+// parseClock 解析 HH:MM，返回距零点的时长
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, use HH:MM", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Enabled 是否有任何时间段需要限速
+func (s RateSchedule) Enabled() bool {
+	if s.Rate > 0 {
+		return true
+	}
+	for _, window := range s.Windows {
+		if window.Rate > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// RateAt 返回 t 时刻的速率
+func (s RateSchedule) RateAt(t time.Time) int64 {
+	for _, window := range s.Windows {
+		if window.contains(t) {
+			return window.Rate
+		}
+	}
+	return s.Rate
+}
+
+// RateLimiter 按限速计划调整速率的令牌桶，可在多个传输间共用
+type RateLimiter struct {
+	schedule RateSchedule
+
+	mu      sync.Mutex
+	limiter *rate.Limiter
+	current int64
+}
+
+// NewRateLimiter 创建限速器，计划中没有限速时返回 nil，nil 的 WaitN 直接返回
+func NewRateLimiter(schedule RateSchedule) *RateLimiter {
+	if !schedule.Enabled() {
+		return nil
+	}
+	return &RateLimiter{schedule: schedule, limiter: rate.NewLimiter(rate.Inf, 0), current: -1}
+}
+
+// update 根据当前时间调整速率，桶容量为一秒的数据量
+func (l *RateLimiter) update(now time.Time) (*rate.Limiter, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	current := l.schedule.RateAt(now)
+	if current != l.current {
+		l.current = current
+		if current <= 0 {
+			l.limiter.SetLimitAt(now, rate.Inf)
+		} else {
+			l.limiter.SetLimitAt(now, rate.Limit(current))
+			l.limiter.SetBurstAt(now, int(current))
+		}
+	}
+	return l.limiter, int(l.current)
+}
+
+// WaitN 等待发送 n 字节，超过桶容量时分多次等待
+func (l *RateLimiter) WaitN(ctx context.Context, n int) error {
+	if l == nil {
+		return nil
+	}
+	for n > 0 {
+		limiter, burst := l.update(time.Now())
+		if burst <= 0 {
+			return nil
+		}
+		take := min(n, burst)
+		if err := limiter.WaitN(ctx, take); err != nil {
+			return err
+		}
+		n -= take
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package common
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestParseRateSchedule(t *testing.T) {
+	cases := []struct {
+		value    string
+		schedule RateSchedule
+		ok       bool
+	}{
+		{"", RateSchedule{}, true},
+		{"10M", RateSchedule{Rate: 10 << 20}, true},
+		{"10M, 08:00-18:00=2M, 22:00-06:00=0", RateSchedule{Rate: 10 << 20, Windows: []RateWindow{
+			{Start: 8 * time.Hour, End: 18 * time.Hour, Rate: 2 << 20},
+			{Start: 22 * time.Hour, End: 6 * time.Hour, Rate: 0},
+		}}, true},
+		// 省略默认速率时不在时间段内不限速
+		{"08:30-09:15=1K", RateSchedule{Windows: []RateWindow{
+			{Start: 8*time.Hour + 30*time.Minute, End: 9*time.Hour + 15*time.Minute, Rate: 1024},
+		}}, true},
+		{"fast", RateSchedule{}, false},
+		{"10M,2M", RateSchedule{}, false},
+		{"10M,08:00=2M", RateSchedule{}, false},
+		{"10M,8-18=2M", RateSchedule{}, false},
+		{"10M,08:00-24:00=2M", RateSchedule{}, false},
+		{"10M,08:00-18:00=slow", RateSchedule{}, false},
+	}
+	for _, tc := range cases {
+		schedule, err := ParseRateSchedule(tc.value)
+		if (err == nil) != tc.ok {
+			t.Errorf("ParseRateSchedule(%q) error = %v", tc.value, err)
+			continue
+		}
+		if tc.ok && (schedule.Rate != tc.schedule.Rate || !slices.Equal(schedule.Windows, tc.schedule.Windows)) {
+			t.Errorf("ParseRateSchedule(%q) = %+v, want %+v", tc.value, schedule, tc.schedule)
+		}
+	}
+}
+
+func TestRateWindowContains(t *testing.T) {
+	day := RateWindow{Start: 8 * time.Hour, End: 18 * time.Hour, Rate: 1}
+	night := RateWindow{Start: 22 * time.Hour, End: 6 * time.Hour, Rate: 2}
+
+	cases := []struct {
+		clock string
+		day   bool
+		night bool
+	}{
+		{"00:00:00", false, true},
+		{"05:59:59", false, true},
+		{"06:00:00", false, false},
+		{"07:59:59", false, false},
+		{"08:00:00", true, false},
+		{"17:59:59", true, false},
+		{"18:00:00", false, false},
+		{"21:59:59", false, false},
+		{"22:00:00", false, true},
+		{"23:59:59", false, true},
+	}
+	for _, tc := range cases {
+		now, err := time.ParseInLocation(time.DateTime, "2026-03-01 "+tc.clock, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := day.contains(now); got != tc.day {
+			t.Errorf("day window contains %s = %v", tc.clock, got)
+		}
+		if got := night.contains(now); got != tc.night {
+			t.Errorf("night window contains %s = %v", tc.clock, got)
+		}
+
+		schedule := RateSchedule{Rate: 3, Windows: []RateWindow{day, night}}
+		want := int64(3)
+		if tc.day {
+			want = 1
+		} else if tc.night {
+			want = 2
+		}
+		if got := schedule.RateAt(now); got != want {
+			t.Errorf("RateAt(%s) = %d, want %d", tc.clock, got, want)
+		}
+	}
+}
+
+func TestRateLimiterWaitN(t *testing.T) {
+	if NewRateLimiter(RateSchedule{Windows: []RateWindow{{Start: 0, End: time.Hour}}}) != nil {
+		t.Fatal("expected nil limiter for a schedule without rates")
+	}
+	var unlimited *RateLimiter
+	if err := unlimited.WaitN(context.Background(), 1<<30); err != nil {
+		t.Fatal(err)
+	}
+
+	// 超过桶容量的请求按容量拆分，不会报错
+	limiter := NewRateLimiter(RateSchedule{Rate: 1000})
+	start := time.Now()
+	if err := limiter.WaitN(context.Background(), 1200); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 1100*time.Millisecond || elapsed > 3*time.Second {
+		t.Fatalf("expected about 1.2s for 1200 bytes at 1000 B/s, took %s", elapsed)
+	}
+}
+
+func TestRateLimiterSwitch(t *testing.T) {
+	limited := RateSchedule{Rate: 1000}
+	limiter := NewRateLimiter(limited)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := limiter.WaitN(ctx, 1000); err == nil {
+		t.Fatal("expected limited wait to exceed the deadline")
+	}
+	if l := limiter.limiter; l.Limit() != 1000 || l.Burst() != 1000 {
+		t.Fatalf("unexpected limit %v burst %d", l.Limit(), l.Burst())
+	}
+
+	// 切换到不限速的时间段后直接返回
+	limiter.schedule = RateSchedule{}
+	if err := limiter.WaitN(ctx, 1<<30); err != nil {
+		t.Fatal(err)
+	}
+	if limiter.limiter.Limit() != rate.Inf {
+		t.Fatalf("expected unlimited, got %v", limiter.limiter.Limit())
+	}
+
+	// 切回限速时重新设置速率
+	limiter.schedule = limited
+	limiter.update(time.Now())
+	if l := limiter.limiter; l.Limit() != 1000 || l.Burst() != 1000 {
+		t.Fatalf("unexpected limit %v burst %d after switching back", l.Limit(), l.Burst())
+	}
+}
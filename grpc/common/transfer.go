@@ -0,0 +1,34 @@
+package common
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const (
+	// TransferIDHeader 传输 ID 的 gRPC 请求头，服务端在响应头中返回同样的值
+	TransferIDHeader = "x-transfer-id"
+	maxTransferIDLen = 64
+)
+
+// NewTransferID 生成随机的传输 ID
+func NewTransferID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ValidTransferID 传输 ID 只能包含字母、数字、- 和 _，避免污染日志
+func ValidTransferID(id string) bool {
+	if id == "" || len(id) > maxTransferIDLen {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
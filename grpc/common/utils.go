@@ -2,20 +2,30 @@ package common
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 
 	transferv1 "qback/internal/pb/qmeta/transfer/v1"
 	"qback/utils"
 )
 
 type FileValidationInfo struct {
-	Data         []byte
-	FilePath     string
+	FilePath string
+	// ActualSize 和 ActualHash 为内存模式下接收时计算的大小和哈希
+	ActualSize   int64
+	ActualHash   string
 	ExpectedSize int64
 	ExpectedHash string
 	IsMemory     bool
 }
 
+const TempDirName = ".tmp"
+
+// StdioPath 作为文件路径时表示标准输入或标准输出
+const StdioPath = "-"
+
 type FileMode int
 
 const (
@@ -31,8 +41,9 @@ func GetFileList(savePath, fileTag string) ([]*transferv1.ListFileItem, error) {
 
 	targetFolder := utils.FileSuite.JoinPath(savePath, fileTag)
 
+	// 标签目录不存在时视为空标签，便于同步到新标签
 	if !utils.FileSuite.Exists(targetFolder) {
-		return nil, fmt.Errorf("folder not exists")
+		return nil, nil
 	}
 
 	files, err := os.ReadDir(targetFolder)
@@ -71,6 +82,98 @@ func GetFileList(savePath, fileTag string) ([]*transferv1.ListFileItem, error) {
 	return fileList, nil
 }
 
+// GetTagList 获取保存目录下的所有标签
+func GetTagList(savePath string) ([]string, error) {
+	if savePath == "" {
+		return nil, fmt.Errorf("savePath is empty")
+	}
+
+	entries, err := os.ReadDir(savePath)
+	if err != nil {
+		return nil, fmt.Errorf("read dir failed: %w", err)
+	}
+
+	var tags []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			tags = append(tags, entry.Name())
+		}
+	}
+
+	return tags, nil
+}
+
+// GetTagUsage 统计标签下文件(含历史版本)的总大小和当前文件数量，标签不存在时返回 0
+func GetTagUsage(savePath, fileTag string) (int64, int64, error) {
+	if savePath == "" || fileTag == "" {
+		return 0, 0, fmt.Errorf("savePath or fileTag is empty")
+	}
+
+	targetFolder := utils.FileSuite.JoinPath(savePath, fileTag)
+	if !utils.FileSuite.Exists(targetFolder) {
+		return 0, 0, nil
+	}
+
+	files, err := os.ReadDir(targetFolder)
+	if err != nil {
+		return 0, 0, fmt.Errorf("read dir failed: %w", err)
+	}
+
+	var used, count int64
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			return 0, 0, fmt.Errorf("get file info failed: %w", err)
+		}
+		used += info.Size()
+		count++
+	}
+
+	// 历史版本同样占用配额
+	versionFolder := utils.FileSuite.JoinPath(targetFolder, VersionDirName)
+	err = filepath.WalkDir(versionFolder, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		used += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("walk version dir failed: %w", err)
+	}
+
+	return used, count, nil
+}
+
+// IsReservedTag 以 . 开头的标签为内部目录保留
+func IsReservedTag(fileTag string) bool {
+	return strings.HasPrefix(fileTag, ".")
+}
+
+// IsSafeName 检查标签或文件名不包含路径分隔符
+func IsSafeName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// IsValidName 检查客户端提供的标签或文件名，不能包含路径分隔符，也不能以 . 开头
+// 以 . 开头的名称保留给 .blobs、.chunks、.versions 等内部目录
+func IsValidName(name string) bool {
+	return IsSafeName(name) && !strings.HasPrefix(name, ".")
+}
+
 // SetTargetFilePath 设置文件路径
 func SetTargetFilePath(savePath, fileTag, fileName string) (string, error) {
 	if savePath == "" || fileTag == "" || fileName == "" {
@@ -120,6 +223,49 @@ func FileIsExist(savePath, fileTag, fileName, fileHash string) (bool, error) {
 	return true, nil
 }
 
+// CreateTempFile 在保存目录下创建接收用的临时文件，接收完成后再移动到目标路径
+func CreateTempFile(savePath string) (*os.File, error) {
+	tmpFolder := utils.FileSuite.JoinPath(savePath, TempDirName)
+	if _, err := utils.FileSuite.Mkdir(tmpFolder); err != nil {
+		return nil, fmt.Errorf("create temp folder failed: %w", err)
+	}
+
+	f, err := os.CreateTemp(tmpFolder, "upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("create temp file failed: %w", err)
+	}
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("chmod temp file failed: %w", err)
+	}
+	return f, nil
+}
+
+// CreatePartFile 在目标文件同目录下创建下载用的临时文件，完成后再替换目标文件
+func CreatePartFile(targetFilePath string) (*os.File, error) {
+	dir := filepath.Dir(targetFilePath)
+	if _, err := utils.FileSuite.Mkdir(dir); err != nil {
+		return nil, fmt.Errorf("create folder failed: %w", err)
+	}
+
+	f, err := os.CreateTemp(dir, "."+filepath.Base(targetFilePath)+".*.part")
+	if err != nil {
+		return nil, fmt.Errorf("create part file failed: %w", err)
+	}
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("chmod part file failed: %w", err)
+	}
+	return f, nil
+}
+
+// CleanTempFiles 清理中断上传遗留的临时文件
+func CleanTempFiles(savePath string) error {
+	return os.RemoveAll(utils.FileSuite.JoinPath(savePath, TempDirName))
+}
+
 // OpenTargetFile 设置文件保存信息
 func OpenTargetFile(targetFilePath string, mode FileMode) (*os.File, error) {
 	var fileMode int
@@ -146,8 +292,8 @@ func ValidateFileIntegrity(info FileValidationInfo) error {
 	var err error
 
 	if info.IsMemory {
-		recHash, err = CalcBlake3FromBytes(info.Data)
-		actualSize = int64(len(info.Data))
+		recHash = info.ActualHash
+		actualSize = info.ActualSize
 	} else {
 		recHash, err = CalcBlake3(info.FilePath)
 		if err == nil {
@@ -0,0 +1,130 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	transferv1 "qback/internal/pb/qmeta/transfer/v1"
+	"qback/utils"
+)
+
+const (
+	VersionDirName  = ".versions"
+	versionIDLayout = "20060102T150405.000000000Z"
+)
+
+// versionFolder 文件历史版本目录 savePath/tag/.versions/name
+func versionFolder(savePath, fileTag, fileName string) string {
+	return utils.FileSuite.JoinPath(savePath, fileTag, VersionDirName, fileName)
+}
+
+// GetVersionPath 获取文件指定历史版本的路径
+func GetVersionPath(savePath, fileTag, fileName, versionID string) (string, error) {
+	if _, err := time.Parse(versionIDLayout, versionID); err != nil {
+		return "", fmt.Errorf("invalid version id: %s", versionID)
+	}
+	return utils.FileSuite.JoinPath(versionFolder(savePath, fileTag, fileName), versionID), nil
+}
+
+// ArchiveFileVersion 将当前文件保存为历史版本，返回版本号
+// 优先使用硬链接，替换当前文件时不会出现文件缺失
+func ArchiveFileVersion(savePath, fileTag, fileName string) (string, error) {
+	currentPath := utils.FileSuite.JoinPath(savePath, fileTag, fileName)
+	if !utils.FileSuite.Exists(currentPath) {
+		return "", nil
+	}
+
+	folder := versionFolder(savePath, fileTag, fileName)
+	if _, err := utils.FileSuite.Mkdir(folder); err != nil {
+		return "", fmt.Errorf("create version folder failed: %w", err)
+	}
+
+	versionID := time.Now().UTC().Format(versionIDLayout)
+	versionPath := utils.FileSuite.JoinPath(folder, versionID)
+	if err := os.Link(currentPath, versionPath); err != nil {
+		if err := os.Rename(currentPath, versionPath); err != nil {
+			return "", fmt.Errorf("archive version failed: %w", err)
+		}
+	}
+
+	return versionID, nil
+}
+
+// GetFileVersions 列出文件的历史版本，按时间从新到旧排列
+func GetFileVersions(savePath, fileTag, fileName string) ([]*transferv1.FileVersion, error) {
+	folder := versionFolder(savePath, fileTag, fileName)
+	if !utils.FileSuite.Exists(folder) {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, fmt.Errorf("read version dir failed: %w", err)
+	}
+
+	var versions []*transferv1.FileVersion
+	for _, entry := range slices.Backward(entries) {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("get version info failed: %w", err)
+		}
+		hash, err := CalcBlake3(utils.FileSuite.JoinPath(folder, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("calc version hash failed: %w", err)
+		}
+
+		version := &transferv1.FileVersion{}
+		version.SetVersionId(entry.Name())
+		version.SetSize(info.Size())
+		version.SetHash(hash)
+		version.SetModifiedTime(info.ModTime().Unix())
+		versions = append(versions, version)
+	}
+
+	return versions, nil
+}
+
+// PruneFileVersions 保留最新的 keep 个版本并删除超过 maxAge 的版本，maxAge 为 0 时不按时间清理
+func PruneFileVersions(savePath, fileTag, fileName string, keep int, maxAge time.Duration) (int, error) {
+	folder := versionFolder(savePath, fileTag, fileName)
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("read version dir failed: %w", err)
+	}
+
+	var removed int
+	kept := 0
+	for _, entry := range slices.Backward(entries) {
+		expired := false
+		if maxAge > 0 {
+			created, err := time.Parse(versionIDLayout, entry.Name())
+			expired = err == nil && time.Since(created) > maxAge
+		}
+		if kept < keep && !expired {
+			kept++
+			continue
+		}
+		if err := os.RemoveAll(utils.FileSuite.JoinPath(folder, entry.Name())); err != nil {
+			return removed, fmt.Errorf("remove version failed: %w", err)
+		}
+		removed++
+	}
+
+	if kept == 0 {
+		_ = os.Remove(folder)
+	}
+	return removed, nil
+}
+
+// RemoveFileVersions 删除文件的全部历史版本
+func RemoveFileVersions(savePath, fileTag, fileName string) error {
+	return os.RemoveAll(versionFolder(savePath, fileTag, fileName))
+}
@@ -0,0 +1,130 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"qback/utils"
+)
+
+func TestArchiveFileVersion(t *testing.T) {
+	savePath := t.TempDir()
+	current := filepath.Join(savePath, "tag", "a.txt")
+	if err := os.MkdirAll(filepath.Dir(current), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if id, err := ArchiveFileVersion(savePath, "tag", "a.txt"); err != nil || id != "" {
+		t.Fatalf("archiving a missing file: id=%q err=%v", id, err)
+	}
+
+	var ids []string
+	for _, content := range []string{"v1", "v2"} {
+		if err := os.WriteFile(current, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		id, err := ArchiveFileVersion(savePath, "tag", "a.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(id) != len(versionIDLayout) {
+			t.Fatalf("unexpected version id %q", id)
+		}
+		ids = append(ids, id)
+		// 归档后当前文件仍然存在
+		if !utils.FileSuite.Exists(current) {
+			t.Fatal("current file missing after archive")
+		}
+		if err := os.Remove(current); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	versions, err := GetFileVersions(savePath, "tag", "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0].GetVersionId() != ids[1] || versions[1].GetVersionId() != ids[0] {
+		t.Fatalf("expected newest first, got %v", versions)
+	}
+	if versions[0].GetSize() != 2 {
+		t.Fatalf("unexpected version size %d", versions[0].GetSize())
+	}
+
+	path, err := GetVersionPath(savePath, "tag", "a.txt", ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "v1" {
+		t.Fatalf("unexpected version content %q, err=%v", data, err)
+	}
+	if _, err := GetVersionPath(savePath, "tag", "a.txt", "../a.txt"); err == nil {
+		t.Fatal("expected invalid version id error")
+	}
+}
+
+func TestPruneFileVersions(t *testing.T) {
+	now := time.Now().UTC()
+	ages := []time.Duration{72 * time.Hour, 48 * time.Hour, 2 * time.Hour, time.Hour}
+
+	setup := func(t *testing.T) (string, []string) {
+		savePath := t.TempDir()
+		folder := versionFolder(savePath, "tag", "a.txt")
+		if err := os.MkdirAll(folder, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, age := range ages {
+			id := now.Add(-age).Format(versionIDLayout)
+			if err := os.WriteFile(filepath.Join(folder, id), []byte(id), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, id)
+		}
+		return savePath, ids
+	}
+
+	for _, tc := range []struct {
+		name    string
+		keep    int
+		maxAge  time.Duration
+		removed int
+		// kept 保留的版本在 ages 中的下标，从新到旧
+		kept []int
+	}{
+		{"keep count", 2, 0, 2, []int{3, 2}},
+		{"max age", 10, 24 * time.Hour, 2, []int{3, 2}},
+		{"keep and max age", 1, 24 * time.Hour, 3, []int{3}},
+		{"keep all", 10, 0, 0, []int{3, 2, 1, 0}},
+		{"remove all", 0, 0, 4, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			savePath, ids := setup(t)
+			removed, err := PruneFileVersions(savePath, "tag", "a.txt", tc.keep, tc.maxAge)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if removed != tc.removed {
+				t.Fatalf("removed %d versions, want %d", removed, tc.removed)
+			}
+
+			versions, err := GetFileVersions(savePath, "tag", "a.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(versions) != len(tc.kept) {
+				t.Fatalf("kept %d versions, want %d", len(versions), len(tc.kept))
+			}
+			for i, index := range tc.kept {
+				if versions[i].GetVersionId() != ids[index] {
+					t.Fatalf("version %d is %s, want %s", i, versions[i].GetVersionId(), ids[index])
+				}
+			}
+			if len(tc.kept) == 0 && utils.FileSuite.Exists(versionFolder(savePath, "tag", "a.txt")) {
+				t.Fatal("empty version folder not removed")
+			}
+		})
+	}
+}
@@ -0,0 +1,360 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	defaultAuditMaxSize = 100 * 1024 * 1024
+	defaultAuditKeep    = 10
+	auditRotateLayout   = "20060102T150405.000000000Z"
+)
+
+// 记录审计日志的方法
+var auditOps = map[string]string{
+	"/qmeta.transfer.v1.FileTransferService/UploadFile":   "upload",
+	"/qmeta.transfer.v1.FileTransferService/DownloadFile": "download",
+	"/qmeta.transfer.v1.FileTransferService/ListFiles":    "list",
+	"/qmeta.transfer.v1.FileTransferService/DeleteFile":   "delete",
+}
+
+// AuditRecord 审计日志中的一条记录
+type AuditRecord struct {
+	Time       time.Time `json:"time"`
+	TransferID string    `json:"transfer_id,omitempty"`
+	Peer       string    `json:"peer"`
+	// ClientCN 客户端证书的 CommonName，未使用 TLS 时为空
+	ClientCN string `json:"client_cn,omitempty"`
+	Op       string `json:"op"`
+	Tag      string `json:"tag,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Hash     string `json:"hash,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+	// Result 为 success、skipped、rejected 或 failed
+	Result     string `json:"result"`
+	Message    string `json:"message,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// AuditFilter 查询条件，为空的条件不做限制
+type AuditFilter struct {
+	Tag   string
+	Since time.Time
+	Until time.Time
+}
+
+func (f AuditFilter) match(rec AuditRecord) bool {
+	if f.Tag != "" && rec.Tag != f.Tag {
+		return false
+	}
+	if !f.Since.IsZero() && rec.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && rec.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// AuditLog 以 JSON lines 格式追加写入的审计日志
+// 文件超过 MaxSize 后重命名为 path.<时间>，只保留最近 Keep 个
+type AuditLog struct {
+	path    string
+	maxSize int64
+	keep    int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// OpenAuditLog 打开审计日志，maxSize 和 keep 为 0 时使用默认值 100MB 和 10 个
+func OpenAuditLog(path string, maxSize int64, keep int) (*AuditLog, error) {
+	if maxSize <= 0 {
+		maxSize = defaultAuditMaxSize
+	}
+	if keep <= 0 {
+		keep = defaultAuditKeep
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create audit log folder failed: %w", err)
+	}
+
+	a := &AuditLog{path: path, maxSize: maxSize, keep: keep}
+	if err := a.open(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *AuditLog) open() error {
+	file, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("open audit log failed: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	a.file = file
+	a.size = info.Size()
+	return nil
+}
+
+// Write 追加一条记录，写入前超过大小限制时先轮转
+func (a *AuditLog) Write(rec AuditRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return fmt.Errorf("audit log is closed")
+	}
+	if a.size > 0 && a.size+int64(len(line)) > a.maxSize {
+		if err := a.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := a.file.Write(line)
+	a.size += int64(n)
+	return err
+}
+
+// rotate 将当前文件改名保存，删除超出数量的旧文件后重新打开
+func (a *AuditLog) rotate() error {
+	if err := a.file.Close(); err != nil {
+		return err
+	}
+	a.file = nil
+	rotated := a.path + "." + time.Now().UTC().Format(auditRotateLayout)
+	if err := os.Rename(a.path, rotated); err != nil {
+		return fmt.Errorf("rotate audit log failed: %w", err)
+	}
+
+	files, err := a.rotatedFiles()
+	if err != nil {
+		return err
+	}
+	for len(files) > a.keep {
+		os.Remove(files[0])
+		files = files[1:]
+	}
+	return a.open()
+}
+
+// rotatedFiles 返回已轮转的文件，按时间从旧到新排列
+func (a *AuditLog) rotatedFiles() ([]string, error) {
+	files, err := filepath.Glob(a.path + ".*")
+	if err != nil {
+		return nil, err
+	}
+	files = slices.DeleteFunc(files, func(name string) bool {
+		_, err := time.Parse(auditRotateLayout, strings.TrimPrefix(name, a.path+"."))
+		return err != nil
+	})
+	slices.Sort(files)
+	return files, nil
+}
+
+// Close 关闭审计日志
+func (a *AuditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
+
+// QueryAuditLog 按条件读取 path 及其轮转文件中的记录，按时间从旧到新返回
+func QueryAuditLog(path string, filter AuditFilter) ([]AuditRecord, error) {
+	files, err := (&AuditLog{path: path}).rotatedFiles()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	}
+
+	var records []AuditRecord
+	for _, name := range files {
+		if err := readAuditFile(name, filter, &records); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+func readAuditFile(name string, filter AuditFilter, records *[]AuditRecord) error {
+	f, err := os.Open(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// 查询时文件被轮转删除
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rec AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// 写入中断留下的不完整行
+			continue
+		}
+		if filter.match(rec) {
+			*records = append(*records, rec)
+		}
+	}
+	return scanner.Err()
+}
+
+type auditKey struct{}
+
+// auditEntry 请求处理中的审计记录，由处理函数补充文件信息和结果
+type auditEntry struct {
+	mu  sync.Mutex
+	rec AuditRecord
+	set bool
+}
+
+// auditResult 记录请求的文件信息和结果，请求不需要审计时不做任何事
+func auditResult(ctx context.Context, result, tag, name, hash string, size int64, message string) {
+	entry, ok := ctx.Value(auditKey{}).(*auditEntry)
+	if !ok {
+		return
+	}
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	entry.rec.Tag = tag
+	entry.rec.Name = name
+	entry.rec.Hash = hash
+	entry.rec.Size = size
+	entry.rec.Result = result
+	entry.rec.Message = message
+	entry.set = true
+}
+
+// begin 为需要审计的方法创建记录
+func (a *AuditLog) begin(ctx context.Context, method string) (context.Context, *auditEntry) {
+	op, ok := auditOps[method]
+	if !ok {
+		return ctx, nil
+	}
+	entry := &auditEntry{rec: AuditRecord{
+		Time:       time.Now(),
+		TransferID: TransferID(ctx),
+		Peer:       peerAddress(ctx),
+		ClientCN:   clientCommonName(ctx),
+		Op:         op,
+	}}
+	return context.WithValue(ctx, auditKey{}, entry), entry
+}
+
+// finish 写入记录，处理函数没有设置结果时根据返回的错误判断
+func (a *AuditLog) finish(entry *auditEntry, resp any, err error) error {
+	entry.mu.Lock()
+	rec := entry.rec
+	set := entry.set
+	entry.mu.Unlock()
+
+	rec.DurationMs = time.Since(rec.Time).Milliseconds()
+	if !set {
+		rec.Result = outcomeSuccess
+		if r, ok := resp.(interface {
+			GetStatus() bool
+			GetMessage() string
+		}); ok && !r.GetStatus() {
+			rec.Result = outcomeFailed
+			rec.Message = r.GetMessage()
+		}
+		if err != nil {
+			rec.Result = outcomeFailed
+			rec.Message = err.Error()
+		}
+	}
+	return a.Write(rec)
+}
+
+// UnaryServerInterceptor 记录列表和删除请求，标签和文件名从请求中获取
+func (a *AuditLog) UnaryServerInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, entry := a.begin(ctx, info.FullMethod)
+		if entry == nil {
+			return handler(ctx, req)
+		}
+		if r, ok := req.(interface{ GetTag() string }); ok {
+			entry.rec.Tag = r.GetTag()
+		}
+		if r, ok := req.(interface{ GetName() string }); ok {
+			entry.rec.Name = r.GetName()
+		}
+
+		resp, err := handler(ctx, req)
+		if auditErr := a.finish(entry, resp, err); auditErr != nil {
+			logger.Error("write audit log failed", "err", auditErr)
+		}
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor 记录上传和下载，文件信息和结果由处理函数设置
+func (a *AuditLog) StreamServerInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, entry := a.begin(ss.Context(), info.FullMethod)
+		if entry == nil {
+			return handler(srv, ss)
+		}
+
+		err := handler(srv, &transferStream{ServerStream: ss, ctx: ctx})
+		if auditErr := a.finish(entry, nil, err); auditErr != nil {
+			logger.Error("write audit log failed", "err", auditErr)
+		}
+		return err
+	}
+}
+
+// clientCommonName 返回客户端证书的 CommonName
+func clientCommonName(ctx context.Context) string {
+	pr, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	tlsInfo, ok := pr.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return ""
+	}
+	return tlsInfo.State.PeerCertificates[0].Subject.CommonName
+}
+
+func (s *FileService) auditUpload(ctx context.Context, result string, info *UploadInfo, message string) {
+	auditResult(ctx, result, info.Tag, info.Name, info.Hash, info.Size, message)
+}
+
+func (s *FileService) auditDownload(ctx context.Context, result string, info *DownloadInfo, message string) {
+	auditResult(ctx, result, info.Tag, info.Name, info.Hash, info.Size, message)
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAuditLogRotateAndQuery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit, err := OpenAuditLog(path, 200, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now().Add(-time.Hour)
+	for i := range 6 {
+		tag := "nightly"
+		if i%2 == 1 {
+			tag = "weekly"
+		}
+		rec := AuditRecord{Time: start.Add(time.Duration(i) * time.Minute), Peer: "127.0.0.1:1234", Op: "upload", Tag: tag, Name: "db.tar", Size: 42, Result: outcomeSuccess}
+		if err := audit.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+		// 轮转文件名精确到纳秒，避免同名
+		time.Sleep(time.Millisecond)
+	}
+	if err := audit.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := (&AuditLog{path: path}).rotatedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rotated) != 2 {
+		t.Fatalf("expected 2 rotated files, got %v", rotated)
+	}
+
+	all, err := QueryAuditLog(path, AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) == 0 || len(all) >= 6 {
+		t.Fatalf("expected old records to be pruned, got %d", len(all))
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].Time.Before(all[i-1].Time) {
+			t.Fatalf("records out of order: %+v", all)
+		}
+	}
+
+	since := start.Add(4 * time.Minute)
+	records, err := QueryAuditLog(path, AuditFilter{Tag: "nightly", Since: since})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Tag != "nightly" || records[0].Time.Before(since) {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/peer"
+)
+
+// Hooks 传输事件回调，在处理请求的 goroutine 中同步调用，耗时操作应自行异步处理
+type Hooks struct {
+	// OnUploadStart 上传通过检查、开始接收数据前调用，返回错误时拒绝上传
+	OnUploadStart func(ctx context.Context, info UploadInfo) error
+	// OnUploadComplete 文件校验通过并保存后调用
+	OnUploadComplete func(ctx context.Context, info UploadInfo)
+	// OnDownload 文件全部发送后调用
+	OnDownload func(ctx context.Context, info DownloadInfo)
+	// OnReject 请求被拒绝或传输失败时调用
+	OnReject func(ctx context.Context, info RejectInfo)
+}
+
+// UploadInfo 上传事件信息，流式上传在开始时 Size 和 Hash 为空
+type UploadInfo struct {
+	Tag  string
+	Name string
+	Size int64
+	Hash string
+	// Path 保存路径，内存模式下为空
+	Path string
+	Peer string
+	// TransferID 传输 ID，与客户端日志中的相同
+	TransferID string
+	Streaming  bool
+	// Received 实际接收的数据字节数，增量和分片去重复用的部分不计入
+	Received int64
+	Duration time.Duration
+}
+
+// DownloadInfo 下载事件信息
+type DownloadInfo struct {
+	Tag        string
+	Name       string
+	VersionID  string
+	Size       int64
+	Hash       string
+	Peer       string
+	TransferID string
+	Duration   time.Duration
+}
+
+// RejectInfo 拒绝或失败事件信息
+type RejectInfo struct {
+	// Op 为 upload 或 download
+	Op         string
+	Tag        string
+	Name       string
+	Peer       string
+	TransferID string
+	Reason     string
+}
+
+func (s *FileService) uploadStart(ctx context.Context, info *UploadInfo) error {
+	if s.hooks.OnUploadStart == nil {
+		return nil
+	}
+	return s.hooks.OnUploadStart(ctx, *info)
+}
+
+func (s *FileService) uploadComplete(ctx context.Context, info *UploadInfo) {
+	if s.hooks.OnUploadComplete != nil {
+		s.hooks.OnUploadComplete(ctx, *info)
+	}
+}
+
+func (s *FileService) downloaded(ctx context.Context, info *DownloadInfo) {
+	if s.hooks.OnDownload != nil {
+		s.hooks.OnDownload(ctx, *info)
+	}
+}
+
+func (s *FileService) rejected(ctx context.Context, op, tag, name, reason string) {
+	if s.hooks.OnReject != nil {
+		s.hooks.OnReject(ctx, RejectInfo{Op: op, Tag: tag, Name: name, Peer: peerAddress(ctx), TransferID: TransferID(ctx), Reason: reason})
+	}
+}
+
+// peerAddress 返回客户端地址
+func peerAddress(ctx context.Context) string {
+	if pr, ok := peer.FromContext(ctx); ok {
+		return pr.Addr.String()
+	}
+	return ""
+}
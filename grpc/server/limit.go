@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+
+	transferv1 "qback/internal/pb/qmeta/transfer/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ConcurrencyLimits 同时进行的上传和下载数量限制，为 0 时不限制
+type ConcurrencyLimits struct {
+	Uploads   int
+	Downloads int
+	// PeerUploads 和 PeerDownloads 按客户端 IP 计算
+	PeerUploads   int
+	PeerDownloads int
+	// Queue 超出限制时排队等待，否则返回 ResourceExhausted
+	Queue bool
+}
+
+// slotLimiter 限制同时进行的传输数量，排队的请求按先后顺序获得名额
+// 已达到单个客户端上限的请求会被跳过，避免一个客户端阻塞其他客户端
+type slotLimiter struct {
+	max     int
+	peerMax int
+	queue   bool
+
+	mu      sync.Mutex
+	active  int
+	peers   map[string]int
+	waiters []*slotWaiter
+}
+
+type slotWaiter struct {
+	peer  string
+	ready chan struct{}
+	// moved 排队位置变化时通知，容量为 1，只保留最新的通知
+	moved chan struct{}
+}
+
+func newSlotLimiter(max, peerMax int, queue bool) *slotLimiter {
+	if max <= 0 && peerMax <= 0 {
+		return nil
+	}
+	return &slotLimiter{max: max, peerMax: peerMax, queue: queue, peers: make(map[string]int)}
+}
+
+// available 是否可以为 peer 分配名额，调用时需持有锁
+func (l *slotLimiter) available(peer string) bool {
+	return (l.max <= 0 || l.active < l.max) && (l.peerMax <= 0 || l.peers[peer] < l.peerMax)
+}
+
+func (l *slotLimiter) take(peer string) {
+	l.active++
+	l.peers[peer]++
+}
+
+// acquire 获取名额，排队时每次位置变化调用 queued，传输结束后调用 release
+// 不排队时名额不足返回 ResourceExhausted
+func (l *slotLimiter) acquire(ctx context.Context, address string, queued func(position int) error) (release func(), err error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	peer := address
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		peer = host
+	}
+
+	l.mu.Lock()
+	// 释放名额时会分配给所有可以分配的排队请求，仍在排队的请求都不可分配，直接检查本请求即可
+	if l.available(peer) {
+		l.take(peer)
+		l.mu.Unlock()
+		return func() { l.release(peer) }, nil
+	}
+	if !l.queue {
+		l.mu.Unlock()
+		return nil, status.Error(codes.ResourceExhausted, "too many concurrent transfers, try again later")
+	}
+	w := &slotWaiter{peer: peer, ready: make(chan struct{}), moved: make(chan struct{}, 1)}
+	l.waiters = append(l.waiters, w)
+	position := len(l.waiters)
+	l.mu.Unlock()
+
+	for {
+		if queued != nil {
+			if err := queued(position); err != nil {
+				l.cancel(w)
+				return nil, err
+			}
+		}
+		select {
+		case <-w.ready:
+			return func() { l.release(peer) }, nil
+		case <-w.moved:
+			position = l.position(w)
+			if position == 0 {
+				// 位置更新后已获得名额
+				<-w.ready
+				return func() { l.release(peer) }, nil
+			}
+		case <-ctx.Done():
+			l.cancel(w)
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// position 返回排队位置，已获得名额时返回 0
+func (l *slotLimiter) position(w *slotWaiter) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, waiter := range l.waiters {
+		if waiter == w {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// cancel 取消排队，取消前已获得的名额归还
+func (l *slotLimiter) cancel(w *slotWaiter) {
+	l.mu.Lock()
+	for i, waiter := range l.waiters {
+		if waiter == w {
+			l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+			l.notifyLocked()
+			l.mu.Unlock()
+			return
+		}
+	}
+	l.mu.Unlock()
+	// 不在队列中说明已获得名额
+	<-w.ready
+	l.release(w.peer)
+}
+
+func (l *slotLimiter) release(peer string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	if l.peers[peer]--; l.peers[peer] <= 0 {
+		delete(l.peers, peer)
+	}
+
+	granted := false
+	for i := 0; i < len(l.waiters); {
+		w := l.waiters[i]
+		if !l.available(w.peer) {
+			i++
+			continue
+		}
+		l.take(w.peer)
+		l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+		close(w.ready)
+		granted = true
+	}
+	if granted {
+		l.notifyLocked()
+	}
+}
+
+// notifyLocked 通知排队的请求位置已变化，调用时需持有锁
+func (l *slotLimiter) notifyLocked() {
+	for _, w := range l.waiters {
+		select {
+		case w.moved <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// uploadSlot 获取上传名额，排队时向客户端发送排队位置，名额不足被拒绝时记录拒绝事件
+func (s *FileService) uploadSlot(stream transferv1.FileTransferService_UploadFileServer, info *UploadInfo, logger *slog.Logger) (func(), error) {
+	ctx := stream.Context()
+	release, err := s.uploadSlots.acquire(ctx, info.Peer, func(position int) error {
+		logger.Info("upload queued", "position", position)
+		metaAck := &transferv1.MetaAck{}
+		metaAck.SetMessage(fmt.Sprintf("Queued at position %d", position))
+		metaAck.SetName(info.Name)
+		metaAck.SetQueuePosition(int32(position))
+
+		uploadRes := &transferv1.UploadFileResponse{}
+		uploadRes.SetMetaAck(metaAck)
+		return stream.Send(uploadRes)
+	})
+	if status.Code(err) == codes.ResourceExhausted {
+		logger.Warn("upload rejected because of concurrency limit")
+		s.metrics.upload(outcomeRejected)
+		s.auditUpload(ctx, outcomeRejected, info, status.Convert(err).Message())
+		s.rejected(ctx, "upload", info.Tag, info.Name, status.Convert(err).Message())
+	}
+	return release, err
+}
+
+// downloadSlot 获取下载名额，排队时不通知客户端
+func (s *FileService) downloadSlot(ctx context.Context, info *DownloadInfo, logger *slog.Logger) (func(), error) {
+	release, err := s.downloadSlots.acquire(ctx, info.Peer, func(position int) error {
+		logger.Info("download queued", "position", position)
+		return nil
+	})
+	if status.Code(err) == codes.ResourceExhausted {
+		logger.Warn("download rejected because of concurrency limit")
+		s.metrics.download(outcomeRejected)
+		s.auditDownload(ctx, outcomeRejected, info, status.Convert(err).Message())
+		s.rejected(ctx, "download", info.Tag, info.Name, status.Convert(err).Message())
+	}
+	return release, err
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSlotLimiterReject(t *testing.T) {
+	limiter := newSlotLimiter(2, 1, false)
+	ctx := context.Background()
+
+	release, err := limiter.acquire(ctx, "10.0.0.1:1000", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := limiter.acquire(ctx, "10.0.0.1:1001", nil); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected per-peer limit, got %v", err)
+	}
+	if _, err := limiter.acquire(ctx, "10.0.0.2:1000", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := limiter.acquire(ctx, "10.0.0.3:1000", nil); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected global limit, got %v", err)
+	}
+
+	release()
+	if _, err := limiter.acquire(ctx, "10.0.0.1:1002", nil); err != nil {
+		t.Fatalf("slot not released: %v", err)
+	}
+}
+
+func TestSlotLimiterQueue(t *testing.T) {
+	limiter := newSlotLimiter(2, 1, true)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	releaseA, err := limiter.acquire(ctx, "10.0.0.1:1000", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	releaseB, err := limiter.acquire(ctx, "10.0.0.2:1000", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 队首是已达上限的客户端 A，释放 B 的名额后应分配给排在后面的 C
+	granted := make(chan string, 2)
+	positions := make(chan int, 4)
+	for _, address := range []string{"10.0.0.1:1001", "10.0.0.3:1000"} {
+		go func() {
+			release, err := limiter.acquire(ctx, address, func(position int) error {
+				positions <- position
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			granted <- address
+			<-ctx.Done()
+			release()
+		}()
+		if position := <-positions; position == 0 {
+			t.Fatalf("expected queue position for %s", address)
+		}
+	}
+
+	releaseB()
+	if got := <-granted; got != "10.0.0.3:1000" {
+		t.Fatalf("expected waiter of another peer to be granted first, got %s", got)
+	}
+	releaseA()
+	if got := <-granted; got != "10.0.0.1:1001" {
+		t.Fatalf("expected queued peer to be granted, got %s", got)
+	}
+}
+
+func TestSlotLimiterCancel(t *testing.T) {
+	limiter := newSlotLimiter(1, 0, true)
+	release, err := limiter.acquire(context.Background(), "10.0.0.1:1000", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := limiter.acquire(ctx, "10.0.0.2:1000", nil)
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+
+	release()
+	if _, err := limiter.acquire(context.Background(), "10.0.0.3:1000", nil); err != nil {
+		t.Fatalf("canceled waiter kept the slot: %v", err)
+	}
+}
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"container/list"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"qback/grpc/common"
+	transferv1 "qback/internal/pb/qmeta/transfer/v1"
+
+	"github.com/qmaru/minitools/v2/hashx/blake3"
+)
+
+// memoryFile 内存存储中的文件，放入后数据不再修改
+type memoryFile struct {
+	tag     string
+	name    string
+	hash    string
+	data    []byte
+	modTime time.Time
+}
+
+// footprint 返回文件实际占用的内存，即数据的容量
+func (f *memoryFile) footprint() int64 {
+	return int64(cap(f.data))
+}
+
+func (f *memoryFile) listItem() *transferv1.ListFileItem {
+	item := &transferv1.ListFileItem{}
+	item.SetName(f.name)
+	item.SetSize(int64(len(f.data)))
+	item.SetHash(f.hash)
+	item.SetModifiedTime(f.modTime.Unix())
+	return item
+}
+
+// memoryStore 内存模式下保存上传文件，总大小超过上限时淘汰最久未使用的文件
+// 接收中的上传先预留空间，已保存的文件和预留的空间合计不超过上限，大小都按数据的容量计算
+type memoryStore struct {
+	maxBytes int64
+
+	mu       sync.Mutex
+	size     int64
+	reserved int64
+	order    *list.List
+	files    map[string]*list.Element
+}
+
+func newMemoryStore(maxBytes int64) *memoryStore {
+	return &memoryStore{maxBytes: maxBytes, order: list.New(), files: make(map[string]*list.Element)}
+}
+
+func memoryKey(tag, name string) string {
+	return tag + "/" + name
+}
+
+// put 保存文件并替换同名文件，reserved 为接收时预留的空间，返回被淘汰的文件数
+func (m *memoryStore) put(file *memoryFile, reserved int64) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.reserved -= reserved
+	key := memoryKey(file.tag, file.name)
+	if elem, ok := m.files[key]; ok {
+		m.size -= elem.Value.(*memoryFile).footprint()
+		m.order.Remove(elem)
+	}
+	m.files[key] = m.order.PushFront(file)
+	m.size += file.footprint()
+	return m.evictLocked(0, file)
+}
+
+// reserve 为接收中的上传预留 n 字节，空间不足时先淘汰最久未使用的文件，返回被淘汰的文件数
+func (m *memoryStore) reserve(n int64) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	evicted := m.evictLocked(n, nil)
+	if m.size+m.reserved+n > m.maxBytes {
+		return evicted, fmt.Errorf("memory store limit of %d bytes reached, %d bytes used by uploads in progress", m.maxBytes, m.reserved)
+	}
+	m.reserved += n
+	return evicted, nil
+}
+
+// release 归还未保存的上传预留的空间
+func (m *memoryStore) release(n int64) {
+	m.mu.Lock()
+	m.reserved -= n
+	m.mu.Unlock()
+}
+
+// evictLocked 淘汰最久未使用的文件，直到再放入 n 字节不超过上限，keep 不会被淘汰，调用时需持有锁
+func (m *memoryStore) evictLocked(n int64, keep *memoryFile) int {
+	evicted := 0
+	for m.size+m.reserved+n > m.maxBytes {
+		oldest := m.order.Back()
+		if oldest == nil || oldest.Value == keep {
+			break
+		}
+		old := oldest.Value.(*memoryFile)
+		m.order.Remove(oldest)
+		delete(m.files, memoryKey(old.tag, old.name))
+		m.size -= old.footprint()
+		evicted++
+	}
+	return evicted
+}
+
+// get 返回文件并标记为最近使用
+func (m *memoryStore) get(tag, name string) (*memoryFile, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elem, ok := m.files[memoryKey(tag, name)]
+	if !ok {
+		return nil, false
+	}
+	m.order.MoveToFront(elem)
+	return elem.Value.(*memoryFile), true
+}
+
+// list 返回标签下的文件，按文件名排列
+func (m *memoryStore) list(tag string) []*memoryFile {
+	m.mu.Lock()
+	var files []*memoryFile
+	for elem := m.order.Front(); elem != nil; elem = elem.Next() {
+		if file := elem.Value.(*memoryFile); file.tag == tag {
+			files = append(files, file)
+		}
+	}
+	m.mu.Unlock()
+
+	slices.SortFunc(files, func(a, b *memoryFile) int {
+		return strings.Compare(a.name, b.name)
+	})
+	return files
+}
+
+func (m *memoryStore) remove(tag, name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := memoryKey(tag, name)
+	elem, ok := m.files[key]
+	if !ok {
+		return false
+	}
+	m.order.Remove(elem)
+	delete(m.files, key)
+	m.size -= elem.Value.(*memoryFile).footprint()
+	return true
+}
+
+// memorySink 内存模式的上传接收端，边接收边计算哈希
+// 开启内存存储时同时保留数据，并在存储中按数据的容量预留空间，空间不足时报错，否则直接丢弃数据
+// 保留数据时必须调用 commit 或 release 归还预留的空间
+type memorySink struct {
+	hasher   *blake3.Blake3Basic
+	size     int64
+	keep     bool
+	store    *memoryStore
+	sizeHint int64
+	reserved int64
+	evicted  int
+	data     []byte
+}
+
+// newMemorySink 创建接收端，sizeHint 为声明的文件大小，流式上传时为 0
+func (s *FileService) newMemorySink(sizeHint int64) *memorySink {
+	sink := &memorySink{hasher: common.NewHasher()}
+	if s.memStore != nil {
+		sink.keep = true
+		sink.store = s.memStore
+		sink.sizeHint = min(sizeHint, s.memStore.maxBytes)
+	}
+	return sink
+}
+
+func (m *memorySink) Write(p []byte) (int, error) {
+	if m.keep {
+		if err := m.grow(len(p)); err != nil {
+			return 0, err
+		}
+		m.data = append(m.data, p...)
+	}
+	m.hasher.Write(p)
+	m.size += int64(len(p))
+	return len(p), nil
+}
+
+// grow 保证还能放下 n 字节，扩容前预留新增的容量
+// 已知大小时一次分配到声明的大小，否则按倍数扩容，空间不足时只扩容到所需的大小
+func (m *memorySink) grow(n int) error {
+	need := len(m.data) + n
+	if need <= cap(m.data) {
+		return nil
+	}
+
+	capacity := max(need, 2*cap(m.data), int(m.sizeHint))
+	capacity = min(capacity, max(need, int(m.store.maxBytes)))
+	evicted, err := m.store.reserve(int64(capacity - cap(m.data)))
+	m.evicted += evicted
+	if err != nil && capacity > need {
+		capacity = need
+		evicted, err = m.store.reserve(int64(capacity - cap(m.data)))
+		m.evicted += evicted
+	}
+	if err != nil {
+		return err
+	}
+	m.reserved += int64(capacity - cap(m.data))
+
+	data := make([]byte, len(m.data), capacity)
+	copy(data, m.data)
+	m.data = data
+	return nil
+}
+
+// commit 将数据保存到内存存储，预留的空间转为文件占用，返回接收过程中被淘汰的文件数
+func (m *memorySink) commit(tag, name, hash string) int {
+	evicted := m.store.put(&memoryFile{tag: tag, name: name, hash: hash, data: m.data, modTime: time.Now()}, m.reserved)
+	m.reserved = 0
+	return m.evicted + evicted
+}
+
+// release 归还预留的空间，已 commit 时不做任何事
+func (m *memorySink) release() {
+	if m.reserved > 0 {
+		m.store.release(m.reserved)
+		m.reserved = 0
+	}
+}
+
+func (m *memorySink) hash() string {
+	return m.hasher.SumStream().ToHex()
+}
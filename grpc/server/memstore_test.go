@@ -0,0 +1,189 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"qback/grpc/common"
+)
+
+func TestMemoryStoreEvict(t *testing.T) {
+	store := newMemoryStore(10)
+	store.put(&memoryFile{tag: "t", name: "a", data: make([]byte, 4)}, 0)
+	store.put(&memoryFile{tag: "t", name: "b", data: make([]byte, 4)}, 0)
+
+	// 访问 a 后 b 成为最久未使用的文件
+	if _, ok := store.get("t", "a"); !ok {
+		t.Fatal("expected a in store")
+	}
+	if evicted := store.put(&memoryFile{tag: "t", name: "c", data: make([]byte, 4)}, 0); evicted != 1 {
+		t.Fatalf("expected 1 eviction, got %d", evicted)
+	}
+	if _, ok := store.get("t", "b"); ok {
+		t.Fatal("expected b to be evicted")
+	}
+
+	files := store.list("t")
+	if len(files) != 2 || files[0].name != "a" || files[1].name != "c" {
+		t.Fatalf("unexpected files: %v", files)
+	}
+	if !store.remove("t", "a") || store.remove("t", "a") {
+		t.Fatal("unexpected remove result")
+	}
+	if store.size != 4 {
+		t.Fatalf("expected size 4, got %d", store.size)
+	}
+}
+
+func TestMemorySinkLimit(t *testing.T) {
+	data := bytes.Repeat([]byte("qback"), 100)
+	expected, err := common.CalcBlake3FromBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	discard := (&FileService{}).newMemorySink(0)
+	discard.Write(data)
+	if discard.data != nil || discard.size != int64(len(data)) || discard.hash() != expected {
+		t.Fatal("discarding sink should only hash data")
+	}
+
+	keep := (&FileService{memStore: newMemoryStore(int64(len(data)))}).newMemorySink(0)
+	if _, err := keep.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keep.Write([]byte{0}); err == nil {
+		t.Fatal("expected memory store limit error")
+	}
+	if !bytes.Equal(keep.data, data) || keep.hash() != expected {
+		t.Fatal("kept data mismatch")
+	}
+}
+
+func TestMemorySinkSharedBudget(t *testing.T) {
+	store := newMemoryStore(100)
+	service := &FileService{memStore: store}
+	store.put(&memoryFile{tag: "t", name: "old", data: make([]byte, 30)}, 0)
+
+	// 接收中的数据计入总量，需要时淘汰已保存的文件
+	a := service.newMemorySink(0)
+	if _, err := a.Write(make([]byte, 60)); err != nil {
+		t.Fatal(err)
+	}
+	b := service.newMemorySink(0)
+	if _, err := b.Write(make([]byte, 30)); err != nil {
+		t.Fatal(err)
+	}
+	if b.evicted != 1 {
+		t.Fatalf("expected old file to be evicted, got %d", b.evicted)
+	}
+	if _, err := b.Write(make([]byte, 20)); err == nil {
+		t.Fatal("expected in-flight uploads to share the store limit")
+	}
+
+	// 失败的上传归还预留的空间
+	b.release()
+	if store.reserved != 60 {
+		t.Fatalf("expected 60 reserved bytes, got %d", store.reserved)
+	}
+	if evicted := a.commit("t", "a", "hash"); evicted != 0 {
+		t.Fatalf("expected no eviction on commit, got %d", evicted)
+	}
+	if store.size != 60 || store.reserved != 0 {
+		t.Fatalf("expected size 60 and nothing reserved, got %d and %d", store.size, store.reserved)
+	}
+	a.release()
+	if store.reserved != 0 {
+		t.Fatalf("release after commit changed reservation to %d", store.reserved)
+	}
+}
+
+func TestMemorySinkCapacity(t *testing.T) {
+	store := newMemoryStore(1000)
+	service := &FileService{memStore: store}
+
+	// 已知大小时一次分配并预留声明的大小
+	known := service.newMemorySink(300)
+	for range 30 {
+		if _, err := known.Write(make([]byte, 10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cap(known.data) != 300 || store.reserved != 300 {
+		t.Fatalf("expected 300 bytes allocated and reserved, got %d and %d", cap(known.data), store.reserved)
+	}
+
+	// 流式上传扩容时预留的空间始终等于容量
+	streaming := service.newMemorySink(0)
+	for range 25 {
+		if _, err := streaming.Write(make([]byte, 10)); err != nil {
+			t.Fatal(err)
+		}
+		if streaming.reserved != int64(cap(streaming.data)) {
+			t.Fatalf("reserved %d bytes for capacity %d", streaming.reserved, cap(streaming.data))
+		}
+	}
+	capacity := int64(cap(streaming.data))
+	if store.reserved != 300+capacity {
+		t.Fatalf("expected %d reserved bytes, got %d", 300+capacity, store.reserved)
+	}
+
+	// 保存后按容量计入存储
+	streaming.commit("t", "s", "hash")
+	known.release()
+	if store.size != capacity || store.reserved != 0 {
+		t.Fatalf("expected size %d and nothing reserved, got %d and %d", capacity, store.size, store.reserved)
+	}
+}
+
+func TestMemorySinkConcurrent(t *testing.T) {
+	const (
+		limit   = 1000
+		sinks   = 8
+		perSink = 300
+	)
+	store := newMemoryStore(limit)
+	service := &FileService{memStore: store}
+
+	// inFlight 所有接收中的上传已保留的数据量，peak 为其最大值
+	var wg sync.WaitGroup
+	var kept, inFlight, peak atomic.Int64
+	for i := range sinks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sink := service.newMemorySink(0)
+			defer func() {
+				inFlight.Add(-int64(len(sink.data)))
+				sink.release()
+			}()
+			for range perSink / 10 {
+				if _, err := sink.Write(make([]byte, 10)); err != nil {
+					return
+				}
+				n := inFlight.Add(10)
+				for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+				}
+			}
+			sink.commit("t", fmt.Sprintf("f%d", i), "hash")
+			kept.Add(1)
+		}()
+	}
+	wg.Wait()
+
+	if p := peak.Load(); p > limit {
+		t.Fatalf("in-flight uploads held %d bytes, limit %d", p, limit)
+	}
+	if kept.Load() == 0 {
+		t.Fatal("expected some uploads to fit")
+	}
+	if store.reserved != 0 {
+		t.Fatalf("expected all reservations returned, got %d", store.reserved)
+	}
+	if store.size > limit {
+		t.Fatalf("store size %d exceeds limit %d", store.size, limit)
+	}
+}
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"qback/grpc/common"
+	transferv1 "qback/internal/pb/qmeta/transfer/v1"
+
+	grpcprom "github.com/grpc-ecosystem/go-grpc-middleware/providers/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// 传输结果
+const (
+	outcomeSuccess  = "success"
+	outcomeSkipped  = "skipped"
+	outcomeRejected = "rejected"
+	outcomeFailed   = "failed"
+)
+
+// Metrics 服务端 Prometheus 指标，为 nil 时所有记录方法都不做任何事
+type Metrics struct {
+	reg prometheus.Registerer
+
+	// GRPC 标准 gRPC 服务端指标，需要通过 UnaryInterceptor 和 StreamInterceptor 接入
+	GRPC *grpcprom.ServerMetrics
+
+	receivedBytes      prometheus.Counter
+	sentBytes          prometheus.Counter
+	uploads            *prometheus.CounterVec
+	downloads          *prometheus.CounterVec
+	validationFailures prometheus.Counter
+	activeStreams      *prometheus.GaugeVec
+	chunkDuration      *prometheus.HistogramVec
+	storage            *storageCollector
+}
+
+// NewMetrics 创建指标并注册到 reg
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		reg: reg,
+		GRPC: grpcprom.NewServerMetrics(
+			grpcprom.WithServerHandlingTimeHistogram(),
+		),
+		receivedBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "qback_received_bytes_total",
+			Help: "File data bytes received from clients.",
+		}),
+		sentBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "qback_sent_bytes_total",
+			Help: "File data bytes sent to clients.",
+		}),
+		uploads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "qback_uploads_total",
+			Help: "Uploads by outcome.",
+		}, []string{"outcome"}),
+		downloads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "qback_downloads_total",
+			Help: "Downloads by outcome.",
+		}, []string{"outcome"}),
+		validationFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "qback_validation_failures_total",
+			Help: "Uploads that failed size or hash validation.",
+		}),
+		activeStreams: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "qback_active_streams",
+			Help: "Transfer streams in progress.",
+		}, []string{"op"}),
+		chunkDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "qback_chunk_duration_seconds",
+			Help:    "Time spent handling a single chunk.",
+			Buckets: prometheus.ExponentialBuckets(0.0001, 4, 10),
+		}, []string{"op"}),
+	}
+
+	reg.MustRegister(
+		m.GRPC,
+		m.receivedBytes,
+		m.sentBytes,
+		m.uploads,
+		m.downloads,
+		m.validationFailures,
+		m.activeStreams,
+		m.chunkDuration,
+	)
+	return m
+}
+
+// registerStorage 注册各标签的存储使用情况
+func (m *Metrics) registerStorage(s *FileService) {
+	if m == nil || s.memoryMode {
+		return
+	}
+	m.storage = &storageCollector{service: s, stale: true}
+	m.reg.MustRegister(m.storage)
+}
+
+// storageChanged 上传、删除或清理后调用，下次采集时重新统计标签使用情况
+func (m *Metrics) storageChanged() {
+	if m == nil || m.storage == nil {
+		return
+	}
+	m.storage.mu.Lock()
+	m.storage.stale = true
+	m.storage.mu.Unlock()
+}
+
+func (m *Metrics) received(n int64) {
+	if m != nil {
+		m.receivedBytes.Add(float64(n))
+	}
+}
+
+func (m *Metrics) sent(n int64) {
+	if m != nil {
+		m.sentBytes.Add(float64(n))
+	}
+}
+
+func (m *Metrics) upload(outcome string) {
+	if m != nil {
+		m.uploads.WithLabelValues(outcome).Inc()
+	}
+}
+
+func (m *Metrics) download(outcome string) {
+	if m != nil {
+		m.downloads.WithLabelValues(outcome).Inc()
+	}
+}
+
+func (m *Metrics) validationFailed() {
+	if m != nil {
+		m.validationFailures.Inc()
+	}
+}
+
+// stream 记录一个进行中的传输，返回的函数在传输结束时调用
+func (m *Metrics) stream(op string) func() {
+	if m == nil {
+		return func() {}
+	}
+	gauge := m.activeStreams.WithLabelValues(op)
+	gauge.Inc()
+	return gauge.Dec
+}
+
+func (m *Metrics) chunk(op string, start time.Time) {
+	if m != nil {
+		m.chunkDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+	}
+}
+
+var (
+	tagUsedDesc  = prometheus.NewDesc("qback_tag_used_bytes", "Bytes stored under a tag.", []string{"tag"}, nil)
+	tagFilesDesc = prometheus.NewDesc("qback_tag_files", "Files stored under a tag.", []string{"tag"}, nil)
+	tagQuotaDesc = prometheus.NewDesc("qback_tag_quota_bytes", "Quota of a tag, 0 means unlimited.", []string{"tag"}, nil)
+	diskFreeDesc = prometheus.NewDesc("qback_disk_free_bytes", "Free bytes on the storage disk.", nil, nil)
+)
+
+// storageCollector 采集磁盘和各标签的使用情况
+// 统计标签需要遍历所有文件，结果缓存到存储有变化后才重新统计
+type storageCollector struct {
+	service *FileService
+
+	mu    sync.Mutex
+	stale bool
+	tags  []*transferv1.TagUsage
+}
+
+func (c *storageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- tagUsedDesc
+	ch <- tagFilesDesc
+	ch <- tagQuotaDesc
+	ch <- diskFreeDesc
+}
+
+func (c *storageCollector) Collect(ch chan<- prometheus.Metric) {
+	if _, diskFree, err := common.DiskUsage(c.service.savePath); err != nil {
+		c.service.logger.Debug("collect disk usage failed", "err", err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(diskFreeDesc, prometheus.GaugeValue, float64(diskFree))
+	}
+
+	for _, usage := range c.usage() {
+		ch <- prometheus.MustNewConstMetric(tagUsedDesc, prometheus.GaugeValue, float64(usage.GetUsed()), usage.GetTag())
+		ch <- prometheus.MustNewConstMetric(tagFilesDesc, prometheus.GaugeValue, float64(usage.GetFiles()), usage.GetTag())
+		ch <- prometheus.MustNewConstMetric(tagQuotaDesc, prometheus.GaugeValue, float64(usage.GetQuota()), usage.GetTag())
+	}
+}
+
+// usage 返回缓存的标签使用情况，存储有变化时重新统计，统计失败时沿用上次的结果
+func (c *storageCollector) usage() []*transferv1.TagUsage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.stale {
+		return c.tags
+	}
+
+	stats, err := c.service.StorageStats(context.Background(), &transferv1.StorageStatsRequest{})
+	if err != nil || !stats.GetStatus() {
+		c.service.logger.Debug("collect storage metrics failed", "message", stats.GetMessage())
+		return c.tags
+	}
+	c.tags = stats.GetTags()
+	c.stale = false
+	return c.tags
+}
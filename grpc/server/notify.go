@@ -0,0 +1,194 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNotifyTimeout    = 10 * time.Second
+	defaultNotifyRetryDelay = time.Second
+)
+
+// UploadEvent 上传完成通知的内容
+type UploadEvent struct {
+	Event      string    `json:"event"`
+	Tag        string    `json:"tag"`
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	Hash       string    `json:"hash"`
+	Peer       string    `json:"peer"`
+	TransferID string    `json:"transfer_id"`
+	DurationMs int64     `json:"duration_ms"`
+	Time       time.Time `json:"time"`
+}
+
+// Notifier 上传完成后调用 webhook 或执行本地命令
+// 命令不经过 shell 执行，事件内容通过 QBACK_* 环境变量和标准输入的 JSON 传入
+type Notifier struct {
+	// WebhookURL 以 POST JSON 的方式通知的地址，为空时不发送
+	WebhookURL string
+	// Command 要执行的命令及参数，为空时不执行
+	Command []string
+	// Timeout 每次尝试的超时时间
+	Timeout time.Duration
+	// Retries 失败后的重试次数
+	Retries int
+	// RetryDelay 第一次重试前的等待时间，之后每次加倍
+	RetryDelay time.Duration
+	// Client 发送 webhook 使用的 HTTP 客户端，为空时使用 http.DefaultClient
+	Client *http.Client
+	// Logger 记录通知失败，为空时使用 slog.Default()
+	Logger *slog.Logger
+
+	// pending 后台进行中的通知
+	pending sync.WaitGroup
+}
+
+// Enabled 是否配置了通知
+func (n *Notifier) Enabled() bool {
+	return n.WebhookURL != "" || len(n.Command) > 0
+}
+
+func (n *Notifier) logger() *slog.Logger {
+	if n.Logger == nil {
+		return slog.Default()
+	}
+	return n.Logger
+}
+
+// OnUploadComplete 作为 Hooks.OnUploadComplete 使用，在后台发送通知，不阻塞上传响应
+// 退出前调用 Wait 等待通知发送完成
+func (n *Notifier) OnUploadComplete(ctx context.Context, info UploadInfo) {
+	n.pending.Add(1)
+	go func() {
+		defer n.pending.Done()
+		if err := n.Notify(context.Background(), info); err != nil {
+			n.logger().Error("notify failed", "transfer_id", info.TransferID, "tag", info.Tag, "name", info.Name, "err", err)
+		}
+	}()
+}
+
+// Wait 等待后台进行中的通知全部完成
+func (n *Notifier) Wait() {
+	n.pending.Wait()
+}
+
+// Notify 依次发送 webhook 和执行命令，失败时按配置重试
+func (n *Notifier) Notify(ctx context.Context, info UploadInfo) error {
+	event := UploadEvent{
+		Event:      "upload.complete",
+		Tag:        info.Tag,
+		Name:       info.Name,
+		Size:       info.Size,
+		Hash:       info.Hash,
+		Peer:       info.Peer,
+		TransferID: info.TransferID,
+		DurationMs: info.Duration.Milliseconds(),
+		Time:       time.Now(),
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if n.WebhookURL != "" {
+		if err := n.retry(ctx, func(ctx context.Context) error { return n.postWebhook(ctx, payload) }); err != nil {
+			errs = append(errs, fmt.Errorf("webhook: %w", err))
+		}
+	}
+	if len(n.Command) > 0 {
+		if err := n.retry(ctx, func(ctx context.Context) error { return n.runCommand(ctx, event, payload) }); err != nil {
+			errs = append(errs, fmt.Errorf("command: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// retry 执行 fn，失败后等待并重试，等待时间每次加倍
+func (n *Notifier) retry(ctx context.Context, fn func(context.Context) error) error {
+	timeout := n.Timeout
+	if timeout <= 0 {
+		timeout = defaultNotifyTimeout
+	}
+	delay := n.RetryDelay
+	if delay <= 0 {
+		delay = defaultNotifyRetryDelay
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		err = fn(attemptCtx)
+		cancel()
+		if err == nil || attempt >= n.Retries {
+			return err
+		}
+
+		n.logger().Warn("notify attempt failed", "attempt", attempt+1, "retry_in", delay, "err", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (n *Notifier) postWebhook(ctx context.Context, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "qback")
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return nil
+}
+
+func (n *Notifier) runCommand(ctx context.Context, event UploadEvent, payload []byte) error {
+	cmd := exec.CommandContext(ctx, n.Command[0], n.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"QBACK_TAG="+event.Tag,
+		"QBACK_NAME="+event.Name,
+		"QBACK_SIZE="+strconv.FormatInt(event.Size, 10),
+		"QBACK_HASH="+event.Hash,
+		"QBACK_PEER="+event.Peer,
+		"QBACK_TRANSFER_ID="+event.TransferID,
+		"QBACK_DURATION_MS="+strconv.FormatInt(event.DurationMs, 10),
+	)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if len(output) > 0 {
+			return fmt.Errorf("%w: %s", err, bytes.TrimSpace(output))
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNotifierWebhookRetry(t *testing.T) {
+	var attempts atomic.Int32
+	events := make(chan UploadEvent, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var event UploadEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+		events <- event
+	}))
+	defer srv.Close()
+
+	notifier := &Notifier{
+		WebhookURL: srv.URL,
+		Retries:    3,
+		RetryDelay: time.Millisecond,
+	}
+	info := UploadInfo{Tag: "nightly", Name: "db.tar", Size: 42, Hash: "abc", Peer: "127.0.0.1:1234", Duration: 1500 * time.Millisecond}
+	if err := notifier.Notify(context.Background(), info); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := attempts.Load(); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+	event := <-events
+	if event.Tag != "nightly" || event.Name != "db.tar" || event.Size != 42 || event.Hash != "abc" || event.Peer != "127.0.0.1:1234" || event.DurationMs != 1500 {
+		t.Fatalf("unexpected payload: %+v", event)
+	}
+}
+
+func TestNotifierWebhookTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	notifier := &Notifier{
+		WebhookURL: srv.URL,
+		Timeout:    50 * time.Millisecond,
+		Retries:    1,
+		RetryDelay: time.Millisecond,
+	}
+	start := time.Now()
+	if err := notifier.Notify(context.Background(), UploadInfo{Tag: "t", Name: "n"}); err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("timeout not applied, took %s", elapsed)
+	}
+}
+
+func TestNotifierCommandWait(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "event")
+	notifier := &Notifier{
+		Command: []string{"sh", "-c", `sleep 0.2; echo "$QBACK_TRANSFER_ID $QBACK_TAG $QBACK_NAME" > "$0"`, output},
+	}
+
+	notifier.OnUploadComplete(context.Background(), UploadInfo{Tag: "nightly", Name: "db.tar", TransferID: "abc123"})
+	notifier.Wait()
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("command not finished after Wait: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "abc123 nightly db.tar" {
+		t.Fatalf("unexpected command env: %q", got)
+	}
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"qback/grpc/common"
+	transferv1 "qback/internal/pb/qmeta/transfer/v1"
+	"qback/utils"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc"
+)
+
+// Option FileService 选项
+type Option func(*FileService)
+
+// WithSavePath 文件保存目录，未开启内存模式时必须设置
+func WithSavePath(path string) Option {
+	return func(s *FileService) {
+		s.savePath = path
+	}
+}
+
+// WithMemoryMode 只校验不落盘
+func WithMemoryMode() Option {
+	return func(s *FileService) {
+		s.memoryMode = true
+	}
+}
+
+// WithMemoryStore 内存模式下保留上传的文件，总大小超过 maxBytes 时淘汰最久未使用的文件
+// 开启后内存模式支持列出、下载和删除文件
+func WithMemoryStore(maxBytes int64) Option {
+	return func(s *FileService) {
+		s.memoryMode = true
+		if maxBytes > 0 {
+			s.memStore = newMemoryStore(maxBytes)
+		}
+	}
+}
+
+// WithLogger 设置日志，默认使用 slog.Default()
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *FileService) {
+		s.logger = logger
+	}
+}
+
+// WithQuotas 标签配额，单位字节
+func WithQuotas(quotas map[string]int64) Option {
+	return func(s *FileService) {
+		s.quotas = quotas
+	}
+}
+
+// WithDedup 按内容哈希去重存储
+func WithDedup() Option {
+	return func(s *FileService) {
+		s.dedup = true
+	}
+}
+
+// WithChunkTTL 内容定义分片未被使用超过该时间后清理
+func WithChunkTTL(ttl time.Duration) Option {
+	return func(s *FileService) {
+		if ttl > 0 {
+			s.chunkTTL = ttl
+		}
+	}
+}
+
+// WithVersioning 每个文件保留 keep 个历史版本，maxAge 为 0 时不限制保留时间
+func WithVersioning(keep int, maxAge time.Duration) Option {
+	return func(s *FileService) {
+		s.keepVersions = keep
+		s.versionMaxAge = maxAge
+	}
+}
+
+// WithHooks 设置传输事件回调
+func WithHooks(hooks Hooks) Option {
+	return func(s *FileService) {
+		s.hooks = hooks
+	}
+}
+
+// WithMetrics 记录 Prometheus 指标
+func WithMetrics(metrics *Metrics) Option {
+	return func(s *FileService) {
+		s.metrics = metrics
+	}
+}
+
+// WithRateLimit 限制上传和下载的数据速率，global 为所有传输共用，perPeer 为每个客户端 IP 单独计算
+func WithRateLimit(global, perPeer common.RateSchedule) Option {
+	return func(s *FileService) {
+		s.rateLimit = common.NewRateLimiter(global)
+		s.peerLimits = newPeerLimiters(perPeer)
+	}
+}
+
+// WithConcurrencyLimits 限制同时进行的上传和下载数量
+func WithConcurrencyLimits(limits ConcurrencyLimits) Option {
+	return func(s *FileService) {
+		s.uploadSlots = newSlotLimiter(limits.Uploads, limits.PeerUploads, limits.Queue)
+		s.downloadSlots = newSlotLimiter(limits.Downloads, limits.PeerDownloads, limits.Queue)
+	}
+}
+
+// WithTracerProvider 记录哈希、写盘、同步和校验等步骤的链路
+// gRPC 请求本身的链路需要通过 NewStatsHandler 接入
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(s *FileService) {
+		s.tracer = tp.Tracer(tracerName)
+	}
+}
+
+// NewFileService 创建文件服务，可以通过 Register 注册到已有的 gRPC 服务
+// 非内存模式下会创建保存目录并清理上次遗留的临时文件
+func NewFileService(opts ...Option) (*FileService, error) {
+	s := &FileService{chunkTTL: defaultChunkTTL, transfers: newTransferRegistry()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.logger == nil {
+		s.logger = slog.Default()
+	}
+	if s.tracer == nil {
+		s.tracer = noop.NewTracerProvider().Tracer(tracerName)
+	}
+
+	if s.memoryMode {
+		if s.dedup {
+			return nil, fmt.Errorf("dedup cannot be used with memory mode")
+		}
+		return s, nil
+	}
+	if s.savePath == "" {
+		return nil, fmt.Errorf("save path is required when memory mode is disabled")
+	}
+
+	if _, err := utils.FileSuite.Mkdir(s.savePath); err != nil {
+		return nil, fmt.Errorf("create save path failed: %w", err)
+	}
+	if err := common.CleanTempFiles(s.savePath); err != nil {
+		return nil, fmt.Errorf("clean temp files failed: %w", err)
+	}
+
+	if s.dedup {
+		blobs, err := common.NewBlobStore(s.savePath)
+		if err != nil {
+			return nil, err
+		}
+		if err := blobs.CleanTemp(); err != nil {
+			return nil, fmt.Errorf("clean blob temp failed: %w", err)
+		}
+		s.blobs = blobs
+	}
+
+	chunks, err := common.NewChunkStore(s.savePath)
+	if err != nil {
+		return nil, err
+	}
+	s.chunks = chunks
+	s.metrics.registerStorage(s)
+	return s, nil
+}
+
+// Register 将文件服务注册到 gRPC 服务
+// 服务端需要通过 grpc.MaxRecvMsgSize 和 grpc.MaxSendMsgSize 允许 common.MaxMsgSize 大小的消息
+func (s *FileService) Register(r grpc.ServiceRegistrar) {
+	transferv1.RegisterFileTransferServiceServer(r, s)
+}
+
+// StartGC 在后台定期清理过期的分片和历史版本，ctx 结束时停止
+func (s *FileService) StartGC(ctx context.Context) {
+	if s.memoryMode {
+		return
+	}
+	go s.runStorageGC(ctx)
+}
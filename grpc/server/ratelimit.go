@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"qback/grpc/common"
+)
+
+// peerLimiters 按客户端 IP 分配的限速器，同一客户端的多个连接共用，没有进行中的传输时释放
+type peerLimiters struct {
+	schedule common.RateSchedule
+
+	mu    sync.Mutex
+	peers map[string]*peerLimiter
+}
+
+type peerLimiter struct {
+	limiter *common.RateLimiter
+	refs    int
+}
+
+func newPeerLimiters(schedule common.RateSchedule) *peerLimiters {
+	if !schedule.Enabled() {
+		return nil
+	}
+	return &peerLimiters{schedule: schedule, peers: make(map[string]*peerLimiter)}
+}
+
+// acquire 返回客户端的限速器，传输结束后调用 release
+func (p *peerLimiters) acquire(address string) (limiter *common.RateLimiter, release func()) {
+	if p == nil {
+		return nil, func() {}
+	}
+	host := address
+	if h, _, err := net.SplitHostPort(address); err == nil {
+		host = h
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	peer, ok := p.peers[host]
+	if !ok {
+		peer = &peerLimiter{limiter: common.NewRateLimiter(p.schedule)}
+		p.peers[host] = peer
+	}
+	peer.refs++
+
+	return peer.limiter, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if peer.refs--; peer.refs == 0 {
+			delete(p.peers, host)
+		}
+	}
+}
+
+// throttle 单个传输使用的限速器，先按客户端限速再按全局限速
+type throttle struct {
+	peer   *common.RateLimiter
+	global *common.RateLimiter
+}
+
+// throttle 返回本次传输的限速器，传输结束后调用 release
+func (s *FileService) throttle(ctx context.Context) (throttle, func()) {
+	peer, release := s.peerLimits.acquire(peerAddress(ctx))
+	return throttle{peer: peer, global: s.rateLimit}, release
+}
+
+// wait 等待传输 n 字节的配额
+func (t throttle) wait(ctx context.Context, n int) error {
+	if err := t.peer.WaitN(ctx, n); err != nil {
+		return err
+	}
+	return t.global.WaitN(ctx, n)
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	transferv1 "qback/internal/pb/qmeta/transfer/v1"
+)
+
+// activeTransfer 正在进行的传输，done 由处理函数在传输过程中更新
+type activeTransfer struct {
+	id    string
+	op    string
+	tag   string
+	name  string
+	peer  string
+	total int64
+	start time.Time
+	done  atomic.Int64
+}
+
+func (t *activeTransfer) advance(n int64) {
+	t.done.Add(n)
+}
+
+// transferRegistry 记录正在进行的上传和下载，供 ListTransfers 查询
+type transferRegistry struct {
+	mu        sync.Mutex
+	transfers map[*activeTransfer]struct{}
+}
+
+func newTransferRegistry() *transferRegistry {
+	return &transferRegistry{transfers: make(map[*activeTransfer]struct{})}
+}
+
+// add 登记传输，传输结束后调用返回值的 remove
+func (r *transferRegistry) add(ctx context.Context, op, tag, name string, total int64) *activeTransfer {
+	t := &activeTransfer{
+		id:    TransferID(ctx),
+		op:    op,
+		tag:   tag,
+		name:  name,
+		peer:  peerAddress(ctx),
+		total: total,
+		start: time.Now(),
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.transfers[t] = struct{}{}
+	return t
+}
+
+func (r *transferRegistry) remove(t *activeTransfer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.transfers, t)
+}
+
+// list 返回 tag 下正在进行的传输，tag 为空时返回全部，按开始时间排列
+func (r *transferRegistry) list(tag string) []*transferv1.TransferStatus {
+	r.mu.Lock()
+	active := make([]*activeTransfer, 0, len(r.transfers))
+	for t := range r.transfers {
+		if tag == "" || t.tag == tag {
+			active = append(active, t)
+		}
+	}
+	r.mu.Unlock()
+
+	slices.SortFunc(active, func(a, b *activeTransfer) int {
+		return a.start.Compare(b.start)
+	})
+
+	now := time.Now()
+	statuses := make([]*transferv1.TransferStatus, 0, len(active))
+	for _, t := range active {
+		done := t.done.Load()
+		status := &transferv1.TransferStatus{}
+		status.SetTransferId(t.id)
+		status.SetOp(t.op)
+		status.SetTag(t.tag)
+		status.SetName(t.name)
+		status.SetPeer(t.peer)
+		status.SetDone(done)
+		status.SetTotal(t.total)
+		if elapsed := now.Sub(t.start).Seconds(); elapsed > 0 {
+			status.SetRate(float64(done) / elapsed)
+		}
+		status.SetStartTime(t.start.Unix())
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// ListTransfers 列出正在进行的上传和下载
+func (s *FileService) ListTransfers(ctx context.Context, in *transferv1.ListTransfersRequest) (*transferv1.ListTransfersResponse, error) {
+	transfers := s.transfers.list(in.GetTag())
+	s.logger.Debug("list transfers", "tag", in.GetTag(), "transfers", len(transfers))
+
+	res := &transferv1.ListTransfersResponse{}
+	res.SetStatus(true)
+	res.SetTransfers(transfers)
+	return res, nil
+}
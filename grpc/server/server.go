@@ -14,22 +14,33 @@ package server
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
+	"slices"
 	"time"
 
 	"qback/grpc/common"
 	transferv1 "qback/internal/pb/qmeta/transfer/v1"
 	"qback/utils"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
-	"google.golang.org/grpc/peer"
+)
+
+const (
+	storageGCInterval = time.Hour
+	defaultChunkTTL   = 7 * 24 * time.Hour
 )
 
 type ServerBasic struct {
@@ -37,22 +48,67 @@ type ServerBasic struct {
 	SavePath      string
 	Secure        bool
 	MemoryMode    bool
-	Debug         bool
+	// MemoryStoreSize 内存模式下保留上传文件的总大小上限，为 0 时只校验后丢弃
+	MemoryStoreSize int64
+	// Logger 服务日志，为空时使用 slog.Default()
+	Logger *slog.Logger
+	// Quotas 标签配额，单位字节
+	Quotas map[string]int64
+	// Dedup 按内容哈希去重存储
+	Dedup bool
+	// ChunkTTL 内容定义分片未被使用超过该时间后清理
+	ChunkTTL time.Duration
+	// KeepVersions 每个文件保留的历史版本数，为 0 时直接覆盖
+	KeepVersions int
+	// VersionMaxAge 历史版本最长保留时间，为 0 时不限制
+	VersionMaxAge time.Duration
+	// Hooks 传输事件回调
+	Hooks Hooks
+	// Notifier 上传完成通知，关闭时等待进行中的通知发送完成
+	Notifier *Notifier
+	// MetricsAddress Prometheus 指标的 HTTP 监听地址，为空时不开启
+	MetricsAddress string
+	// TracerProvider 链路追踪，为空时不记录
+	TracerProvider trace.TracerProvider
+	// RateLimit 所有传输共用的限速计划
+	RateLimit common.RateSchedule
+	// PeerRateLimit 每个客户端 IP 的限速计划
+	PeerRateLimit common.RateSchedule
+	// Concurrency 同时进行的传输数量限制
+	Concurrency ConcurrencyLimits
+	// AuditPath 审计日志路径，为空时不记录
+	AuditPath string
+	// AuditMaxSize 审计日志轮转大小，为 0 时使用 100MB
+	AuditMaxSize int64
+	// AuditKeep 保留的轮转文件数，为 0 时保留 10 个
+	AuditKeep int
 }
 
 type FileService struct {
 	savePath   string
 	memoryMode bool
-	debug      bool
+	memStore   *memoryStore
+	logger     *slog.Logger
+	quotas     map[string]int64
+	dedup      bool
+	blobs      *common.BlobStore
+	chunks     *common.ChunkStore
+	chunkTTL   time.Duration
+	hooks      Hooks
+	metrics    *Metrics
+	tracer     trace.Tracer
+	transfers  *transferRegistry
+	rateLimit  *common.RateLimiter
+	peerLimits *peerLimiters
+
+	uploadSlots   *slotLimiter
+	downloadSlots *slotLimiter
+
+	keepVersions  int
+	versionMaxAge time.Duration
 	transferv1.UnimplementedFileTransferServiceServer
 }
 
-func (s *FileService) logDebug(format string, v ...any) {
-	if s.debug {
-		utils.LogDebug(format, v...)
-	}
-}
-
 func (s *FileService) shouldLogChunk(chunk, total int64) bool {
 	if total <= 10 {
 		return true
@@ -60,70 +116,93 @@ func (s *FileService) shouldLogChunk(chunk, total int64) bool {
 	return chunk == 1 || chunk == total || chunk%100 == 0
 }
 
-// unaryLoggerMid
-func unaryLoggerMid(ctx context.Context, info *grpc.UnaryServerInfo) error {
-	var clientIP string
-	pr, ok := peer.FromContext(ctx)
-	if ok {
-		clientIP = pr.Addr.String()
-	} else {
-		clientIP = ""
+// unaryLogInterceptor 记录每个请求的方法和客户端地址
+func unaryLogInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		logger.Info("request", "method", info.FullMethod, "peer", peerAddress(ctx), "transfer_id", TransferID(ctx))
+		return handler(ctx, req)
 	}
-
-	log.Printf("%s %s\n", clientIP, info.FullMethod)
-	return nil
 }
 
-// streamLoggerMid
-func streamLoggerMid(ctx context.Context, info *grpc.StreamServerInfo) error {
-	var clientIP string
-	pr, ok := peer.FromContext(ctx)
-	if ok {
-		clientIP = pr.Addr.String()
-	} else {
-		clientIP = ""
+// streamLogInterceptor 记录每个流的方法和客户端地址
+func streamLogInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		logger.Info("stream", "method", info.FullMethod, "peer", peerAddress(ss.Context()), "transfer_id", TransferID(ss.Context()))
+		return handler(srv, ss)
 	}
-
-	log.Printf("%s %s (stream)\n", clientIP, info.FullMethod)
-	return nil
 }
 
-// unaryLogInterceptor
-func unaryLogInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
-	// 输出日志
-	err := unaryLoggerMid(ctx, info)
-	if err != nil {
-		return nil, err
+func (s *ServerBasic) Run(ctx context.Context) error {
+	logger := s.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.Info("listening", "address", s.ListenAddress)
+	logger.Debug("server config", "address", s.ListenAddress, "secure", s.Secure, "memory_mode", s.MemoryMode, "save_path", s.SavePath)
+
+	serviceOpts := []Option{
+		WithSavePath(s.SavePath),
+		WithLogger(logger),
+		WithQuotas(s.Quotas),
+		WithChunkTTL(s.ChunkTTL),
+		WithVersioning(s.KeepVersions, s.VersionMaxAge),
+		WithHooks(s.hooks()),
+		WithRateLimit(s.RateLimit, s.PeerRateLimit),
+		WithConcurrencyLimits(s.Concurrency),
 	}
-	// 继续处理请求
-	return handler(ctx, req)
-}
 
-func streamLogInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-	err := streamLoggerMid(ss.Context(), info)
+	var metrics *Metrics
+	var registry *prometheus.Registry
+	if s.MetricsAddress != "" {
+		registry = prometheus.NewRegistry()
+		registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+		metrics = NewMetrics(registry)
+		serviceOpts = append(serviceOpts, WithMetrics(metrics))
+	}
+	if s.MemoryMode {
+		serviceOpts = append(serviceOpts, WithMemoryMode())
+		if s.MemoryStoreSize > 0 {
+			serviceOpts = append(serviceOpts, WithMemoryStore(s.MemoryStoreSize))
+		}
+	}
+	if s.Dedup {
+		serviceOpts = append(serviceOpts, WithDedup())
+	}
+	if s.TracerProvider != nil {
+		serviceOpts = append(serviceOpts, WithTracerProvider(s.TracerProvider))
+	}
+	fileService, err := NewFileService(serviceOpts...)
 	if err != nil {
 		return err
 	}
-	return handler(srv, ss)
-}
-
-func (s *ServerBasic) Run(ctx context.Context) error {
-	log.Printf("Listen on %s\n", s.ListenAddress)
-	if s.Debug {
-		utils.LogDebug("server config: address=%s secure=%t memory_mode=%t save_path=%s", s.ListenAddress, s.Secure, s.MemoryMode, s.SavePath)
-	}
 
 	listener, err := net.Listen("tcp", s.ListenAddress)
 	if err != nil {
-		if s.Debug {
-			utils.LogDebug("listen failed: %v", err)
-		}
+		logger.Debug("listen failed", "err", err)
 		return err
 	}
 
+	unaryInterceptors := []grpc.UnaryServerInterceptor{UnaryTransferIDInterceptor, unaryLogInterceptor(logger)}
+	streamInterceptors := []grpc.StreamServerInterceptor{StreamTransferIDInterceptor, streamLogInterceptor(logger)}
+	if metrics != nil {
+		unaryInterceptors = append(unaryInterceptors, metrics.GRPC.UnaryServerInterceptor())
+		streamInterceptors = append(streamInterceptors, metrics.GRPC.StreamServerInterceptor())
+	}
+	var audit *AuditLog
+	if s.AuditPath != "" {
+		audit, err = OpenAuditLog(s.AuditPath, s.AuditMaxSize, s.AuditKeep)
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		logger.Info("audit log enabled", "path", s.AuditPath)
+		unaryInterceptors = append(unaryInterceptors, audit.UnaryServerInterceptor(logger))
+		streamInterceptors = append(streamInterceptors, audit.StreamServerInterceptor(logger))
+	}
+
 	opts := []grpc.ServerOption{
-		grpc.UnaryInterceptor(unaryLogInterceptor),
-		grpc.StreamInterceptor(streamLogInterceptor),
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
 		grpc.MaxRecvMsgSize(common.MaxMsgSize),
 		grpc.MaxSendMsgSize(common.MaxMsgSize),
 		grpc.ConnectionTimeout(10 * time.Second),
@@ -132,50 +211,199 @@ func (s *ServerBasic) Run(ctx context.Context) error {
 			PermitWithoutStream: true,
 		}),
 	}
+	if s.TracerProvider != nil {
+		logger.Info("tracing enabled")
+		opts = append(opts, grpc.StatsHandler(NewStatsHandler(s.TracerProvider)))
+	}
 
 	if s.Secure {
-		log.Println("TLS ON")
+		logger.Info("tls enabled")
 		tlsConfig, err := common.GenTLSInfo("server", true)
 		if err != nil {
-			if s.Debug {
-				utils.LogDebug("load tls config failed: %v", err)
-			}
+			logger.Debug("load tls config failed", "err", err)
 			return err
 		}
 		creds := credentials.NewTLS(tlsConfig)
 		opts = append(opts, grpc.Creds(creds))
-		if s.Debug {
-			utils.LogDebug("tls credentials attached")
-		}
+		logger.Debug("tls credentials attached")
 	}
+	fileService.StartGC(ctx)
+
 	server := grpc.NewServer(opts...)
-	transferv1.RegisterFileTransferServiceServer(server, &FileService{savePath: s.SavePath, memoryMode: s.MemoryMode, debug: s.Debug})
+	fileService.Register(server)
+
+	if metrics != nil {
+		metrics.GRPC.InitializeMetrics(server)
+		if err := serveMetrics(ctx, logger, s.MetricsAddress, registry); err != nil {
+			listener.Close()
+			if audit != nil {
+				audit.Close()
+			}
+			return err
+		}
+	}
 
+	stopped := make(chan struct{})
 	go func() {
+		defer close(stopped)
 		<-ctx.Done()
-		log.Println("Shutting down server...")
-		if s.Debug {
-			utils.LogDebug("graceful stop requested")
-		}
+		logger.Info("shutting down server")
+		logger.Debug("graceful stop requested")
 		server.GracefulStop()
+		if s.Notifier != nil {
+			// 上传全部结束后不会再产生新的通知
+			s.Notifier.Wait()
+		}
+		if audit != nil {
+			// 等待进行中的请求写入审计记录后再关闭
+			audit.Close()
+		}
 	}()
 
-	log.Println("Server is ready")
+	logger.Info("server is ready")
 	if s.MemoryMode {
-		log.Println("Memory Mode: ON")
+		logger.Info("memory mode enabled", "store_size", s.MemoryStoreSize)
 	}
-	if s.Debug {
-		utils.LogDebug("grpc server ready with %d options", len(opts))
+	if fileService.blobs != nil {
+		logger.Info("dedup enabled")
 	}
+	if !s.MemoryMode && s.KeepVersions > 0 {
+		logger.Info("versioning enabled", "keep", s.KeepVersions, "max_age", s.VersionMaxAge)
+	}
+	if c := s.Concurrency; c.Uploads > 0 || c.Downloads > 0 || c.PeerUploads > 0 || c.PeerDownloads > 0 {
+		logger.Info("concurrency limit", "uploads", c.Uploads, "downloads", c.Downloads, "peer_uploads", c.PeerUploads, "peer_downloads", c.PeerDownloads, "queue", c.Queue)
+	}
+	if s.RateLimit.Enabled() {
+		logger.Info("rate limit", "rate", s.RateLimit.Rate, "windows", len(s.RateLimit.Windows))
+	}
+	if s.PeerRateLimit.Enabled() {
+		logger.Info("peer rate limit", "rate", s.PeerRateLimit.Rate, "windows", len(s.PeerRateLimit.Windows))
+	}
+	for tag, quota := range s.Quotas {
+		logger.Info("quota", "tag", tag, "limit", quota)
+	}
+	logger.Debug("grpc server ready", "options", len(opts))
 
-	return server.Serve(listener)
+	err = server.Serve(listener)
+	if ctx.Err() != nil {
+		<-stopped
+	}
+	return err
 }
 
-func (s *FileService) sendUploadError(stream transferv1.FileTransferService_UploadFileServer, message string) error {
-	s.logDebug("sending upload error response: %s", message)
+// hooks 返回服务端使用的回调，配置了通知时在 OnUploadComplete 之后发送通知
+func (s *ServerBasic) hooks() Hooks {
+	hooks := s.Hooks
+	if s.Notifier == nil || !s.Notifier.Enabled() {
+		return hooks
+	}
+	onComplete := hooks.OnUploadComplete
+	hooks.OnUploadComplete = func(ctx context.Context, info UploadInfo) {
+		if onComplete != nil {
+			onComplete(ctx, info)
+		}
+		s.Notifier.OnUploadComplete(ctx, info)
+	}
+	return hooks
+}
+
+// serveMetrics 在 address 上提供 /metrics，ctx 结束时关闭
+func serveMetrics(ctx context.Context, logger *slog.Logger, address string, registry *prometheus.Registry) error {
+	metricsListener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("metrics listen failed: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry}))
+	metricsServer := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		metricsServer.Close()
+	}()
+	go func() {
+		if err := metricsServer.Serve(metricsListener); err != nil && err != http.ErrServerClosed {
+			logger.Error("metrics server failed", "err", err)
+		}
+	}()
+
+	logger.Info("metrics listening", "url", "http://"+metricsListener.Addr().String()+"/metrics")
+	return nil
+}
+
+// 返回给客户端的错误类型
+const (
+	codeRejected  = transferv1.ErrorCode_ERROR_CODE_REJECTED
+	codeExists    = transferv1.ErrorCode_ERROR_CODE_EXISTS
+	codeNotFound  = transferv1.ErrorCode_ERROR_CODE_NOT_FOUND
+	codeIntegrity = transferv1.ErrorCode_ERROR_CODE_INTEGRITY
+	codeInternal  = transferv1.ErrorCode_ERROR_CODE_INTERNAL
+)
+
+func (s *FileService) sendUploadReject(stream transferv1.FileTransferService_UploadFileServer, info *UploadInfo, message string) error {
+	s.logger.Debug("sending upload reject ack", "transfer_id", TransferID(stream.Context()), "message", message)
+	s.metrics.upload(outcomeRejected)
+	s.auditUpload(stream.Context(), outcomeRejected, info, message)
+	s.rejected(stream.Context(), "upload", info.Tag, info.Name, message)
+	metaAck := &transferv1.MetaAck{}
+	metaAck.SetAllowUpload(false)
+	metaAck.SetMessage(message)
+	metaAck.SetErrorCode(codeRejected)
+
+	uploadRes := &transferv1.UploadFileResponse{}
+	uploadRes.SetMetaAck(metaAck)
+
+	return stream.Send(uploadRes)
+}
+
+func (s *FileService) sendUploadConflict(stream transferv1.FileTransferService_UploadFileServer, info *UploadInfo, message string) error {
+	s.logger.Debug("sending upload conflict ack", "transfer_id", TransferID(stream.Context()), "message", message, "name", info.Name)
+	s.metrics.upload(outcomeRejected)
+	s.auditUpload(stream.Context(), outcomeRejected, info, message)
+	s.rejected(stream.Context(), "upload", info.Tag, info.Name, message)
+	metaAck := &transferv1.MetaAck{}
+	metaAck.SetAllowUpload(false)
+	metaAck.SetMessage(message)
+	metaAck.SetErrorCode(codeExists)
+	metaAck.SetOutcome(transferv1.ConflictOutcome_CONFLICT_OUTCOME_FAILED)
+	metaAck.SetName(info.Name)
+
+	uploadRes := &transferv1.UploadFileResponse{}
+	uploadRes.SetMetaAck(metaAck)
+
+	return stream.Send(uploadRes)
+}
+
+// sendUploadCompleted 无需接收数据即完成上传，result 为指标和审计中记录的结果：
+// 内容相同而跳过时为 outcomeSkipped，去重链接已有内容时为 outcomeSuccess
+func (s *FileService) sendUploadCompleted(stream transferv1.FileTransferService_UploadFileServer, info *UploadInfo, result, message string, outcome transferv1.ConflictOutcome, fileName string) error {
+	s.logger.Debug("sending upload completed ack", "transfer_id", TransferID(stream.Context()), "message", message, "outcome", outcome, "name", fileName)
+	s.metrics.upload(result)
+	s.auditUpload(stream.Context(), result, info, message)
+	metaAck := &transferv1.MetaAck{}
+	metaAck.SetAllowUpload(false)
+	metaAck.SetCompleted(true)
+	metaAck.SetMessage(message)
+	metaAck.SetOutcome(outcome)
+	metaAck.SetName(fileName)
+
+	uploadRes := &transferv1.UploadFileResponse{}
+	uploadRes.SetMetaAck(metaAck)
+
+	return stream.Send(uploadRes)
+}
+
+func (s *FileService) sendUploadError(stream transferv1.FileTransferService_UploadFileServer, info *UploadInfo, code transferv1.ErrorCode, message string) error {
+	s.logger.Debug("sending upload error response", "transfer_id", TransferID(stream.Context()), "code", code, "message", message)
+	s.metrics.upload(outcomeFailed)
+	s.auditUpload(stream.Context(), outcomeFailed, info, message)
+	s.rejected(stream.Context(), "upload", info.Tag, info.Name, message)
 	result := &transferv1.TransferResult{}
 	result.SetStatus(false)
 	result.SetMessage(message)
+	result.SetErrorCode(code)
+	result.SetTransferId(TransferID(stream.Context()))
 
 	uploadRes := &transferv1.UploadFileResponse{}
 	uploadRes.SetResult(result)
@@ -183,11 +411,14 @@ func (s *FileService) sendUploadError(stream transferv1.FileTransferService_Uplo
 	return stream.Send(uploadRes)
 }
 
-func (s *FileService) sendUploadSuccess(stream transferv1.FileTransferService_UploadFileServer, message string) error {
-	s.logDebug("sending upload success response: %s", message)
+func (s *FileService) sendUploadSuccess(stream transferv1.FileTransferService_UploadFileServer, info *UploadInfo, message string) error {
+	s.logger.Debug("sending upload success response", "transfer_id", TransferID(stream.Context()), "message", message)
+	s.metrics.upload(outcomeSuccess)
+	s.auditUpload(stream.Context(), outcomeSuccess, info, message)
 	result := &transferv1.TransferResult{}
 	result.SetStatus(true)
 	result.SetMessage(message)
+	result.SetTransferId(TransferID(stream.Context()))
 
 	uploadRes := &transferv1.UploadFileResponse{}
 	uploadRes.SetResult(result)
@@ -195,11 +426,33 @@ func (s *FileService) sendUploadSuccess(stream transferv1.FileTransferService_Up
 	return stream.Send(uploadRes)
 }
 
-func (s *FileService) sendDownloadError(stream transferv1.FileTransferService_DownloadFileServer, message string) error {
-	s.logDebug("sending download error response: %s", message)
+// sendUploadSkipped 流式上传接收完成后发现内容相同，结果中带上跳过的冲突结果
+func (s *FileService) sendUploadSkipped(stream transferv1.FileTransferService_UploadFileServer, info *UploadInfo, message string) error {
+	s.logger.Debug("sending upload skipped response", "transfer_id", TransferID(stream.Context()), "message", message)
+	s.metrics.upload(outcomeSkipped)
+	s.auditUpload(stream.Context(), outcomeSkipped, info, message)
+	result := &transferv1.TransferResult{}
+	result.SetStatus(true)
+	result.SetMessage(message)
+	result.SetOutcome(transferv1.ConflictOutcome_CONFLICT_OUTCOME_SKIPPED)
+	result.SetTransferId(TransferID(stream.Context()))
+
+	uploadRes := &transferv1.UploadFileResponse{}
+	uploadRes.SetResult(result)
+
+	return stream.Send(uploadRes)
+}
+
+func (s *FileService) sendDownloadError(stream transferv1.FileTransferService_DownloadFileServer, info *DownloadInfo, code transferv1.ErrorCode, message string) error {
+	s.logger.Debug("sending download error response", "transfer_id", TransferID(stream.Context()), "code", code, "message", message)
+	s.metrics.download(outcomeFailed)
+	s.auditDownload(stream.Context(), outcomeFailed, info, message)
+	s.rejected(stream.Context(), "download", info.Tag, info.Name, message)
 	result := &transferv1.TransferResult{}
 	result.SetStatus(false)
 	result.SetMessage(message)
+	result.SetErrorCode(code)
+	result.SetTransferId(TransferID(stream.Context()))
 
 	uploadRes := &transferv1.DownloadFileResponse{}
 	uploadRes.SetResult(result)
@@ -207,11 +460,14 @@ func (s *FileService) sendDownloadError(stream transferv1.FileTransferService_Do
 	return stream.Send(uploadRes)
 }
 
-func (s *FileService) sendDownloadSuccess(stream transferv1.FileTransferService_DownloadFileServer, message string) error {
-	s.logDebug("sending download success response: %s", message)
+func (s *FileService) sendDownloadSuccess(stream transferv1.FileTransferService_DownloadFileServer, info *DownloadInfo, message string) error {
+	s.logger.Debug("sending download success response", "transfer_id", TransferID(stream.Context()), "message", message)
+	s.metrics.download(outcomeSuccess)
+	s.auditDownload(stream.Context(), outcomeSuccess, info, message)
 	result := &transferv1.TransferResult{}
 	result.SetStatus(true)
 	result.SetMessage(message)
+	result.SetTransferId(TransferID(stream.Context()))
 
 	uploadRes := &transferv1.DownloadFileResponse{}
 	uploadRes.SetResult(result)
@@ -219,9 +475,151 @@ func (s *FileService) sendDownloadSuccess(stream transferv1.FileTransferService_
 	return stream.Send(uploadRes)
 }
 
+// replacedSize 返回将被替换的同名文件大小，开启版本管理时旧文件仍然保留
+func (s *FileService) replacedSize(fileTag, fileName string) int64 {
+	if s.keepVersions > 0 {
+		return 0
+	}
+	if info, err := os.Stat(utils.FileSuite.JoinPath(s.savePath, fileTag, fileName)); err == nil && !info.IsDir() {
+		return info.Size()
+	}
+	return 0
+}
+
+// storageBudget 上传开始时的磁盘剩余空间和标签配额，接收过程中按将要写入的总量检查
+type storageBudget struct {
+	tag      string
+	free     int64
+	replaced int64
+	// quota 为 0 时不限制
+	quota int64
+	used  int64
+}
+
+// checkStorage 检查磁盘剩余空间和标签配额，同名文件将被替换时扣除其大小
+func (s *FileService) checkStorage(fileTag, fileName string, fileSize int64) (*storageBudget, error) {
+	budget := &storageBudget{tag: fileTag, replaced: s.replacedSize(fileTag, fileName)}
+
+	_, free, err := common.DiskUsage(s.savePath)
+	if err != nil {
+		return nil, fmt.Errorf("disk space check failed: %w", err)
+	}
+	budget.free = free
+
+	if quota, ok := s.quotas[fileTag]; ok && quota > 0 {
+		used, _, err := common.GetTagUsage(s.savePath, fileTag)
+		if err != nil {
+			return nil, fmt.Errorf("quota check failed: %w", err)
+		}
+		budget.quota = quota
+		budget.used = used
+	}
+	s.logger.Debug("storage check", "need", fileSize, "replaced", budget.replaced, "free", free, "used", budget.used, "quota", budget.quota)
+
+	return budget, budget.check(fileSize)
+}
+
+// check 检查共写入 need 字节后是否超出磁盘剩余空间或标签配额
+func (b *storageBudget) check(need int64) error {
+	if need-b.replaced > b.free {
+		return fmt.Errorf("insufficient disk space: need %s, available %s",
+			utils.PrettySize(need), utils.PrettySize(b.free))
+	}
+	if b.quota > 0 && b.used-b.replaced+need > b.quota {
+		return fmt.Errorf("quota exceeded for tag %s: used %s, need %s, quota %s",
+			b.tag, utils.PrettySize(b.used), utils.PrettySize(need), utils.PrettySize(b.quota))
+	}
+	return nil
+}
+
+// runStorageGC 定期回收不再被引用的 blob 和过期的分片
+func (s *FileService) runStorageGC(ctx context.Context) {
+	ticker := time.NewTicker(storageGCInterval)
+	defer ticker.Stop()
+
+	for {
+		if s.blobs != nil {
+			removed, freed, err := s.blobs.GC()
+			if err != nil {
+				s.logger.Error("gc failed", "err", err)
+			} else if removed > 0 {
+				s.logger.Info("blob gc removed blobs", "removed", removed, "freed", freed)
+			}
+			s.logger.Debug("blob gc finished", "removed", removed, "freed", freed)
+		}
+
+		removed, freed, err := s.chunks.Prune(s.chunkTTL)
+		if err != nil {
+			s.logger.Error("gc failed", "err", err)
+		} else if removed > 0 {
+			s.logger.Info("chunk gc pruned chunks", "removed", removed, "freed", freed)
+		}
+		s.logger.Debug("chunk prune finished", "removed", removed, "freed", freed, "ttl", s.chunkTTL)
+		s.metrics.storageChanged()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// archiveVersion 开启版本管理时将当前文件归档为历史版本，并按保留策略清理旧版本
+func (s *FileService) archiveVersion(fileTag, fileName string) error {
+	if s.keepVersions <= 0 {
+		return nil
+	}
+
+	versionID, err := common.ArchiveFileVersion(s.savePath, fileTag, fileName)
+	if err != nil {
+		return err
+	}
+	if versionID == "" {
+		return nil
+	}
+	s.logger.Info("version archived", "tag", fileTag, "name", fileName, "version", versionID)
+
+	removed, err := common.PruneFileVersions(s.savePath, fileTag, fileName, s.keepVersions, s.versionMaxAge)
+	if err != nil {
+		return err
+	}
+	s.logger.Debug("version retention applied", "tag", fileTag, "name", fileName, "keep", s.keepVersions, "max_age", s.versionMaxAge, "removed", removed)
+	return nil
+}
+
+// storeChunk 校验内容定义分片与分片列表一致后保存
+func (s *FileService) storeChunk(chunkHashes []string, chunk *transferv1.ChunkData) error {
+	index := chunk.GetChunk()
+	if index < 1 || index > int64(len(chunkHashes)) {
+		return fmt.Errorf("chunk index out of range: %d", index)
+	}
+	if chunk.GetHash() != chunkHashes[index-1] {
+		return fmt.Errorf("chunk %d hash does not match metadata", index)
+	}
+	return s.chunks.Put(chunk.GetHash(), chunk.GetData())
+}
+
+// assembleChunks 按分片列表顺序从分片存储重建文件
+func (s *FileService) assembleChunks(chunkHashes []string, w io.Writer) (int64, error) {
+	var written int64
+	for i, hash := range chunkHashes {
+		f, err := s.chunks.Open(hash)
+		if err != nil {
+			return written, fmt.Errorf("missing chunk %d: %w", i+1, err)
+		}
+		n, err := io.Copy(w, f)
+		f.Close()
+		written += n
+		if err != nil {
+			return written, fmt.Errorf("copy chunk %d failed: %w", i+1, err)
+		}
+	}
+	return written, nil
+}
+
 func (s *FileService) ServerCheck(ctx context.Context, in *transferv1.ServerCheckRequest) (*transferv1.ServerCheckResponse, error) {
-	log.Printf("[Ping] Received")
-	s.logDebug("server check payload: status=%t", in.GetStatus())
+	s.logger.Info("ping received", "status", in.GetStatus())
 
 	checkRes := &transferv1.ServerCheckResponse{}
 
@@ -230,21 +628,26 @@ func (s *FileService) ServerCheck(ctx context.Context, in *transferv1.ServerChec
 	} else {
 		checkRes.SetStatus(false)
 	}
-	s.logDebug("server check response: status=%t", checkRes.GetStatus())
+	s.logger.Debug("server check response", "status", checkRes.GetStatus())
 	return checkRes, nil
 }
 
 func (s *FileService) UploadFile(stream transferv1.FileTransferService_UploadFileServer) error {
+	ctx := stream.Context()
+	info := &UploadInfo{Peer: peerAddress(ctx), TransferID: TransferID(ctx)}
+	logger := s.logger.With("transfer_id", info.TransferID, "peer", info.Peer)
+	defer s.metrics.stream("upload")()
+
 	req, err := stream.Recv()
 	if err != nil {
-		s.logDebug("failed to receive upload metadata request: %v", err)
+		logger.Debug("failed to receive upload metadata request", "err", err)
 		return err
 	}
 
 	metadata := req.GetMetadata()
 	if metadata == nil {
-		s.logDebug("upload request missing metadata")
-		return s.sendUploadError(stream, "Missing metadata")
+		logger.Debug("upload request missing metadata")
+		return s.sendUploadError(stream, info, codeRejected, "Missing metadata")
 	}
 
 	fileTag := metadata.GetTag()
@@ -253,85 +656,338 @@ func (s *FileService) UploadFile(stream transferv1.FileTransferService_UploadFil
 	fileChunks := metadata.GetChunks()
 	fileChunksize := metadata.GetChunksize()
 	fileHash := metadata.GetHash()
+	chunkHashes := metadata.GetChunkHashes()
+	streaming := metadata.GetStreaming()
+	info.Tag = fileTag
+	info.Name = fileName
+	info.Size = fileSize
+	info.Hash = fileHash
+	info.Streaming = streaming
+
+	logger = logger.With("tag", fileTag, "name", fileName)
+
+	if !common.IsValidName(fileTag) || !common.IsValidName(fileName) {
+		logger.Debug("upload rejected because tag or name is invalid")
+		return s.sendUploadReject(stream, info, "Invalid tag or name")
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attrTransferID.String(info.TransferID), attrTag.String(fileTag), attrName.String(fileName), attrSize.Int64(fileSize))
+	logger.Info("upload metadata", "size", fileSize, "chunks", fileChunks, "chunksize", fileChunksize, "hash", fileHash, "content_defined", len(chunkHashes) > 0, "streaming", streaming)
+
+	releaseSlot, err := s.uploadSlot(stream, info, logger)
+	if err != nil {
+		return err
+	}
+	defer releaseSlot()
+
+	// 流式上传的大小和哈希在结尾才知道，无法使用分片去重和增量传输
+	if streaming && (len(chunkHashes) > 0 || metadata.GetDelta()) {
+		logger.Debug("streaming upload rejected because content-defined or delta transfer was requested")
+		return s.sendUploadReject(stream, info, "Streaming upload does not support content-defined or delta transfer")
+	}
 
-	log.Printf("[Upload] Metadata: tag=%s, name=%s, size=%d, chunks=%d x %d Byte, hash=%s\n",
-		fileTag, fileName, fileSize, fileChunks, fileChunksize, fileHash)
-	s.logDebug("upload metadata received: tag=%s name=%s size=%d chunks=%d hash=%s", fileTag, fileName, fileSize, fileChunks, fileHash)
+	if len(chunkHashes) > 0 {
+		if s.memoryMode {
+			logger.Debug("content-defined upload rejected because memory mode is enabled")
+			return s.sendUploadReject(stream, info, "Content-defined upload not supported in Memory Mode")
+		}
+		for _, hash := range chunkHashes {
+			if !common.IsValidHash(hash) {
+				logger.Debug("content-defined upload rejected because chunk hash is invalid", "hash", hash)
+				return s.sendUploadReject(stream, info, "Invalid chunk hash: "+hash)
+			}
+		}
+	}
+
+	// 内存存储放不下的文件直接拒绝，流式上传在接收时检查
+	if s.memStore != nil && fileSize > s.memStore.maxBytes {
+		logger.Debug("upload rejected because file exceeds memory store limit", "limit", s.memStore.maxBytes)
+		return s.sendUploadReject(stream, info, "File exceeds memory store limit")
+	}
+
+	var signatures *transferv1.BlockSignatures
+	var basisFilePath string
+	var existingHash string
+	conflictPolicy := metadata.GetConflict()
+	conflictOutcome := transferv1.ConflictOutcome_CONFLICT_OUTCOME_NONE
+	var budget *storageBudget
+	// placeholder 重命名时占位的空文件，上传成功前失败时删除
+	var placeholder string
+	defer func() {
+		if placeholder != "" {
+			os.Remove(placeholder)
+		}
+	}()
 
 	if !s.memoryMode {
-		ok, err := common.FileIsExist(s.savePath, fileTag, fileName, fileHash)
+		// 同名文件按冲突策略处理，覆盖时在接收完成后替换，开启版本管理时归档为历史版本
+		existingFilePath := utils.FileSuite.JoinPath(s.savePath, fileTag, fileName)
+		if utils.FileSuite.Exists(existingFilePath) {
+			if conflictPolicy == transferv1.ConflictPolicy_CONFLICT_POLICY_FAIL {
+				logger.Warn("upload conflict", "policy", "fail")
+				return s.sendUploadConflict(stream, info, "File already exists")
+			}
+
+			_, hashSpan := s.startSpan(ctx, "qback.hash")
+			currentHash, err := common.CalcBlake3(existingFilePath)
+			endSpan(hashSpan, err)
+			if err != nil {
+				logger.Error("upload pre-check failed", "err", err, "tag", fileTag, "name", fileName)
+				return s.sendUploadError(stream, info, codeInternal, "File not found")
+			}
+			logger.Debug("upload conflict", "policy", conflictPolicy, "identical", currentHash == fileHash)
+
+			switch conflictPolicy {
+			case transferv1.ConflictPolicy_CONFLICT_POLICY_UNSPECIFIED:
+				if streaming {
+					existingHash = currentHash
+				} else if currentHash == fileHash {
+					logger.Debug("upload rejected because file exists")
+					return s.sendUploadError(stream, info, codeExists, "File already exists")
+				}
+				conflictOutcome = transferv1.ConflictOutcome_CONFLICT_OUTCOME_OVERWRITTEN
+			case transferv1.ConflictPolicy_CONFLICT_POLICY_SKIP_IF_IDENTICAL:
+				if streaming {
+					existingHash = currentHash
+				} else if currentHash == fileHash {
+					logger.Info("skipped identical file")
+					return s.sendUploadCompleted(stream, info, outcomeSkipped, "Identical file already exists", transferv1.ConflictOutcome_CONFLICT_OUTCOME_SKIPPED, fileName)
+				}
+				conflictOutcome = transferv1.ConflictOutcome_CONFLICT_OUTCOME_OVERWRITTEN
+			case transferv1.ConflictPolicy_CONFLICT_POLICY_OVERWRITE:
+				conflictOutcome = transferv1.ConflictOutcome_CONFLICT_OUTCOME_OVERWRITTEN
+			case transferv1.ConflictPolicy_CONFLICT_POLICY_RENAME_WITH_SUFFIX:
+				newName, err := common.SuffixedFileName(s.savePath, fileTag, fileName)
+				if err != nil {
+					logger.Error("rename failed", "err", err)
+					return s.sendUploadConflict(stream, info, "No free file name available")
+				}
+				logger.Warn("upload conflict, renamed", "new_name", newName)
+				placeholder = utils.FileSuite.JoinPath(s.savePath, fileTag, newName)
+				fileName = newName
+				info.Name = newName
+				conflictOutcome = transferv1.ConflictOutcome_CONFLICT_OUTCOME_RENAMED
+			default:
+				return s.sendUploadReject(stream, info, fmt.Sprintf("Invalid conflict policy: %d", conflictPolicy))
+			}
+
+			// 重命名后仍以原文件作为增量基准
+			if metadata.GetDelta() && len(chunkHashes) == 0 {
+				signatures, err = common.CalcBlockSignatures(existingFilePath)
+				if err != nil {
+					logger.Error("calc block signatures failed, fallback to full upload", "err", err)
+				} else {
+					basisFilePath = existingFilePath
+					logger.Info("delta basis", "blocks", len(signatures.GetBlocks()), "block_size", signatures.GetBlockSize())
+				}
+			}
+		}
+
+		budget, err = s.checkStorage(fileTag, fileName, fileSize)
+		if err != nil {
+			logger.Warn("upload rejected", "err", err, "tag", fileTag, "name", fileName, "size", fileSize)
+			return s.sendUploadReject(stream, info, err.Error())
+		}
+	}
+
+	// 回调在去重、归档等任何修改之前执行，拒绝时不会留下痕迹
+	if err := s.uploadStart(stream.Context(), info); err != nil {
+		logger.Warn("upload rejected by hook", "err", err)
+		return s.sendUploadReject(stream, info, err.Error())
+	}
+
+	if s.blobs != nil && !streaming {
+		if !common.IsValidHash(fileHash) {
+			logger.Debug("upload rejected because hash is invalid", "hash", fileHash)
+			return s.sendUploadReject(stream, info, "Invalid hash: "+fileHash)
+		}
+
+		dstFilePath, err := common.SetTargetFilePath(s.savePath, fileTag, fileName)
 		if err != nil {
-			log.Printf("[Upload] File error: %s \n", err.Error())
-			s.logDebug("upload pre-check failed: tag=%s name=%s err=%v", fileTag, fileName, err)
-			return s.sendUploadError(stream, "File not found")
+			logger.Error("failed to resolve upload target path", "err", err)
+			return s.sendUploadError(stream, info, codeInternal, "File upload path unavailable")
 		}
-		if ok {
-			s.logDebug("upload rejected because file exists: tag=%s name=%s", fileTag, fileName)
-			return s.sendUploadError(stream, "File already exists")
+
+		// 归档在确认 blob 存在并建立链接之后执行，重命名后的文件名是新占用的，不需要归档
+		var archiveErr error
+		archive := func() error {
+			if placeholder == "" {
+				archiveErr = s.archiveVersion(fileTag, fileName)
+			}
+			return archiveErr
+		}
+		linked, err := s.blobs.LinkExisting(fileHash, dstFilePath, archive)
+		if archiveErr != nil {
+			logger.Error("archive failed", "err", archiveErr)
+			return s.sendUploadError(stream, info, codeInternal, "Failed to archive existing file")
+		}
+		if err != nil {
+			logger.Error("failed to link existing blob", "err", err, "hash", fileHash)
+			return s.sendUploadError(stream, info, codeInternal, "Failed to link existing content")
+		}
+		if linked {
+			placeholder = ""
+			logger.Info("deduplicated", "hash", fileHash)
+			info.Path = dstFilePath
+			s.metrics.storageChanged()
+			s.uploadComplete(stream.Context(), info)
+			return s.sendUploadCompleted(stream, info, outcomeSuccess, "Content already stored", conflictOutcome, fileName)
 		}
 	}
 
+	active := s.transfers.add(ctx, "upload", fileTag, fileName, fileSize)
+	defer s.transfers.remove(active)
+	limit, releaseLimit := s.throttle(ctx)
+	defer releaseLimit()
+
 	metaAck := &transferv1.MetaAck{}
 	metaAck.SetAllowUpload(true)
 	metaAck.SetMessage("Ready to receive")
+	metaAck.SetOutcome(conflictOutcome)
+	metaAck.SetName(fileName)
+	if signatures != nil {
+		metaAck.SetMessage("Ready to receive delta")
+		metaAck.SetSignatures(signatures)
+	}
 
 	uploadRes := &transferv1.UploadFileResponse{}
 	uploadRes.SetMetaAck(metaAck)
 
-	s.logDebug("sending upload ack: allow=%t message=%s", metaAck.GetAllowUpload(), metaAck.GetMessage())
+	logger.Debug("sending upload ack", "allow", metaAck.GetAllowUpload(), "message", metaAck.GetMessage())
 	if err := stream.Send(uploadRes); err != nil {
-		s.logDebug("failed to send upload ack: %v", err)
+		logger.Debug("failed to send upload ack", "err", err)
 		return err
 	}
 
 	var bufWriter *bufio.Writer
 	var recFile *os.File
 	var recFilePath string
-	var memBuf []byte
+	var targetFilePath string
+	var basisFile *os.File
+	var deltaCopied int64
+	var trailer *transferv1.FileTrailer
+	var sink *memorySink
 	startTime := time.Now()
 	var totalReceived int64 = 0
 
 	if s.memoryMode {
-		memBuf = make([]byte, 0, fileSize)
-		s.logDebug("upload memory buffer allocated: cap=%d", fileSize)
+		var sizeHint int64
+		if !streaming {
+			sizeHint = fileSize
+		}
+		sink = s.newMemorySink(sizeHint)
+		defer sink.release()
+		logger.Debug("upload memory sink created", "keep", sink.keep)
 	} else {
 		dstFilePath, err := common.SetTargetFilePath(s.savePath, fileTag, fileName)
 		if err != nil {
-			log.Printf("[Upload] Create file path error: %s \n", err.Error())
-			s.logDebug("failed to resolve upload target path: %v", err)
-			return s.sendUploadError(stream, "File upload path unavailable")
+			logger.Error("failed to resolve upload target path", "err", err)
+			return s.sendUploadError(stream, info, codeInternal, "File upload path unavailable")
 		}
-		s.logDebug("upload target path resolved: %s", dstFilePath)
+		logger.Debug("upload target path resolved", "path", dstFilePath)
 
-		recFile, err = common.OpenTargetFile(dstFilePath, common.FileWrite)
+		if s.blobs != nil {
+			recFile, err = s.blobs.CreateTemp()
+		} else {
+			recFile, err = common.CreateTempFile(s.savePath)
+		}
 		if err != nil {
-			log.Printf("[Upload] Create file error: %s \n", err.Error())
-			s.logDebug("failed to open upload target file: %v", err)
-			return s.sendUploadError(stream, "Failed to access destination file")
+			logger.Error("failed to open upload target file", "err", err)
+			return s.sendUploadError(stream, info, codeInternal, "Failed to access destination file")
 		}
 		defer recFile.Close()
 
-		recFilePath = dstFilePath
+		recFilePath = recFile.Name()
+		targetFilePath = dstFilePath
 		bufWriter = bufio.NewWriterSize(recFile, 64*1024)
+
+		if basisFilePath != "" {
+			basisFile, err = os.Open(basisFilePath)
+			if err != nil {
+				os.Remove(recFilePath)
+				logger.Error("failed to open delta basis file", "err", err)
+				return s.sendUploadError(stream, info, codeInternal, "Failed to access existing file")
+			}
+			defer basisFile.Close()
+		}
 	}
 
-	log.Println("[Upload] Start receiving data")
+	logger.Info("start receiving data")
+	writePhase := s.startPhase(ctx, "qback.upload.write")
+	defer writePhase.end(nil)
 	for {
 		req, err := stream.Recv()
 
 		if err == io.EOF {
-			log.Println("[Upload] Reached EOF, processing final validation")
-			s.logDebug("upload stream reached EOF")
+			logger.Info("reached EOF, processing final validation")
 			break
 		}
 
 		if err != nil {
-			log.Printf("[Upload] Receive error: %v\n", err)
 			if !s.memoryMode {
 				os.Remove(recFilePath)
 			}
-			s.logDebug("upload receive failed: %v, cleaned file=%s", err, recFilePath)
-			return s.sendUploadError(stream, "Receive error: file transfer incomplete")
+			logger.Error("upload receive failed", "err", err, "file", recFilePath)
+			return s.sendUploadError(stream, info, codeInternal, "Receive error: file transfer incomplete")
+		}
+
+		if t := req.GetTrailer(); t != nil {
+			if !streaming {
+				if !s.memoryMode {
+					os.Remove(recFilePath)
+				}
+				logger.Warn("unexpected trailer in non-streaming upload", "file", recFilePath)
+				return s.sendUploadError(stream, info, codeRejected, "Receive error: unexpected trailer")
+			}
+			trailer = t
+			logger.Debug("upload trailer received", "size", t.GetSize(), "hash", t.GetHash())
+			continue
+		}
+
+		if delta := req.GetDelta(); delta != nil {
+			if basisFile == nil {
+				os.Remove(recFilePath)
+				logger.Warn("unexpected delta data without basis", "file", recFilePath)
+				return s.sendUploadError(stream, info, codeRejected, "Receive error: unexpected delta data")
+			}
+			for _, op := range delta.GetOps() {
+				if err := limit.wait(ctx, len(op.GetData())); err != nil {
+					os.Remove(recFilePath)
+					logger.Error("upload rate limit wait failed", "err", err, "file", recFilePath)
+					return s.sendUploadError(stream, info, codeInternal, "Receive error: file transfer incomplete")
+				}
+				// 复制指令不占用传输量，按写入的字节数检查声明的大小和存储空间
+				size, err := common.DeltaOpSize(signatures, op)
+				if err != nil {
+					os.Remove(recFilePath)
+					logger.Warn("invalid delta op", "err", err, "file", recFilePath)
+					return s.sendUploadError(stream, info, codeRejected, "Receive error: invalid delta")
+				}
+				written := totalReceived + deltaCopied + size
+				if written > fileSize {
+					os.Remove(recFilePath)
+					logger.Warn("delta upload exceeds declared size", "size", fileSize, "written", written)
+					return s.sendUploadError(stream, info, codeRejected, "Receive error: data exceeds declared size")
+				}
+				if err := budget.check(written); err != nil {
+					os.Remove(recFilePath)
+					logger.Warn("delta upload exceeds storage budget", "err", err, "written", written)
+					return s.sendUploadError(stream, info, codeRejected, err.Error())
+				}
+				n, err := common.ApplyDeltaOp(bufWriter, basisFile, signatures, op)
+				if err != nil {
+					os.Remove(recFilePath)
+					logger.Error("apply delta op failed", "err", err, "file", recFilePath)
+					return s.sendUploadError(stream, info, codeRejected, "Receive error: invalid delta")
+				}
+				if len(op.GetData()) > 0 {
+					totalReceived += n
+					s.metrics.received(n)
+				} else {
+					deltaCopied += n
+				}
+				active.advance(n)
+			}
+			continue
 		}
 
 		chunk := req.GetChunk()
@@ -344,55 +1000,193 @@ func (s *FileService) UploadFile(stream transferv1.FileTransferService_UploadFil
 			continue
 		}
 		if s.shouldLogChunk(chunk.GetChunk(), fileChunks) {
-			s.logDebug("received upload chunk=%d/%d bytes=%d", chunk.GetChunk(), fileChunks, len(fileData))
+			logger.Debug("received upload chunk", "chunk", chunk.GetChunk(), "chunks", fileChunks, "bytes", len(fileData))
+		}
+
+		if err := limit.wait(ctx, len(fileData)); err != nil {
+			if !s.memoryMode {
+				os.Remove(recFilePath)
+			}
+			logger.Error("upload rate limit wait failed", "err", err, "file", recFilePath)
+			return s.sendUploadError(stream, info, codeInternal, "Receive error: file transfer incomplete")
 		}
 
+		chunkStart := time.Now()
 		totalReceived += int64(len(fileData))
+		s.metrics.received(int64(len(fileData)))
+		active.advance(int64(len(fileData)))
 
-		if s.memoryMode {
-			memBuf = append(memBuf, fileData...)
+		// 非流式上传不能超过声明的大小，流式上传的大小在结尾才知道
+		if !streaming && totalReceived > fileSize {
+			if !s.memoryMode {
+				os.Remove(recFilePath)
+			}
+			logger.Warn("upload exceeds declared size", "size", fileSize, "received", totalReceived)
+			return s.sendUploadError(stream, info, codeRejected, "Receive error: data exceeds declared size")
+		}
+		// 每次写入前检查磁盘空间和配额，分片在分片存储中另存一份，与重建的文件一起计入
+		if budget != nil {
+			need := totalReceived
+			if len(chunkHashes) > 0 {
+				need += fileSize
+			}
+			if err := budget.check(need); err != nil {
+				writePhase.end(err)
+				os.Remove(recFilePath)
+				logger.Warn("upload exceeds storage budget", "err", err, "received", totalReceived)
+				return s.sendUploadError(stream, info, codeRejected, err.Error())
+			}
+		}
+
+		writeStart := time.Now()
+		if len(chunkHashes) > 0 {
+			if err := s.storeChunk(chunkHashes, chunk); err != nil {
+				writePhase.end(err)
+				os.Remove(recFilePath)
+				logger.Error("store content-defined chunk failed", "chunk", chunk.GetChunk(), "err", err, "file", recFilePath)
+				return s.sendUploadError(stream, info, codeIntegrity, "Receive error: invalid chunk")
+			}
+		} else if s.memoryMode {
+			if _, err := sink.Write(fileData); err != nil {
+				writePhase.end(err)
+				logger.Warn("memory upload chunk rejected", "chunk", chunk.GetChunk(), "err", err)
+				return s.sendUploadError(stream, info, codeRejected, fmt.Sprintf("Receive error: %v", err))
+			}
 		} else {
 			if _, err := bufWriter.Write(fileData); err != nil {
-				log.Printf("[Upload] Write error: %v\n", err)
+				writePhase.end(err)
 				os.Remove(recFilePath)
-				s.logDebug("write upload chunk failed: chunk=%d err=%v cleaned file=%s", chunk.GetChunk(), err, recFilePath)
-				return s.sendUploadError(stream, "Receive error: write file error")
+				logger.Error("write upload chunk failed", "chunk", chunk.GetChunk(), "err", err, "file", recFilePath)
+				return s.sendUploadError(stream, info, codeInternal, "Receive error: write file error")
 			}
 		}
 
-		if fileChunks > 0 {
-			common.ShowProgress(chunk.GetChunk(), fileChunks)
+		writePhase.add(len(fileData), writeStart)
+		s.metrics.chunk("upload", chunkStart)
+	}
+	writePhase.end(nil)
+
+	if len(chunkHashes) > 0 {
+		_, assembleSpan := s.startSpan(ctx, "qback.upload.assemble")
+		written, err := s.assembleChunks(chunkHashes, bufWriter)
+		endSpan(assembleSpan, err)
+		if err != nil {
+			os.Remove(recFilePath)
+			logger.Error("assemble content-defined chunks failed", "err", err, "file", recFilePath)
+			return s.sendUploadError(stream, info, codeInternal, fmt.Sprintf("Receive error: %v", err))
 		}
+		logger.Info("assembled chunks", "chunks", len(chunkHashes), "received", totalReceived, "reused", written-totalReceived)
 	}
 
 	if !s.memoryMode {
-		if err := bufWriter.Flush(); err != nil {
+		_, flushSpan := s.startSpan(ctx, "qback.upload.flush")
+		err := bufWriter.Flush()
+		endSpan(flushSpan, err)
+		if err != nil {
 			os.Remove(recFilePath)
-			log.Printf("[Upload] Flush error: %v\n", err)
-			s.logDebug("flush upload file failed: %v cleaned file=%s", err, recFilePath)
-			return s.sendUploadError(stream, "Receive error: save file")
+			logger.Error("flush upload file failed", "err", err, "file", recFilePath)
+			return s.sendUploadError(stream, info, codeInternal, "Receive error: save file")
 		}
-		if err := recFile.Sync(); err != nil {
+		_, syncSpan := s.startSpan(ctx, "qback.upload.sync")
+		err = recFile.Sync()
+		endSpan(syncSpan, err)
+		if err != nil {
 			os.Remove(recFilePath)
-			log.Printf("[Upload] Sync error: %v\n", err)
-			s.logDebug("sync upload file failed: %v cleaned file=%s", err, recFilePath)
-			return s.sendUploadError(stream, "Receive error: sync file")
+			logger.Error("sync upload file failed", "err", err, "file", recFilePath)
+			return s.sendUploadError(stream, info, codeInternal, "Receive error: sync file")
+		}
+	}
+
+	if streaming {
+		if trailer == nil {
+			if !s.memoryMode {
+				os.Remove(recFilePath)
+			}
+			logger.Warn("streaming upload ended without trailer", "file", recFilePath)
+			return s.sendUploadError(stream, info, codeRejected, "Receive error: missing trailer")
 		}
+		fileSize = trailer.GetSize()
+		fileHash = trailer.GetHash()
+		info.Size = fileSize
+		info.Hash = fileHash
+		logger.Info("trailer received", "size", fileSize, "hash", fileHash)
 	}
 
-	if err := common.ValidateFileIntegrity(common.FileValidationInfo{
-		Data:         memBuf,
+	_, validateSpan := s.startSpan(ctx, "qback.upload.validate", attrSize.Int64(fileSize))
+	validation := common.FileValidationInfo{
 		FilePath:     recFilePath,
 		ExpectedSize: fileSize,
 		ExpectedHash: fileHash,
 		IsMemory:     s.memoryMode,
-	}); err != nil {
+	}
+	if sink != nil {
+		validation.ActualSize = sink.size
+		validation.ActualHash = sink.hash()
+	}
+	err = common.ValidateFileIntegrity(validation)
+	endSpan(validateSpan, err)
+	if err != nil {
 		if !s.memoryMode {
 			os.Remove(recFilePath)
 		}
-		log.Printf("[Upload] Validation error: %v\n", err)
-		s.logDebug("upload validation failed: %v cleaned file=%s", err, recFilePath)
-		return s.sendUploadError(stream, fmt.Sprintf("Receive error: %v", err))
+		s.metrics.validationFailed()
+		logger.Error("upload validation failed", "err", err, "file", recFilePath)
+		return s.sendUploadError(stream, info, codeIntegrity, fmt.Sprintf("Receive error: %v", err))
+	}
+
+	if streaming && !s.memoryMode {
+		if existingHash != "" && existingHash == fileHash {
+			os.Remove(recFilePath)
+			if conflictPolicy == transferv1.ConflictPolicy_CONFLICT_POLICY_SKIP_IF_IDENTICAL {
+				logger.Info("skipped identical file")
+				return s.sendUploadSkipped(stream, info, "Identical file already exists")
+			}
+			logger.Debug("upload rejected because file exists")
+			return s.sendUploadError(stream, info, codeExists, "File already exists")
+		}
+		if s.blobs != nil && !common.IsValidHash(fileHash) {
+			os.Remove(recFilePath)
+			logger.Debug("upload rejected because trailer hash is invalid", "hash", fileHash)
+			return s.sendUploadError(stream, info, codeRejected, "Invalid hash: "+fileHash)
+		}
+	}
+
+	// 重命名后的文件名是新占用的，没有需要归档的旧版本
+	if !s.memoryMode && placeholder == "" {
+		if err := s.archiveVersion(fileTag, fileName); err != nil {
+			os.Remove(recFilePath)
+			logger.Error("archive existing file failed", "err", err, "file", recFilePath)
+			return s.sendUploadError(stream, info, codeInternal, "Receive error: archive existing file")
+		}
+	}
+
+	if s.blobs != nil {
+		recFile.Close()
+		if err := s.blobs.Commit(recFilePath, fileHash, targetFilePath); err != nil {
+			os.Remove(recFilePath)
+			logger.Error("store upload blob failed", "err", err, "file", recFilePath)
+			return s.sendUploadError(stream, info, codeInternal, "Receive error: store file")
+		}
+		logger.Debug("upload stored as blob", "hash", fileHash, "target", targetFilePath)
+	} else if !s.memoryMode {
+		recFile.Close()
+		if err := os.Rename(recFilePath, targetFilePath); err != nil {
+			os.Remove(recFilePath)
+			logger.Error("move upload file failed", "err", err, "file", recFilePath)
+			return s.sendUploadError(stream, info, codeInternal, "Receive error: store file")
+		}
+	}
+	placeholder = ""
+
+	if sink != nil && sink.keep {
+		evicted := sink.commit(fileTag, fileName, fileHash)
+		if evicted > 0 {
+			logger.Info("memory store evicted files", "count", evicted)
+		}
+	}
+
+	if deltaCopied > 0 {
+		logger.Info("delta applied", "received", totalReceived, "reused", deltaCopied)
 	}
 
 	elapsed := time.Since(startTime)
@@ -400,67 +1194,122 @@ func (s *FileService) UploadFile(stream transferv1.FileTransferService_UploadFil
 	speedStr := common.FormatSpeed(speed)
 
 	if s.memoryMode {
-		log.Printf("[Upload] Success (memory): elapsed=%d, received=%d, speed=%s\n", elapsed.Milliseconds(), totalReceived, speedStr)
+		logger.Info("upload succeeded", "memory_mode", true, "elapsed_ms", elapsed.Milliseconds(), "received", totalReceived, "speed", speedStr)
 	} else {
-		log.Printf("[Upload] Success: %s, elapsed=%d, received=%d bytes, speed=%s\n", fileName, elapsed.Milliseconds(), totalReceived, speedStr)
+		logger.Info("upload succeeded", "elapsed_ms", elapsed.Milliseconds(), "received", totalReceived, "speed", speedStr)
 	}
-	s.logDebug("upload completed successfully: name=%s elapsed_ms=%d received=%d memory_mode=%t", fileName, elapsed.Milliseconds(), totalReceived, s.memoryMode)
+	logger.Debug("upload completed successfully", "name", fileName, "elapsed_ms", elapsed.Milliseconds(), "received", totalReceived, "memory_mode", s.memoryMode)
+
+	info.Path = targetFilePath
+	info.Received = totalReceived
+	info.Duration = elapsed
+	s.metrics.storageChanged()
+	s.uploadComplete(stream.Context(), info)
 
-	return s.sendUploadSuccess(stream, "Receive complete")
+	return s.sendUploadSuccess(stream, info, "Receive complete")
 }
 
 func (s *FileService) DownloadFile(in *transferv1.DownloadFileRequest, stream transferv1.FileTransferService_DownloadFileServer) error {
 	fileTag := in.GetTag()
 	fileName := in.GetName()
 	fileChunksize := in.GetChunksize()
+	versionID := in.GetVersionId()
+	ctx := stream.Context()
+	info := &DownloadInfo{Tag: fileTag, Name: fileName, VersionID: versionID, Peer: peerAddress(ctx), TransferID: TransferID(ctx)}
+	logger := s.logger.With("transfer_id", info.TransferID, "peer", info.Peer, "tag", fileTag, "name", fileName)
+	trace.SpanFromContext(ctx).SetAttributes(attrTransferID.String(info.TransferID), attrTag.String(fileTag), attrName.String(fileName))
+	defer s.metrics.stream("download")()
+
+	logger.Info("download requested", "version", versionID, "chunksize", fileChunksize)
+
+	if s.memoryMode && s.memStore == nil {
+		logger.Debug("download rejected because memory mode is enabled")
+		return s.sendDownloadError(stream, info, codeRejected, "download not supported in Memory Mode")
+	}
 
-	log.Printf("[Download] Request: tag=%s, name=%s, chunksize=%d\n", fileTag, fileName, fileChunksize)
-	s.logDebug("download request received: tag=%s name=%s chunksize=%d", fileTag, fileName, fileChunksize)
-
-	if s.memoryMode {
-		s.logDebug("download rejected because memory mode is enabled")
-		return s.sendDownloadError(stream, "download not supported in Memory Mode")
+	if !common.IsValidName(fileTag) || !common.IsValidName(fileName) {
+		logger.Debug("download rejected because tag or name is invalid")
+		return s.sendDownloadError(stream, info, codeRejected, "invalid tag or name")
 	}
 
-	ok, err := common.FileIsExist(s.savePath, fileTag, fileName, "")
+	releaseSlot, err := s.downloadSlot(ctx, info, logger)
 	if err != nil {
-		log.Printf("[Download] File error: %s \n", err.Error())
-		s.logDebug("download pre-check failed: %v", err)
-		return s.sendDownloadError(stream, "file not found")
+		return err
 	}
+	defer releaseSlot()
 
-	if !ok {
-		log.Printf("[Download] File %s/%s does not exist\n", fileTag, fileName)
-		s.logDebug("download rejected because source file is missing: tag=%s name=%s", fileTag, fileName)
-		return s.sendDownloadError(stream, "file does not exist")
+	chunkSize64 := fileChunksize
+	if chunkSize64 <= 0 {
+		logger.Warn("invalid chunksize", "chunksize", chunkSize64)
+		return s.sendDownloadError(stream, info, codeRejected, "invalid chunksize")
 	}
 
-	srcFilePath := utils.FileSuite.JoinPath(s.savePath, fileTag, fileName)
-	s.logDebug("download source path resolved: %s", srcFilePath)
+	var srcFilePath string
+	var srcFileSize int64
+	var srcFileHash string
+	var memFile *memoryFile
+	if s.memoryMode {
+		if versionID != "" {
+			logger.Debug("download rejected because versions are not kept in memory mode")
+			return s.sendDownloadError(stream, info, codeRejected, "versions not supported in Memory Mode")
+		}
+		f, ok := s.memStore.get(fileTag, fileName)
+		if !ok {
+			logger.Warn("file does not exist", "tag", fileTag, "name", fileName)
+			return s.sendDownloadError(stream, info, codeNotFound, "file does not exist")
+		}
+		memFile = f
+		srcFileSize = int64(len(f.data))
+		srcFileHash = f.hash
+	} else {
+		srcFilePath = utils.FileSuite.JoinPath(s.savePath, fileTag, fileName)
+		if versionID != "" {
+			versionPath, err := common.GetVersionPath(s.savePath, fileTag, fileName, versionID)
+			if err != nil {
+				logger.Debug("download rejected because version id is invalid", "err", err)
+				return s.sendDownloadError(stream, info, codeRejected, "invalid version id")
+			}
+			if !utils.FileSuite.Exists(versionPath) {
+				logger.Warn("version does not exist", "version", versionID)
+				return s.sendDownloadError(stream, info, codeNotFound, "version does not exist")
+			}
+			srcFilePath = versionPath
+		} else {
+			ok, err := common.FileIsExist(s.savePath, fileTag, fileName, "")
+			if err != nil {
+				logger.Error("download pre-check failed", "err", err)
+				return s.sendDownloadError(stream, info, codeNotFound, "file not found")
+			}
 
-	srcFileInfo, err := os.Stat(srcFilePath)
-	if err != nil {
-		log.Printf("[Download] Stat error: %s \n", err.Error())
-		s.logDebug("download stat failed: %v", err)
-		return s.sendDownloadError(stream, "file access error")
-	}
+			if !ok {
+				logger.Warn("file does not exist", "tag", fileTag, "name", fileName)
+				return s.sendDownloadError(stream, info, codeNotFound, "file does not exist")
+			}
+		}
+		logger.Debug("download source path resolved", "path", srcFilePath)
 
-	srcFileSize := srcFileInfo.Size()
-	chunkSize64 := fileChunksize
-	if chunkSize64 <= 0 {
-		log.Printf("[Download] Invalid chunksize: %d\n", chunkSize64)
-		s.logDebug("invalid download chunksize: %d", chunkSize64)
-		return s.sendDownloadError(stream, "invalid chunksize")
+		srcFileInfo, err := os.Stat(srcFilePath)
+		if err != nil {
+			logger.Error("download stat failed", "err", err)
+			return s.sendDownloadError(stream, info, codeInternal, "file access error")
+		}
+
+		srcFileSize = srcFileInfo.Size()
+
+		_, hashSpan := s.startSpan(ctx, "qback.hash", attrSize.Int64(srcFileSize))
+		srcFileHash, err = common.CalcBlake3(srcFilePath)
+		endSpan(hashSpan, err)
+		if err != nil {
+			logger.Error("download hash calculation failed", "err", err)
+			return s.sendDownloadError(stream, info, codeInternal, "hash calculation error")
+		}
 	}
+
 	totalChunks := (srcFileSize + chunkSize64 - 1) / chunkSize64
-	s.logDebug("download source metadata: size=%d total_chunks=%d", srcFileSize, totalChunks)
+	logger.Debug("download source metadata", "size", srcFileSize, "total_chunks", totalChunks)
 
-	srcFileHash, err := common.CalcBlake3(srcFilePath)
-	if err != nil {
-		log.Printf("[Download] Hash calculation error: %s \n", err.Error())
-		s.logDebug("download hash calculation failed: %v", err)
-		return s.sendDownloadError(stream, "hash calculation error")
-	}
+	info.Size = srcFileSize
+	info.Hash = srcFileHash
 
 	// 1. send metadata
 	fileMetadata := &transferv1.FileMetadata{}
@@ -475,25 +1324,31 @@ func (s *FileService) DownloadFile(in *transferv1.DownloadFileRequest, stream tr
 	downloadRes.SetMetadata(fileMetadata)
 
 	if err := stream.Send(downloadRes); err != nil {
-		log.Printf("[Download] Send metadata error: %s \n", err.Error())
-		s.logDebug("failed to send download metadata: %v", err)
+		logger.Error("failed to send download metadata", "err", err)
 		return fmt.Errorf("failed to send metadata")
 	}
 
-	log.Printf("[Download] Metadata sent: size=%d, chunks=%d x %d Byte, hash=%s\n",
-		srcFileSize, totalChunks, chunkSize64, srcFileHash)
+	logger.Info("download metadata sent", "size", srcFileSize, "chunks", totalChunks, "chunksize", chunkSize64, "hash", srcFileHash)
 
-	file, err := common.OpenTargetFile(srcFilePath, common.FileRead)
-	if err != nil {
-		log.Printf("[Download] Open error: %s \n", err.Error())
-		s.logDebug("failed to open download source file: %v", err)
-		return s.sendDownloadError(stream, "file open error")
+	var bufReader io.Reader
+	if memFile != nil {
+		bufReader = bytes.NewReader(memFile.data)
+	} else {
+		file, err := common.OpenTargetFile(srcFilePath, common.FileRead)
+		if err != nil {
+			logger.Error("failed to open download source file", "err", err)
+			return s.sendDownloadError(stream, info, codeInternal, "file open error")
+		}
+		defer file.Close()
+		bufReader = bufio.NewReaderSize(file, 64*1024)
 	}
-	defer file.Close()
 
-	bufReader := bufio.NewReaderSize(file, 64*1024)
+	active := s.transfers.add(ctx, "download", fileTag, fileName, info.Size)
+	defer s.transfers.remove(active)
+	limit, releaseLimit := s.throttle(ctx)
+	defer releaseLimit()
 
-	log.Println("[Download] Start sending data")
+	logger.Info("start sending data")
 	var sentChunks int64 = 0
 	var totalSent int64 = 0
 	startTime := time.Now()
@@ -501,20 +1356,30 @@ func (s *FileService) DownloadFile(in *transferv1.DownloadFileRequest, stream tr
 	buffer := make([]byte, chunkSize)
 
 	// 2. send file data
+	readPhase := s.startPhase(ctx, "qback.download.read")
+	defer readPhase.end(nil)
 	for {
+		chunkStart := time.Now()
 		n, err := bufReader.Read(buffer)
 		if err != nil && err != io.EOF {
-			log.Printf("[Download] Read error: %s \n", err.Error())
-			s.logDebug("read download source failed: %v", err)
-			return s.sendDownloadError(stream, "file read error")
+			readPhase.end(err)
+			logger.Error("read download source failed", "err", err)
+			return s.sendDownloadError(stream, info, codeInternal, "file read error")
 		}
 
 		if n == 0 {
 			break
 		}
+		readPhase.add(n, chunkStart)
+
+		if err := limit.wait(ctx, n); err != nil {
+			logger.Error("download rate limit wait failed", "err", err)
+			return s.sendDownloadError(stream, info, codeInternal, "file send error")
+		}
 
 		sentChunks++
 		totalSent += int64(n)
+		active.advance(int64(n))
 
 		chunk := &transferv1.ChunkData{}
 		chunk.SetChunk(sentChunks)
@@ -523,32 +1388,34 @@ func (s *FileService) DownloadFile(in *transferv1.DownloadFileRequest, stream tr
 		downloadRes := &transferv1.DownloadFileResponse{}
 		downloadRes.SetChunk(chunk)
 		if s.shouldLogChunk(sentChunks, totalChunks) {
-			s.logDebug("sending download chunk=%d/%d bytes=%d", sentChunks, totalChunks, n)
+			logger.Debug("sending download chunk", "chunk", sentChunks, "total_chunks", totalChunks, "bytes", n)
 		}
 
 		if err := stream.Send(downloadRes); err != nil {
-			log.Printf("[Download] Send error: %s \n", err.Error())
-			s.logDebug("send download chunk failed: chunk=%d err=%v", sentChunks, err)
-			return s.sendDownloadError(stream, "file send error")
+			logger.Error("send download chunk failed", "err", err, "chunk", sentChunks)
+			return s.sendDownloadError(stream, info, codeInternal, "file send error")
 		}
-
-		common.ShowProgress(sentChunks, totalChunks)
+		s.metrics.sent(int64(n))
+		s.metrics.chunk("download", chunkStart)
 	}
+	readPhase.end(nil)
 
 	elapsed := time.Since(startTime)
 	speed := float64(totalSent) / elapsed.Seconds()
 	speedStr := common.FormatSpeed(speed)
 
-	log.Printf("[Download] Complete: %s, elapsed=%d, sent=%d bytes, speed=%s\n", fileName, elapsed.Milliseconds(), totalSent, speedStr)
-	s.logDebug("download completed successfully: name=%s elapsed_ms=%d sent=%d", fileName, elapsed.Milliseconds(), totalSent)
+	logger.Info("download complete", "elapsed_ms", elapsed.Milliseconds(), "sent", totalSent, "speed", speedStr)
+
+	info.Duration = elapsed
+	s.downloaded(stream.Context(), info)
 
 	// 3. send result
-	return s.sendDownloadSuccess(stream, "download complete")
+	return s.sendDownloadSuccess(stream, info, "download complete")
 }
 
 func (s *FileService) ListFiles(ctx context.Context, in *transferv1.ListFilesRequest) (*transferv1.ListFilesResponse, error) {
-	if s.memoryMode {
-		s.logDebug("list files rejected because memory mode is enabled")
+	if s.memoryMode && s.memStore == nil {
+		s.logger.Debug("list files rejected because memory mode is enabled")
 		listRes := &transferv1.ListFilesResponse{}
 		listRes.SetStatus(false)
 		listRes.SetMessage("ListFiles not supported in Memory Mode")
@@ -556,18 +1423,46 @@ func (s *FileService) ListFiles(ctx context.Context, in *transferv1.ListFilesReq
 	}
 
 	tag := in.GetTag()
-	s.logDebug("listing files for tag=%s", tag)
-	files, err := common.GetFileList(s.savePath, tag)
+	s.logger.Debug("listing files", "tag", tag)
+	if !common.IsValidName(tag) {
+		listRes := &transferv1.ListFilesResponse{}
+		listRes.SetStatus(false)
+		listRes.SetMessage("Invalid tag")
+		return listRes, nil
+	}
+	var files []*transferv1.ListFileItem
+	var err error
+	if s.memoryMode {
+		for _, file := range s.memStore.list(tag) {
+			files = append(files, file.listItem())
+		}
+	} else {
+		files, err = common.GetFileList(s.savePath, tag)
+	}
 	if err != nil {
-		s.logDebug("list files failed: %v", err)
+		s.logger.Debug("list files failed", "err", err)
 		listRes := &transferv1.ListFilesResponse{}
 		listRes.SetStatus(false)
 		listRes.SetMessage("Get file list error: " + err.Error())
 		return listRes, nil
 	}
 
-	log.Printf("Found %d files under tag %s\n", len(files), tag)
-	s.logDebug("list files succeeded: tag=%s count=%d", tag, len(files))
+	// 内存存储不保留历史版本
+	if in.GetVersions() && !s.memoryMode {
+		for _, file := range files {
+			versions, err := common.GetFileVersions(s.savePath, tag, file.GetName())
+			if err != nil {
+				s.logger.Debug("list file versions failed", "err", err)
+				listRes := &transferv1.ListFilesResponse{}
+				listRes.SetStatus(false)
+				listRes.SetMessage("Get file versions error: " + err.Error())
+				return listRes, nil
+			}
+			file.SetVersions(versions)
+		}
+	}
+
+	s.logger.Info("listed files", "tag", tag, "count", len(files), "versions", in.GetVersions())
 
 	listRes := &transferv1.ListFilesResponse{}
 	listRes.SetStatus(true)
@@ -575,3 +1470,172 @@ func (s *FileService) ListFiles(ctx context.Context, in *transferv1.ListFilesReq
 	listRes.SetFiles(files)
 	return listRes, nil
 }
+
+func (s *FileService) StorageStats(ctx context.Context, in *transferv1.StorageStatsRequest) (*transferv1.StorageStatsResponse, error) {
+	statsRes := &transferv1.StorageStatsResponse{}
+
+	if s.memoryMode {
+		s.logger.Debug("storage stats rejected because memory mode is enabled")
+		statsRes.SetStatus(false)
+		statsRes.SetMessage("StorageStats not supported in Memory Mode")
+		return statsRes, nil
+	}
+
+	diskTotal, diskFree, err := common.DiskUsage(s.savePath)
+	if err != nil {
+		s.logger.Debug("storage stats disk usage failed", "err", err)
+		statsRes.SetStatus(false)
+		statsRes.SetMessage("Get disk usage error: " + err.Error())
+		return statsRes, nil
+	}
+
+	var tags []string
+	if tag := in.GetTag(); tag != "" {
+		if !common.IsValidName(tag) {
+			statsRes.SetStatus(false)
+			statsRes.SetMessage("Invalid tag")
+			return statsRes, nil
+		}
+		tags = []string{tag}
+	} else {
+		tags, err = common.GetTagList(s.savePath)
+		if err != nil {
+			s.logger.Debug("storage stats tag list failed", "err", err)
+			statsRes.SetStatus(false)
+			statsRes.SetMessage("Get tag list error: " + err.Error())
+			return statsRes, nil
+		}
+		for tag := range s.quotas {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		slices.Sort(tags)
+	}
+
+	var usages []*transferv1.TagUsage
+	for _, tag := range tags {
+		used, files, err := common.GetTagUsage(s.savePath, tag)
+		if err != nil {
+			s.logger.Debug("storage stats tag usage failed", "tag", tag, "err", err)
+			statsRes.SetStatus(false)
+			statsRes.SetMessage("Get tag usage error: " + err.Error())
+			return statsRes, nil
+		}
+
+		usage := &transferv1.TagUsage{}
+		usage.SetTag(tag)
+		usage.SetUsed(used)
+		usage.SetFiles(files)
+		usage.SetQuota(s.quotas[tag])
+		usages = append(usages, usage)
+	}
+
+	s.logger.Debug("storage stats succeeded", "disk_total", diskTotal, "disk_free", diskFree, "tags", len(usages))
+
+	statsRes.SetStatus(true)
+	statsRes.SetMessage("Storage stats retrieved successfully")
+	statsRes.SetDiskTotal(diskTotal)
+	statsRes.SetDiskFree(diskFree)
+	statsRes.SetTags(usages)
+	return statsRes, nil
+}
+
+func (s *FileService) DeleteFile(ctx context.Context, in *transferv1.DeleteFileRequest) (*transferv1.DeleteFileResponse, error) {
+	deleteRes := &transferv1.DeleteFileResponse{}
+
+	if s.memoryMode && s.memStore == nil {
+		s.logger.Debug("delete file rejected because memory mode is enabled")
+		deleteRes.SetStatus(false)
+		deleteRes.SetMessage("DeleteFile not supported in Memory Mode")
+		deleteRes.SetErrorCode(codeRejected)
+		return deleteRes, nil
+	}
+
+	fileTag := in.GetTag()
+	fileName := in.GetName()
+	s.logger.Debug("delete request received", "tag", fileTag, "name", fileName)
+
+	if !common.IsValidName(fileTag) || !common.IsValidName(fileName) {
+		deleteRes.SetStatus(false)
+		deleteRes.SetMessage("Invalid tag or name")
+		deleteRes.SetErrorCode(codeRejected)
+		return deleteRes, nil
+	}
+
+	if s.memoryMode {
+		if !s.memStore.remove(fileTag, fileName) {
+			s.logger.Debug("delete rejected because file is missing", "tag", fileTag, "name", fileName)
+			deleteRes.SetStatus(false)
+			deleteRes.SetMessage("File does not exist")
+			deleteRes.SetErrorCode(codeNotFound)
+			return deleteRes, nil
+		}
+		s.logger.Info("file deleted", "tag", fileTag, "name", fileName, "memory_mode", true)
+		deleteRes.SetStatus(true)
+		deleteRes.SetMessage("File deleted")
+		return deleteRes, nil
+	}
+
+	ok, err := common.FileIsExist(s.savePath, fileTag, fileName, "")
+	if err != nil || !ok {
+		s.logger.Debug("delete rejected because file is missing", "tag", fileTag, "name", fileName, "err", err)
+		deleteRes.SetStatus(false)
+		deleteRes.SetMessage("File does not exist")
+		deleteRes.SetErrorCode(codeNotFound)
+		return deleteRes, nil
+	}
+
+	targetFilePath := utils.FileSuite.JoinPath(s.savePath, fileTag, fileName)
+	err = common.RemoveFileVersions(s.savePath, fileTag, fileName)
+	if err == nil {
+		if s.blobs != nil {
+			err = s.blobs.Remove(targetFilePath)
+		} else {
+			err = os.Remove(targetFilePath)
+		}
+	}
+	if err != nil {
+		s.logger.Error("delete failed", "err", err)
+		deleteRes.SetStatus(false)
+		deleteRes.SetMessage("Delete file error: " + err.Error())
+		deleteRes.SetErrorCode(codeInternal)
+		return deleteRes, nil
+	}
+
+	s.logger.Info("file deleted", "tag", fileTag, "name", fileName)
+	s.metrics.storageChanged()
+
+	deleteRes.SetStatus(true)
+	deleteRes.SetMessage("File deleted")
+	return deleteRes, nil
+}
+
+func (s *FileService) QueryChunks(ctx context.Context, in *transferv1.QueryChunksRequest) (*transferv1.QueryChunksResponse, error) {
+	queryRes := &transferv1.QueryChunksResponse{}
+
+	if s.memoryMode {
+		s.logger.Debug("query chunks rejected because memory mode is enabled")
+		queryRes.SetStatus(false)
+		queryRes.SetMessage("QueryChunks not supported in Memory Mode")
+		return queryRes, nil
+	}
+
+	seen := make(map[string]bool)
+	var missing []string
+	for _, hash := range in.GetHashes() {
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+		if !s.chunks.Has(hash) {
+			missing = append(missing, hash)
+		}
+	}
+	s.logger.Debug("query chunks finished", "requested", len(in.GetHashes()), "unique", len(seen), "missing", len(missing))
+
+	queryRes.SetStatus(true)
+	queryRes.SetMessage("Chunks queried successfully")
+	queryRes.SetMissing(missing)
+	return queryRes, nil
+}
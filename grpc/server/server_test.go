@@ -0,0 +1,58 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckStorage(t *testing.T) {
+	savePath := t.TempDir()
+	s, err := NewFileService(WithSavePath(savePath), WithQuotas(map[string]int64{"limited": 100}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(savePath, "limited"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(savePath, "limited", "a.bin"), make([]byte, 60), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.checkStorage("limited", "b.bin", 40); err != nil {
+		t.Fatalf("expected quota to fit: %v", err)
+	}
+	if _, err := s.checkStorage("limited", "b.bin", 41); err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Fatalf("expected quota error, got %v", err)
+	}
+	// 替换同名文件时扣除其大小
+	if _, err := s.checkStorage("limited", "a.bin", 100); err != nil {
+		t.Fatalf("expected replaced size to be deducted: %v", err)
+	}
+	if _, err := s.checkStorage("other", "a.bin", 1<<20); err != nil {
+		t.Fatalf("tag without quota rejected: %v", err)
+	}
+	if _, err := s.checkStorage("other", "a.bin", 1<<62); err == nil || !strings.Contains(err.Error(), "insufficient disk space") {
+		t.Fatalf("expected disk space error, got %v", err)
+	}
+}
+
+func TestStorageBudgetCountsChunkCopies(t *testing.T) {
+	s, err := NewFileService(WithSavePath(t.TempDir()), WithQuotas(map[string]int64{"cdc": 100}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	budget, err := s.checkStorage("cdc", "a.bin", 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 重建的文件加上分片存储中的副本
+	if err := budget.check(60 + 40); err != nil {
+		t.Fatalf("expected budget to fit: %v", err)
+	}
+	if err := budget.check(60 + 41); err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Fatalf("expected chunk copies to count against quota, got %v", err)
+	}
+}
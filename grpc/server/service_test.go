@@ -0,0 +1,551 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"qback/grpc/common"
+	"qback/grpc/server"
+	transferv1 "qback/internal/pb/qmeta/transfer/v1"
+	"qback/internal/servertest"
+	"qback/pkg/qback"
+
+	"github.com/prometheus/client_golang/prometheus"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+)
+
+func TestEmbeddedService(t *testing.T) {
+	var mu sync.Mutex
+	var completed []server.UploadInfo
+	var rejected []server.RejectInfo
+
+	address, savePath := servertest.Start(t,
+		server.WithDedup(),
+		server.WithHooks(server.Hooks{
+			OnUploadStart: func(ctx context.Context, info server.UploadInfo) error {
+				if strings.HasSuffix(info.Name, ".tmp") {
+					return errors.New("temporary files are not accepted")
+				}
+				return nil
+			},
+			OnUploadComplete: func(ctx context.Context, info server.UploadInfo) {
+				mu.Lock()
+				defer mu.Unlock()
+				completed = append(completed, info)
+			},
+			OnReject: func(ctx context.Context, info server.RejectInfo) {
+				mu.Lock()
+				defer mu.Unlock()
+				rejected = append(rejected, info)
+			},
+		}),
+	)
+	sdk := servertest.Dial(t, address)
+	ctx := t.Context()
+
+	result, err := sdk.Upload(ctx, "embed", "hello.txt", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sdk.Upload(ctx, "embed", "scratch.tmp", strings.NewReader("scratch"), qback.WithTransferID("scratch-1")); !errors.Is(err, qback.ErrRejected) {
+		t.Fatalf("expected rejection, got %v", err)
+	}
+
+	// 内容已存在时走去重捷径，回调仍然先于链接执行
+	if _, err := sdk.UploadFile(ctx, "embed", "copy.tmp", writeTemp(t, "hello")); !errors.Is(err, qback.ErrRejected) {
+		t.Fatalf("expected rejection of deduplicated upload, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(savePath, "embed", "copy.tmp")); !os.IsNotExist(err) {
+		t.Fatalf("rejected upload should not be linked, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(completed) != 1 || completed[0].Name != "hello.txt" || completed[0].Size != 5 || completed[0].Hash != result.Hash || completed[0].TransferID != result.TransferID {
+		t.Fatalf("unexpected upload events: %+v", completed)
+	}
+	if len(rejected) != 2 || rejected[0].Name != "scratch.tmp" || rejected[0].Op != "upload" || rejected[0].TransferID != "scratch-1" || rejected[1].Name != "copy.tmp" {
+		t.Fatalf("unexpected reject events: %+v", rejected)
+	}
+}
+
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer tp.Shutdown(context.Background())
+
+	fileService, err := server.NewFileService(
+		server.WithSavePath(t.TempDir()),
+		server.WithTracerProvider(tp),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address := servertest.Listen(t, fileService, grpc.StatsHandler(server.NewStatsHandler(tp)))
+	sdk := servertest.Dial(t, address, qback.WithTracerProvider(tp), qback.WithChunkSize(1024))
+	ctx := t.Context()
+
+	path := filepath.Join(t.TempDir(), "data.bin")
+	if err := os.WriteFile(path, bytes.Repeat([]byte("qback"), 1000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sdk.UploadFile(ctx, "trace", "data.bin", path); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if _, err := sdk.Download(ctx, "trace", "data.bin", &out); err != nil {
+		t.Fatal(err)
+	}
+
+	// 服务端的步骤链路应与客户端的请求在同一条链路中
+	traces := map[string]string{}
+	for _, span := range exporter.GetSpans() {
+		traces[span.Name] = span.SpanContext.TraceID().String()
+	}
+	for _, want := range []struct{ rpc, step string }{
+		{"qmeta.transfer.v1.FileTransferService/UploadFile", "qback.upload.write"},
+		{"qmeta.transfer.v1.FileTransferService/UploadFile", "qback.upload.flush"},
+		{"qmeta.transfer.v1.FileTransferService/UploadFile", "qback.upload.sync"},
+		{"qmeta.transfer.v1.FileTransferService/UploadFile", "qback.upload.validate"},
+		{"qmeta.transfer.v1.FileTransferService/DownloadFile", "qback.hash"},
+		{"qmeta.transfer.v1.FileTransferService/DownloadFile", "qback.download.read"},
+	} {
+		rpcTrace, ok := traces[want.rpc]
+		if !ok {
+			t.Fatalf("missing rpc span %s, got %v", want.rpc, traces)
+		}
+		if traces[want.step] != rpcTrace {
+			t.Fatalf("span %s not in trace of %s, got %v", want.step, want.rpc, traces)
+		}
+	}
+	// 每个阶段只有一个链路，分片的字节数累计在属性中
+	for _, name := range []string{"qback.upload.write", "qback.download.read"} {
+		var found []sdktrace.ReadOnlySpan
+		for _, span := range exporter.GetSpans().Snapshots() {
+			if span.Name() == name {
+				found = append(found, span)
+			}
+		}
+		if len(found) != 1 {
+			t.Fatalf("expected one %s span, got %d", name, len(found))
+		}
+		attrs := map[string]int64{}
+		for _, attr := range found[0].Attributes() {
+			attrs[string(attr.Key)] = attr.Value.AsInt64()
+		}
+		if attrs["qback.bytes"] != 5000 || attrs["qback.chunks"] != 5 {
+			t.Fatalf("unexpected %s attributes: %v", name, attrs)
+		}
+	}
+}
+
+func TestDedupUpload(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	address, savePath := servertest.Start(t,
+		server.WithDedup(),
+		server.WithVersioning(5, 0),
+		server.WithMetrics(server.NewMetrics(registry)),
+	)
+	sdk := servertest.Dial(t, address)
+	ctx := t.Context()
+
+	if _, err := sdk.Upload(ctx, "docs", "a.txt", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sdk.Upload(ctx, "docs", "b.txt", strings.NewReader("other")); err != nil {
+		t.Fatal(err)
+	}
+
+	// 内容已存在时直接链接，覆盖前归档旧文件，并记为成功的上传
+	if _, err := sdk.UploadFile(ctx, "docs", "b.txt", writeTemp(t, "hello"), qback.WithConflict(qback.ConflictOverwrite)); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(savePath, "docs", "b.txt")); string(data) != "hello" {
+		t.Fatalf("unexpected content %q", data)
+	}
+	if success, skipped := uploads(t, registry, "success"), uploads(t, registry, "skipped"); success != 3 || skipped != 0 {
+		t.Fatalf("expected 3 successful uploads, got success=%v skipped=%v", success, skipped)
+	}
+
+	files, err := sdk.List(ctx, "docs", qback.WithVersions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	for _, file := range files {
+		if file.Name != "b.txt" {
+			continue
+		}
+		if len(file.Versions) != 1 {
+			t.Fatalf("expected one archived version, got %+v", file.Versions)
+		}
+		if _, err := sdk.Download(ctx, "docs", "b.txt", &out, qback.WithVersion(file.Versions[0].ID)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if out.String() != "other" {
+		t.Fatalf("expected archived content, got %q", out.String())
+	}
+}
+
+func TestUploadPathTraversal(t *testing.T) {
+	address, _ := servertest.Start(t, server.WithDedup())
+	sdk := servertest.Dial(t, address)
+	ctx := t.Context()
+
+	result, err := sdk.Upload(ctx, "safe", "hello.txt", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 尝试覆盖所有硬链接共用的 blob
+	for _, target := range []struct{ tag, name string }{
+		{"safe", "../.blobs/" + result.Hash},
+		{"x/../.blobs", result.Hash},
+		{".blobs", result.Hash},
+		{"safe", ".versions"},
+		{"..", "hello.txt"},
+	} {
+		if _, err := sdk.Upload(ctx, target.tag, target.name, strings.NewReader("evil")); !errors.Is(err, qback.ErrRejected) {
+			t.Fatalf("upload %s/%s: expected rejection, got %v", target.tag, target.name, err)
+		}
+	}
+	if _, err := sdk.Download(ctx, "safe", "../safe/hello.txt", &bytes.Buffer{}); err == nil {
+		t.Fatal("expected traversal download to fail")
+	}
+	if _, err := sdk.List(ctx, "../safe"); err == nil {
+		t.Fatal("expected traversal list to fail")
+	}
+
+	var out bytes.Buffer
+	if _, err := sdk.Download(ctx, "safe", "hello.txt", &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "hello" {
+		t.Fatalf("stored content changed: %q", out.String())
+	}
+}
+
+// uploads 返回按结果统计的上传次数
+func uploads(t *testing.T, registry *prometheus.Registry, outcome string) float64 {
+	t.Helper()
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "qback_uploads_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			if metric.GetLabel()[0].GetValue() == outcome {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestUploadConflict(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	address, savePath := servertest.Start(t,
+		server.WithMetrics(server.NewMetrics(registry)),
+		server.WithHooks(server.Hooks{
+			OnUploadStart: func(ctx context.Context, info server.UploadInfo) error {
+				if info.Name == "a_2.txt" {
+					return errors.New("name not accepted")
+				}
+				return nil
+			},
+		}),
+	)
+	sdk := servertest.Dial(t, address)
+	ctx := t.Context()
+
+	if _, err := sdk.Upload(ctx, "conflict", "a.txt", strings.NewReader("first")); err != nil {
+		t.Fatal(err)
+	}
+	// 默认策略：相同内容报错
+	if _, err := sdk.UploadFile(ctx, "conflict", "a.txt", writeTemp(t, "first")); !errors.Is(err, qback.ErrExists) {
+		t.Fatalf("expected exists error, got %v", err)
+	}
+
+	// 流式上传在结尾才知道哈希，内容相同时结果仍为跳过
+	result, err := sdk.Upload(ctx, "conflict", "a.txt", strings.NewReader("first"), qback.WithConflict(qback.ConflictSkipIfIdentical))
+	if err != nil || !result.Skipped || result.Outcome != qback.OutcomeSkipped {
+		t.Fatalf("expected skipped streaming upload, got %+v, %v", result, err)
+	}
+	if n := uploads(t, registry, "skipped"); n != 1 {
+		t.Fatalf("expected one skipped upload recorded, got %v", n)
+	}
+
+	result, err = sdk.Upload(ctx, "conflict", "a.txt", strings.NewReader("second"), qback.WithConflict(qback.ConflictRename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Name != "a_1.txt" || result.Outcome != qback.OutcomeRenamed {
+		t.Fatalf("unexpected rename result: %+v", result)
+	}
+
+	// 被拒绝的上传不应留下占位文件
+	if _, err := sdk.Upload(ctx, "conflict", "a.txt", strings.NewReader("third"), qback.WithConflict(qback.ConflictRename)); !errors.Is(err, qback.ErrRejected) {
+		t.Fatalf("expected rejection, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(savePath, "conflict", "a_2.txt")); !os.IsNotExist(err) {
+		t.Fatalf("placeholder left behind: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(savePath, "conflict", "a_1.txt"))
+	if err != nil || string(data) != "second" {
+		t.Fatalf("unexpected renamed content %q, err=%v", data, err)
+	}
+}
+
+func writeTemp(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestStreamingUploadQuota(t *testing.T) {
+	address, savePath := servertest.Start(t, server.WithQuotas(map[string]int64{"limited": 1 << 20}))
+	sdk := servertest.Dial(t, address, qback.WithChunkSize(256*1024))
+	ctx := t.Context()
+
+	// 流式上传在接收超过配额时中止，不等整个文件写入磁盘
+	input := &countingReader{r: io.LimitReader(neverEnding('q'), 64<<20)}
+	_, err := sdk.Upload(ctx, "limited", "big.bin", input)
+	if err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Fatalf("expected quota error, got %v", err)
+	}
+	if input.n >= 64<<20 {
+		t.Fatal("upload was not aborted before the end of the stream")
+	}
+	entries, err := os.ReadDir(filepath.Join(savePath, common.TempDirName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("temp file left behind: %v", entries)
+	}
+
+	if _, err := sdk.Upload(ctx, "limited", "small.bin", io.LimitReader(neverEnding('q'), 512*1024)); err != nil {
+		t.Fatalf("upload within quota failed: %v", err)
+	}
+}
+
+// rawUpload 发送元数据和后续请求，返回服务端的最终结果
+func rawUpload(t *testing.T, rpc transferv1.FileTransferServiceClient, meta *transferv1.FileMetadata, reqs ...*transferv1.UploadFileRequest) *transferv1.TransferResult {
+	t.Helper()
+	stream, err := rpc.UploadFile(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &transferv1.UploadFileRequest{}
+	req.SetMetadata(meta)
+	if err := stream.Send(req); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ack := resp.GetMetaAck(); ack == nil || !ack.GetAllowUpload() {
+		t.Fatalf("upload not allowed: %v", resp)
+	}
+
+	// 服务端拒绝后关闭流，剩余的请求发送失败
+	for _, req := range reqs {
+		if err := stream.Send(req); err != nil {
+			break
+		}
+	}
+	stream.CloseSend()
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result := resp.GetResult(); result != nil {
+			return result
+		}
+	}
+}
+
+func chunkRequest(chunk int64, data []byte) *transferv1.UploadFileRequest {
+	req := &transferv1.UploadFileRequest{}
+	c := &transferv1.ChunkData{}
+	c.SetChunk(chunk)
+	c.SetData(data)
+	req.SetChunk(c)
+	return req
+}
+
+func TestUploadExceedsDeclaredSize(t *testing.T) {
+	address, savePath := servertest.Start(t)
+	rpc := servertest.RPC(t, address)
+
+	data := bytes.Repeat([]byte("q"), 64)
+	meta := &transferv1.FileMetadata{}
+	meta.SetTag("docs")
+	meta.SetName("a.bin")
+	meta.SetSize(int64(len(data)))
+	meta.SetChunks(1)
+	meta.SetChunksize(int64(len(data)))
+	result := rawUpload(t, rpc, meta, chunkRequest(0, data), chunkRequest(1, data), chunkRequest(2, data))
+	if result.GetStatus() || result.GetErrorCode() != transferv1.ErrorCode_ERROR_CODE_REJECTED || !strings.Contains(result.GetMessage(), "exceeds declared size") {
+		t.Fatalf("expected rejection, got %v", result)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(savePath, common.TempDirName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("temp file left behind: %v", entries)
+	}
+	if _, err := os.Stat(filepath.Join(savePath, "docs", "a.bin")); !os.IsNotExist(err) {
+		t.Fatalf("oversized upload should not be saved, got %v", err)
+	}
+}
+
+func TestDeltaUploadExceedsDeclaredSize(t *testing.T) {
+	address, savePath := servertest.Start(t)
+	sdk := servertest.Dial(t, address)
+	rpc := servertest.RPC(t, address)
+
+	data := bytes.Repeat([]byte("qback"), 20000)
+	if _, err := sdk.Upload(t.Context(), "docs", "a.bin", bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 反复复制同一块，只声明很小的大小
+	delta := &transferv1.DeltaData{}
+	for range 64 {
+		op := &transferv1.DeltaOp{}
+		op.SetBlock(0)
+		op.SetCount(1)
+		delta.SetOps(append(delta.GetOps(), op))
+	}
+	req := &transferv1.UploadFileRequest{}
+	req.SetDelta(delta)
+
+	meta := &transferv1.FileMetadata{}
+	meta.SetTag("docs")
+	meta.SetName("a.bin")
+	meta.SetSize(100)
+	meta.SetChunks(1)
+	meta.SetChunksize(100)
+	meta.SetDelta(true)
+	meta.SetConflict(transferv1.ConflictPolicy_CONFLICT_POLICY_OVERWRITE)
+	result := rawUpload(t, rpc, meta, req)
+	if result.GetStatus() || result.GetErrorCode() != transferv1.ErrorCode_ERROR_CODE_REJECTED || !strings.Contains(result.GetMessage(), "exceeds declared size") {
+		t.Fatalf("expected rejection, got %v", result)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(savePath, common.TempDirName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("temp file left behind: %v", entries)
+	}
+	if saved, _ := os.ReadFile(filepath.Join(savePath, "docs", "a.bin")); !bytes.Equal(saved, data) {
+		t.Fatal("existing file changed")
+	}
+}
+
+// neverEnding 无限重复同一字节的 Reader
+type neverEnding byte
+
+func (b neverEnding) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(b)
+	}
+	return len(p), nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func TestStorageMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	address, savePath := servertest.Start(t, server.WithMetrics(server.NewMetrics(registry)))
+	sdk := servertest.Dial(t, address)
+	ctx := t.Context()
+
+	// scrape 返回标签 docs 的文件数和已用空间
+	scrape := func() (files, used float64) {
+		t.Helper()
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, family := range families {
+			for _, metric := range family.GetMetric() {
+				if len(metric.GetLabel()) != 1 || metric.GetLabel()[0].GetValue() != "docs" {
+					continue
+				}
+				switch family.GetName() {
+				case "qback_tag_files":
+					files = metric.GetGauge().GetValue()
+				case "qback_tag_used_bytes":
+					used = metric.GetGauge().GetValue()
+				}
+			}
+		}
+		return files, used
+	}
+
+	if files, _ := scrape(); files != 0 {
+		t.Fatalf("expected no files before upload, got %v", files)
+	}
+	if _, err := sdk.Upload(ctx, "docs", "a.txt", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if files, used := scrape(); files != 1 || used != 5 {
+		t.Fatalf("after upload: files=%v used=%v", files, used)
+	}
+
+	// 统计结果被缓存，绕过服务端写入的文件在下次上传前不会被统计
+	if err := os.WriteFile(filepath.Join(savePath, "docs", "outside.txt"), []byte("outside"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := scrape(); files != 1 {
+		t.Fatalf("expected cached usage, got %v files", files)
+	}
+
+	if _, err := sdk.Upload(ctx, "docs", "b.txt", strings.NewReader("world")); err != nil {
+		t.Fatal(err)
+	}
+	if files, used := scrape(); files != 3 || used != 17 {
+		t.Fatalf("after second upload: files=%v used=%v", files, used)
+	}
+	if err := sdk.Delete(ctx, "docs", "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if files, used := scrape(); files != 2 || used != 12 {
+		t.Fatalf("after delete: files=%v used=%v", files, used)
+	}
+}
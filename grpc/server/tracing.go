@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/stats"
+)
+
+const tracerName = "qback/grpc/server"
+
+// 链路追踪属性
+var (
+	attrTag        = attribute.Key("qback.tag")
+	attrName       = attribute.Key("qback.name")
+	attrSize       = attribute.Key("qback.size")
+	attrChunks     = attribute.Key("qback.chunks")
+	attrBytes      = attribute.Key("qback.bytes")
+	attrBusy       = attribute.Key("qback.busy_ms")
+	attrTransferID = attribute.Key("qback.transfer_id")
+)
+
+// NewStatsHandler 创建记录 gRPC 请求链路的 stats.Handler，通过 grpc.StatsHandler 接入
+// 客户端通过 W3C trace context 传递的链路会作为父链路
+func NewStatsHandler(tp trace.TracerProvider) stats.Handler {
+	return otelgrpc.NewServerHandler(
+		otelgrpc.WithTracerProvider(tp),
+		otelgrpc.WithPropagators(propagation.TraceContext{}),
+	)
+}
+
+// startSpan 在请求的链路下创建子链路，未设置 TracerProvider 时不记录
+func (s *FileService) startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return s.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan 结束链路，err 不为空时标记为失败
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// phaseSpan 传输中的一个阶段，所有分片合并为一个链路，只累计分片数、字节数和处理耗时
+// 链路时长包含等待网络的时间，busy 只计算处理分片本身的耗时
+type phaseSpan struct {
+	span   trace.Span
+	chunks int64
+	bytes  int64
+	busy   time.Duration
+	ended  bool
+}
+
+func (s *FileService) startPhase(ctx context.Context, name string) *phaseSpan {
+	_, span := s.startSpan(ctx, name)
+	return &phaseSpan{span: span}
+}
+
+// add 累计一个从 start 开始处理的分片
+func (p *phaseSpan) add(n int, start time.Time) {
+	p.chunks++
+	p.bytes += int64(n)
+	p.busy += time.Since(start)
+}
+
+// end 写入累计的属性并结束链路，重复调用时不做任何事
+func (p *phaseSpan) end(err error) {
+	if p.ended {
+		return
+	}
+	p.ended = true
+	p.span.SetAttributes(attrChunks.Int64(p.chunks), attrBytes.Int64(p.bytes), attrBusy.Int64(p.busy.Milliseconds()))
+	endSpan(p.span, err)
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+
+	"qback/grpc/common"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type transferIDKey struct{}
+
+// TransferID 返回请求的传输 ID
+// 经过 TransferIDInterceptor 的请求总有 ID，否则取客户端请求头中的值
+func TransferID(ctx context.Context) string {
+	if id, ok := ctx.Value(transferIDKey{}).(string); ok {
+		return id
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(common.TransferIDHeader); len(ids) > 0 && common.ValidTransferID(ids[0]) {
+			return ids[0]
+		}
+	}
+	return ""
+}
+
+// withTransferID 读取客户端提供的传输 ID，没有或无效时生成新的，并在响应头中返回
+func withTransferID(ctx context.Context) context.Context {
+	id := TransferID(ctx)
+	if id == "" {
+		id = common.NewTransferID()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(common.TransferIDHeader, id))
+	return context.WithValue(ctx, transferIDKey{}, id)
+}
+
+// transferStream 替换流的 context
+type transferStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *transferStream) Context() context.Context {
+	return s.ctx
+}
+
+// UnaryTransferIDInterceptor 为每个请求设置传输 ID，嵌入使用时应放在其他拦截器之前
+func UnaryTransferIDInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(withTransferID(ctx), req)
+}
+
+// StreamTransferIDInterceptor 为每个流设置传输 ID，嵌入使用时应放在其他拦截器之前
+func StreamTransferIDInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &transferStream{ServerStream: ss, ctx: withTransferID(ss.Context())})
+}
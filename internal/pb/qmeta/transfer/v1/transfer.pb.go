@@ -20,6 +20,166 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// ConflictPolicy 上传时同名文件已存在的处理策略
+// UNSPECIFIED 保持旧行为：相同内容报错，不同内容覆盖
+type ConflictPolicy int32
+
+const (
+	ConflictPolicy_CONFLICT_POLICY_UNSPECIFIED        ConflictPolicy = 0
+	ConflictPolicy_CONFLICT_POLICY_FAIL               ConflictPolicy = 1
+	ConflictPolicy_CONFLICT_POLICY_SKIP_IF_IDENTICAL  ConflictPolicy = 2
+	ConflictPolicy_CONFLICT_POLICY_OVERWRITE          ConflictPolicy = 3
+	ConflictPolicy_CONFLICT_POLICY_RENAME_WITH_SUFFIX ConflictPolicy = 4
+)
+
+// Enum value maps for ConflictPolicy.
+var (
+	ConflictPolicy_name = map[int32]string{
+		0: "CONFLICT_POLICY_UNSPECIFIED",
+		1: "CONFLICT_POLICY_FAIL",
+		2: "CONFLICT_POLICY_SKIP_IF_IDENTICAL",
+		3: "CONFLICT_POLICY_OVERWRITE",
+		4: "CONFLICT_POLICY_RENAME_WITH_SUFFIX",
+	}
+	ConflictPolicy_value = map[string]int32{
+		"CONFLICT_POLICY_UNSPECIFIED":        0,
+		"CONFLICT_POLICY_FAIL":               1,
+		"CONFLICT_POLICY_SKIP_IF_IDENTICAL":  2,
+		"CONFLICT_POLICY_OVERWRITE":          3,
+		"CONFLICT_POLICY_RENAME_WITH_SUFFIX": 4,
+	}
+)
+
+func (x ConflictPolicy) Enum() *ConflictPolicy {
+	p := new(ConflictPolicy)
+	*p = x
+	return p
+}
+
+func (x ConflictPolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ConflictPolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_qmeta_transfer_v1_transfer_proto_enumTypes[0].Descriptor()
+}
+
+func (ConflictPolicy) Type() protoreflect.EnumType {
+	return &file_qmeta_transfer_v1_transfer_proto_enumTypes[0]
+}
+
+func (x ConflictPolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// ConflictOutcome 同名文件冲突的处理结果
+type ConflictOutcome int32
+
+const (
+	ConflictOutcome_CONFLICT_OUTCOME_UNSPECIFIED ConflictOutcome = 0
+	ConflictOutcome_CONFLICT_OUTCOME_NONE        ConflictOutcome = 1
+	ConflictOutcome_CONFLICT_OUTCOME_FAILED      ConflictOutcome = 2
+	ConflictOutcome_CONFLICT_OUTCOME_SKIPPED     ConflictOutcome = 3
+	ConflictOutcome_CONFLICT_OUTCOME_OVERWRITTEN ConflictOutcome = 4
+	ConflictOutcome_CONFLICT_OUTCOME_RENAMED     ConflictOutcome = 5
+)
+
+// Enum value maps for ConflictOutcome.
+var (
+	ConflictOutcome_name = map[int32]string{
+		0: "CONFLICT_OUTCOME_UNSPECIFIED",
+		1: "CONFLICT_OUTCOME_NONE",
+		2: "CONFLICT_OUTCOME_FAILED",
+		3: "CONFLICT_OUTCOME_SKIPPED",
+		4: "CONFLICT_OUTCOME_OVERWRITTEN",
+		5: "CONFLICT_OUTCOME_RENAMED",
+	}
+	ConflictOutcome_value = map[string]int32{
+		"CONFLICT_OUTCOME_UNSPECIFIED": 0,
+		"CONFLICT_OUTCOME_NONE":        1,
+		"CONFLICT_OUTCOME_FAILED":      2,
+		"CONFLICT_OUTCOME_SKIPPED":     3,
+		"CONFLICT_OUTCOME_OVERWRITTEN": 4,
+		"CONFLICT_OUTCOME_RENAMED":     5,
+	}
+)
+
+func (x ConflictOutcome) Enum() *ConflictOutcome {
+	p := new(ConflictOutcome)
+	*p = x
+	return p
+}
+
+func (x ConflictOutcome) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ConflictOutcome) Descriptor() protoreflect.EnumDescriptor {
+	return file_qmeta_transfer_v1_transfer_proto_enumTypes[1].Descriptor()
+}
+
+func (ConflictOutcome) Type() protoreflect.EnumType {
+	return &file_qmeta_transfer_v1_transfer_proto_enumTypes[1]
+}
+
+func (x ConflictOutcome) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// ErrorCode 失败原因，客户端据此判断错误类型，不依赖 message 的内容
+type ErrorCode int32
+
+const (
+	ErrorCode_ERROR_CODE_UNSPECIFIED ErrorCode = 0
+	ErrorCode_ERROR_CODE_REJECTED    ErrorCode = 1
+	ErrorCode_ERROR_CODE_EXISTS      ErrorCode = 2
+	ErrorCode_ERROR_CODE_NOT_FOUND   ErrorCode = 3
+	ErrorCode_ERROR_CODE_INTEGRITY   ErrorCode = 4
+	ErrorCode_ERROR_CODE_INTERNAL    ErrorCode = 5
+)
+
+// Enum value maps for ErrorCode.
+var (
+	ErrorCode_name = map[int32]string{
+		0: "ERROR_CODE_UNSPECIFIED",
+		1: "ERROR_CODE_REJECTED",
+		2: "ERROR_CODE_EXISTS",
+		3: "ERROR_CODE_NOT_FOUND",
+		4: "ERROR_CODE_INTEGRITY",
+		5: "ERROR_CODE_INTERNAL",
+	}
+	ErrorCode_value = map[string]int32{
+		"ERROR_CODE_UNSPECIFIED": 0,
+		"ERROR_CODE_REJECTED":    1,
+		"ERROR_CODE_EXISTS":      2,
+		"ERROR_CODE_NOT_FOUND":   3,
+		"ERROR_CODE_INTEGRITY":   4,
+		"ERROR_CODE_INTERNAL":    5,
+	}
+)
+
+func (x ErrorCode) Enum() *ErrorCode {
+	p := new(ErrorCode)
+	*p = x
+	return p
+}
+
+func (x ErrorCode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ErrorCode) Descriptor() protoreflect.EnumDescriptor {
+	return file_qmeta_transfer_v1_transfer_proto_enumTypes[2].Descriptor()
+}
+
+func (ErrorCode) Type() protoreflect.EnumType {
+	return &file_qmeta_transfer_v1_transfer_proto_enumTypes[2]
+}
+
+func (x ErrorCode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
 // ServerCheckRequest 服务器检查请求
 type ServerCheckRequest struct {
 	state                  protoimpl.MessageState `protogen:"opaque.v1"`
@@ -173,6 +333,11 @@ func (b0 ServerCheckResponse_builder) Build() *ServerCheckResponse {
 }
 
 // FileMetadata 文件元数据
+// chunk_hashes 不为空时为内容定义分片上传，按顺序记录每个分片的哈希，
+// 客户端只发送服务端缺少的分片
+// delta 表示客户端支持增量传输，服务端已有不同版本时返回块签名
+// conflict 为同名文件已存在时的处理策略
+// streaming 表示大小和哈希未知，由最后一个分片之后的 FileTrailer 给出
 type FileMetadata struct {
 	state                  protoimpl.MessageState `protogen:"opaque.v1"`
 	xxx_hidden_Tag         *string                `protobuf:"bytes,1,opt,name=tag"`
@@ -181,6 +346,10 @@ type FileMetadata struct {
 	xxx_hidden_Chunks      int64                  `protobuf:"varint,4,opt,name=chunks"`
 	xxx_hidden_Chunksize   int64                  `protobuf:"varint,5,opt,name=chunksize"`
 	xxx_hidden_Hash        *string                `protobuf:"bytes,6,opt,name=hash"`
+	xxx_hidden_ChunkHashes []string               `protobuf:"bytes,7,rep,name=chunk_hashes,json=chunkHashes"`
+	xxx_hidden_Delta       bool                   `protobuf:"varint,8,opt,name=delta"`
+	xxx_hidden_Conflict    ConflictPolicy         `protobuf:"varint,9,opt,name=conflict,enum=qmeta.transfer.v1.ConflictPolicy"`
+	xxx_hidden_Streaming   bool                   `protobuf:"varint,10,opt,name=streaming"`
 	XXX_raceDetectHookData protoimpl.RaceDetectHookData
 	XXX_presence           [1]uint32
 	unknownFields          protoimpl.UnknownFields
@@ -263,34 +432,83 @@ func (x *FileMetadata) GetHash() string {
 	return ""
 }
 
+func (x *FileMetadata) GetChunkHashes() []string {
+	if x != nil {
+		return x.xxx_hidden_ChunkHashes
+	}
+	return nil
+}
+
+func (x *FileMetadata) GetDelta() bool {
+	if x != nil {
+		return x.xxx_hidden_Delta
+	}
+	return false
+}
+
+func (x *FileMetadata) GetConflict() ConflictPolicy {
+	if x != nil {
+		if protoimpl.X.Present(&(x.XXX_presence[0]), 8) {
+			return x.xxx_hidden_Conflict
+		}
+	}
+	return ConflictPolicy_CONFLICT_POLICY_UNSPECIFIED
+}
+
+func (x *FileMetadata) GetStreaming() bool {
+	if x != nil {
+		return x.xxx_hidden_Streaming
+	}
+	return false
+}
+
 func (x *FileMetadata) SetTag(v string) {
 	x.xxx_hidden_Tag = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 6)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 10)
 }
 
 func (x *FileMetadata) SetName(v string) {
 	x.xxx_hidden_Name = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 6)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 10)
 }
 
 func (x *FileMetadata) SetSize(v int64) {
 	x.xxx_hidden_Size = v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 6)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 10)
 }
 
 func (x *FileMetadata) SetChunks(v int64) {
 	x.xxx_hidden_Chunks = v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 6)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 10)
 }
 
 func (x *FileMetadata) SetChunksize(v int64) {
 	x.xxx_hidden_Chunksize = v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 4, 6)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 4, 10)
 }
 
 func (x *FileMetadata) SetHash(v string) {
 	x.xxx_hidden_Hash = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 5, 6)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 5, 10)
+}
+
+func (x *FileMetadata) SetChunkHashes(v []string) {
+	x.xxx_hidden_ChunkHashes = v
+}
+
+func (x *FileMetadata) SetDelta(v bool) {
+	x.xxx_hidden_Delta = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 7, 10)
+}
+
+func (x *FileMetadata) SetConflict(v ConflictPolicy) {
+	x.xxx_hidden_Conflict = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 8, 10)
+}
+
+func (x *FileMetadata) SetStreaming(v bool) {
+	x.xxx_hidden_Streaming = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 9, 10)
 }
 
 func (x *FileMetadata) HasTag() bool {
@@ -335,6 +553,27 @@ func (x *FileMetadata) HasHash() bool {
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 5)
 }
 
+func (x *FileMetadata) HasDelta() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 7)
+}
+
+func (x *FileMetadata) HasConflict() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 8)
+}
+
+func (x *FileMetadata) HasStreaming() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 9)
+}
+
 func (x *FileMetadata) ClearTag() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
 	x.xxx_hidden_Tag = nil
@@ -365,15 +604,34 @@ func (x *FileMetadata) ClearHash() {
 	x.xxx_hidden_Hash = nil
 }
 
+func (x *FileMetadata) ClearDelta() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 7)
+	x.xxx_hidden_Delta = false
+}
+
+func (x *FileMetadata) ClearConflict() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 8)
+	x.xxx_hidden_Conflict = ConflictPolicy_CONFLICT_POLICY_UNSPECIFIED
+}
+
+func (x *FileMetadata) ClearStreaming() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 9)
+	x.xxx_hidden_Streaming = false
+}
+
 type FileMetadata_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-	Tag       *string
-	Name      *string
-	Size      *int64
-	Chunks    *int64
-	Chunksize *int64
-	Hash      *string
+	Tag         *string
+	Name        *string
+	Size        *int64
+	Chunks      *int64
+	Chunksize   *int64
+	Hash        *string
+	ChunkHashes []string
+	Delta       *bool
+	Conflict    *ConflictPolicy
+	Streaming   *bool
 }
 
 func (b0 FileMetadata_builder) Build() *FileMetadata {
@@ -381,57 +639,70 @@ func (b0 FileMetadata_builder) Build() *FileMetadata {
 	b, x := &b0, m0
 	_, _ = b, x
 	if b.Tag != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 6)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 10)
 		x.xxx_hidden_Tag = b.Tag
 	}
 	if b.Name != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 6)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 10)
 		x.xxx_hidden_Name = b.Name
 	}
 	if b.Size != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 6)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 10)
 		x.xxx_hidden_Size = *b.Size
 	}
 	if b.Chunks != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 6)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 10)
 		x.xxx_hidden_Chunks = *b.Chunks
 	}
 	if b.Chunksize != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 4, 6)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 4, 10)
 		x.xxx_hidden_Chunksize = *b.Chunksize
 	}
 	if b.Hash != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 5, 6)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 5, 10)
 		x.xxx_hidden_Hash = b.Hash
 	}
+	x.xxx_hidden_ChunkHashes = b.ChunkHashes
+	if b.Delta != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 7, 10)
+		x.xxx_hidden_Delta = *b.Delta
+	}
+	if b.Conflict != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 8, 10)
+		x.xxx_hidden_Conflict = *b.Conflict
+	}
+	if b.Streaming != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 9, 10)
+		x.xxx_hidden_Streaming = *b.Streaming
+	}
 	return m0
 }
 
-// ChunkData 文件块数据
-type ChunkData struct {
+// FileTrailer 流式上传结束时发送的文件大小和哈希
+type FileTrailer struct {
 	state                  protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_Data        []byte                 `protobuf:"bytes,1,opt,name=data"`
-	xxx_hidden_Chunk       int64                  `protobuf:"varint,2,opt,name=chunk"`
+	xxx_hidden_Size        int64                  `protobuf:"varint,1,opt,name=size"`
+	xxx_hidden_Hash        *string                `protobuf:"bytes,2,opt,name=hash"`
 	XXX_raceDetectHookData protoimpl.RaceDetectHookData
 	XXX_presence           [1]uint32
 	unknownFields          protoimpl.UnknownFields
 	sizeCache              protoimpl.SizeCache
 }
 
-func (x *ChunkData) Reset() {
-	*x = ChunkData{}
+func (x *FileTrailer) Reset() {
+	*x = FileTrailer{}
 	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ChunkData) String() string {
+func (x *FileTrailer) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ChunkData) ProtoMessage() {}
+func (*FileTrailer) ProtoMessage() {}
 
-func (x *ChunkData) ProtoReflect() protoreflect.Message {
+func (x *FileTrailer) ProtoReflect() protoreflect.Message {
 	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -443,101 +714,105 @@ func (x *ChunkData) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *ChunkData) GetData() []byte {
+func (x *FileTrailer) GetSize() int64 {
 	if x != nil {
-		return x.xxx_hidden_Data
+		return x.xxx_hidden_Size
 	}
-	return nil
+	return 0
 }
 
-func (x *ChunkData) GetChunk() int64 {
+func (x *FileTrailer) GetHash() string {
 	if x != nil {
-		return x.xxx_hidden_Chunk
+		if x.xxx_hidden_Hash != nil {
+			return *x.xxx_hidden_Hash
+		}
+		return ""
 	}
-	return 0
+	return ""
 }
 
-func (x *ChunkData) SetData(v []byte) {
-	if v == nil {
-		v = []byte{}
-	}
-	x.xxx_hidden_Data = v
+func (x *FileTrailer) SetSize(v int64) {
+	x.xxx_hidden_Size = v
 	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 2)
 }
 
-func (x *ChunkData) SetChunk(v int64) {
-	x.xxx_hidden_Chunk = v
+func (x *FileTrailer) SetHash(v string) {
+	x.xxx_hidden_Hash = &v
 	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 2)
 }
 
-func (x *ChunkData) HasData() bool {
+func (x *FileTrailer) HasSize() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
 }
 
-func (x *ChunkData) HasChunk() bool {
+func (x *FileTrailer) HasHash() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
 }
 
-func (x *ChunkData) ClearData() {
+func (x *FileTrailer) ClearSize() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
-	x.xxx_hidden_Data = nil
+	x.xxx_hidden_Size = 0
 }
 
-func (x *ChunkData) ClearChunk() {
+func (x *FileTrailer) ClearHash() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
-	x.xxx_hidden_Chunk = 0
+	x.xxx_hidden_Hash = nil
 }
 
-type ChunkData_builder struct {
+type FileTrailer_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-	Data  []byte
-	Chunk *int64
+	Size *int64
+	Hash *string
 }
 
-func (b0 ChunkData_builder) Build() *ChunkData {
-	m0 := &ChunkData{}
+func (b0 FileTrailer_builder) Build() *FileTrailer {
+	m0 := &FileTrailer{}
 	b, x := &b0, m0
 	_, _ = b, x
-	if b.Data != nil {
+	if b.Size != nil {
 		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 2)
-		x.xxx_hidden_Data = b.Data
+		x.xxx_hidden_Size = *b.Size
 	}
-	if b.Chunk != nil {
+	if b.Hash != nil {
 		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 2)
-		x.xxx_hidden_Chunk = *b.Chunk
+		x.xxx_hidden_Hash = b.Hash
 	}
 	return m0
 }
 
-// UploadFileRequest 上传文件请求，包含文件元数据和文件块数据
-type UploadFileRequest struct {
-	state              protoimpl.MessageState      `protogen:"opaque.v1"`
-	xxx_hidden_Payload isUploadFileRequest_Payload `protobuf_oneof:"payload"`
-	unknownFields      protoimpl.UnknownFields
-	sizeCache          protoimpl.SizeCache
+// ChunkData 文件块数据，内容定义分片上传时 hash 为分片哈希
+type ChunkData struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Data        []byte                 `protobuf:"bytes,1,opt,name=data"`
+	xxx_hidden_Chunk       int64                  `protobuf:"varint,2,opt,name=chunk"`
+	xxx_hidden_Hash        *string                `protobuf:"bytes,3,opt,name=hash"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
 }
 
-func (x *UploadFileRequest) Reset() {
-	*x = UploadFileRequest{}
+func (x *ChunkData) Reset() {
+	*x = ChunkData{}
 	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UploadFileRequest) String() string {
+func (x *ChunkData) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UploadFileRequest) ProtoMessage() {}
+func (*ChunkData) ProtoMessage() {}
 
-func (x *UploadFileRequest) ProtoReflect() protoreflect.Message {
+func (x *ChunkData) ProtoReflect() protoreflect.Message {
 	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -549,168 +824,333 @@ func (x *UploadFileRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *UploadFileRequest) GetMetadata() *FileMetadata {
+func (x *ChunkData) GetData() []byte {
 	if x != nil {
-		if x, ok := x.xxx_hidden_Payload.(*uploadFileRequest_Metadata); ok {
-			return x.Metadata
-		}
+		return x.xxx_hidden_Data
 	}
 	return nil
 }
 
-func (x *UploadFileRequest) GetChunk() *ChunkData {
+func (x *ChunkData) GetChunk() int64 {
 	if x != nil {
-		if x, ok := x.xxx_hidden_Payload.(*uploadFileRequest_Chunk); ok {
-			return x.Chunk
-		}
+		return x.xxx_hidden_Chunk
 	}
-	return nil
+	return 0
 }
 
-func (x *UploadFileRequest) SetMetadata(v *FileMetadata) {
-	if v == nil {
-		x.xxx_hidden_Payload = nil
-		return
+func (x *ChunkData) GetHash() string {
+	if x != nil {
+		if x.xxx_hidden_Hash != nil {
+			return *x.xxx_hidden_Hash
+		}
+		return ""
 	}
-	x.xxx_hidden_Payload = &uploadFileRequest_Metadata{v}
+	return ""
 }
 
-func (x *UploadFileRequest) SetChunk(v *ChunkData) {
+func (x *ChunkData) SetData(v []byte) {
 	if v == nil {
-		x.xxx_hidden_Payload = nil
-		return
+		v = []byte{}
 	}
-	x.xxx_hidden_Payload = &uploadFileRequest_Chunk{v}
+	x.xxx_hidden_Data = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 3)
 }
 
-func (x *UploadFileRequest) HasPayload() bool {
+func (x *ChunkData) SetChunk(v int64) {
+	x.xxx_hidden_Chunk = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 3)
+}
+
+func (x *ChunkData) SetHash(v string) {
+	x.xxx_hidden_Hash = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 3)
+}
+
+func (x *ChunkData) HasData() bool {
 	if x == nil {
 		return false
 	}
-	return x.xxx_hidden_Payload != nil
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
 }
 
-func (x *UploadFileRequest) HasMetadata() bool {
+func (x *ChunkData) HasChunk() bool {
 	if x == nil {
 		return false
 	}
-	_, ok := x.xxx_hidden_Payload.(*uploadFileRequest_Metadata)
-	return ok
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
 }
 
-func (x *UploadFileRequest) HasChunk() bool {
+func (x *ChunkData) HasHash() bool {
 	if x == nil {
 		return false
 	}
-	_, ok := x.xxx_hidden_Payload.(*uploadFileRequest_Chunk)
-	return ok
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
 }
 
-func (x *UploadFileRequest) ClearPayload() {
-	x.xxx_hidden_Payload = nil
+func (x *ChunkData) ClearData() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Data = nil
 }
 
-func (x *UploadFileRequest) ClearMetadata() {
-	if _, ok := x.xxx_hidden_Payload.(*uploadFileRequest_Metadata); ok {
-		x.xxx_hidden_Payload = nil
-	}
+func (x *ChunkData) ClearChunk() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Chunk = 0
 }
 
-func (x *UploadFileRequest) ClearChunk() {
-	if _, ok := x.xxx_hidden_Payload.(*uploadFileRequest_Chunk); ok {
-		x.xxx_hidden_Payload = nil
-	}
+func (x *ChunkData) ClearHash() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_Hash = nil
 }
 
-const UploadFileRequest_Payload_not_set_case case_UploadFileRequest_Payload = 0
-const UploadFileRequest_Metadata_case case_UploadFileRequest_Payload = 1
-const UploadFileRequest_Chunk_case case_UploadFileRequest_Payload = 2
+type ChunkData_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-func (x *UploadFileRequest) WhichPayload() case_UploadFileRequest_Payload {
+	Data  []byte
+	Chunk *int64
+	Hash  *string
+}
+
+func (b0 ChunkData_builder) Build() *ChunkData {
+	m0 := &ChunkData{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Data != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 3)
+		x.xxx_hidden_Data = b.Data
+	}
+	if b.Chunk != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 3)
+		x.xxx_hidden_Chunk = *b.Chunk
+	}
+	if b.Hash != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 3)
+		x.xxx_hidden_Hash = b.Hash
+	}
+	return m0
+}
+
+// DeltaOp 增量指令，data 为空时复制旧文件中从 block 开始的 count 个块，否则写入新数据
+type DeltaOp struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Block       int64                  `protobuf:"varint,1,opt,name=block"`
+	xxx_hidden_Count       int64                  `protobuf:"varint,2,opt,name=count"`
+	xxx_hidden_Data        []byte                 `protobuf:"bytes,3,opt,name=data"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *DeltaOp) Reset() {
+	*x = DeltaOp{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeltaOp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeltaOp) ProtoMessage() {}
+
+func (x *DeltaOp) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *DeltaOp) GetBlock() int64 {
+	if x != nil {
+		return x.xxx_hidden_Block
+	}
+	return 0
+}
+
+func (x *DeltaOp) GetCount() int64 {
+	if x != nil {
+		return x.xxx_hidden_Count
+	}
+	return 0
+}
+
+func (x *DeltaOp) GetData() []byte {
+	if x != nil {
+		return x.xxx_hidden_Data
+	}
+	return nil
+}
+
+func (x *DeltaOp) SetBlock(v int64) {
+	x.xxx_hidden_Block = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 3)
+}
+
+func (x *DeltaOp) SetCount(v int64) {
+	x.xxx_hidden_Count = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 3)
+}
+
+func (x *DeltaOp) SetData(v []byte) {
+	if v == nil {
+		v = []byte{}
+	}
+	x.xxx_hidden_Data = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 3)
+}
+
+func (x *DeltaOp) HasBlock() bool {
 	if x == nil {
-		return UploadFileRequest_Payload_not_set_case
+		return false
 	}
-	switch x.xxx_hidden_Payload.(type) {
-	case *uploadFileRequest_Metadata:
-		return UploadFileRequest_Metadata_case
-	case *uploadFileRequest_Chunk:
-		return UploadFileRequest_Chunk_case
-	default:
-		return UploadFileRequest_Payload_not_set_case
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *DeltaOp) HasCount() bool {
+	if x == nil {
+		return false
 	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
 }
 
-type UploadFileRequest_builder struct {
+func (x *DeltaOp) HasData() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
+}
+
+func (x *DeltaOp) ClearBlock() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Block = 0
+}
+
+func (x *DeltaOp) ClearCount() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Count = 0
+}
+
+func (x *DeltaOp) ClearData() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_Data = nil
+}
+
+type DeltaOp_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-	// Fields of oneof xxx_hidden_Payload:
-	Metadata *FileMetadata
-	Chunk    *ChunkData
-	// -- end of xxx_hidden_Payload
+	Block *int64
+	Count *int64
+	Data  []byte
 }
 
-func (b0 UploadFileRequest_builder) Build() *UploadFileRequest {
-	m0 := &UploadFileRequest{}
+func (b0 DeltaOp_builder) Build() *DeltaOp {
+	m0 := &DeltaOp{}
 	b, x := &b0, m0
 	_, _ = b, x
-	if b.Metadata != nil {
-		x.xxx_hidden_Payload = &uploadFileRequest_Metadata{b.Metadata}
+	if b.Block != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 3)
+		x.xxx_hidden_Block = *b.Block
 	}
-	if b.Chunk != nil {
-		x.xxx_hidden_Payload = &uploadFileRequest_Chunk{b.Chunk}
+	if b.Count != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 3)
+		x.xxx_hidden_Count = *b.Count
+	}
+	if b.Data != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 3)
+		x.xxx_hidden_Data = b.Data
 	}
 	return m0
 }
 
-type case_UploadFileRequest_Payload protoreflect.FieldNumber
+// DeltaData 一组增量指令
+type DeltaData struct {
+	state          protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Ops *[]*DeltaOp            `protobuf:"bytes,1,rep,name=ops"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
 
-func (x case_UploadFileRequest_Payload) String() string {
-	md := file_qmeta_transfer_v1_transfer_proto_msgTypes[4].Descriptor()
-	if x == 0 {
-		return "not set"
-	}
-	return protoimpl.X.MessageFieldStringOf(md, protoreflect.FieldNumber(x))
+func (x *DeltaData) Reset() {
+	*x = DeltaData{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-type isUploadFileRequest_Payload interface {
-	isUploadFileRequest_Payload()
+func (x *DeltaData) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-type uploadFileRequest_Metadata struct {
-	Metadata *FileMetadata `protobuf:"bytes,1,opt,name=metadata,oneof"`
+func (*DeltaData) ProtoMessage() {}
+
+func (x *DeltaData) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-type uploadFileRequest_Chunk struct {
-	Chunk *ChunkData `protobuf:"bytes,2,opt,name=chunk,oneof"`
+func (x *DeltaData) GetOps() []*DeltaOp {
+	if x != nil {
+		if x.xxx_hidden_Ops != nil {
+			return *x.xxx_hidden_Ops
+		}
+	}
+	return nil
 }
 
-func (*uploadFileRequest_Metadata) isUploadFileRequest_Payload() {}
+func (x *DeltaData) SetOps(v []*DeltaOp) {
+	x.xxx_hidden_Ops = &v
+}
 
-func (*uploadFileRequest_Chunk) isUploadFileRequest_Payload() {}
+type DeltaData_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-// UploadFileResponse 上传文件响应，包含元数据确认、块确认和传输结果
-type UploadFileResponse struct {
-	state              protoimpl.MessageState       `protogen:"opaque.v1"`
-	xxx_hidden_Payload isUploadFileResponse_Payload `protobuf_oneof:"payload"`
+	Ops []*DeltaOp
+}
+
+func (b0 DeltaData_builder) Build() *DeltaData {
+	m0 := &DeltaData{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Ops = &b.Ops
+	return m0
+}
+
+// UploadFileRequest 上传文件请求，包含文件元数据、文件块数据、增量数据或流式上传结尾
+type UploadFileRequest struct {
+	state              protoimpl.MessageState      `protogen:"opaque.v1"`
+	xxx_hidden_Payload isUploadFileRequest_Payload `protobuf_oneof:"payload"`
 	unknownFields      protoimpl.UnknownFields
 	sizeCache          protoimpl.SizeCache
 }
 
-func (x *UploadFileResponse) Reset() {
-	*x = UploadFileResponse{}
-	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[5]
+func (x *UploadFileRequest) Reset() {
+	*x = UploadFileRequest{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UploadFileResponse) String() string {
+func (x *UploadFileRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UploadFileResponse) ProtoMessage() {}
+func (*UploadFileRequest) ProtoMessage() {}
 
-func (x *UploadFileResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[5]
+func (x *UploadFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -721,99 +1161,359 @@ func (x *UploadFileResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *UploadFileResponse) GetMetaAck() *MetaAck {
+func (x *UploadFileRequest) GetMetadata() *FileMetadata {
 	if x != nil {
-		if x, ok := x.xxx_hidden_Payload.(*uploadFileResponse_MetaAck); ok {
-			return x.MetaAck
+		if x, ok := x.xxx_hidden_Payload.(*uploadFileRequest_Metadata); ok {
+			return x.Metadata
 		}
 	}
 	return nil
 }
 
-func (x *UploadFileResponse) GetChunkAck() *ChunkAck {
+func (x *UploadFileRequest) GetChunk() *ChunkData {
 	if x != nil {
-		if x, ok := x.xxx_hidden_Payload.(*uploadFileResponse_ChunkAck); ok {
-			return x.ChunkAck
+		if x, ok := x.xxx_hidden_Payload.(*uploadFileRequest_Chunk); ok {
+			return x.Chunk
 		}
 	}
 	return nil
 }
 
-func (x *UploadFileResponse) GetResult() *TransferResult {
+func (x *UploadFileRequest) GetDelta() *DeltaData {
 	if x != nil {
-		if x, ok := x.xxx_hidden_Payload.(*uploadFileResponse_Result); ok {
-			return x.Result
+		if x, ok := x.xxx_hidden_Payload.(*uploadFileRequest_Delta); ok {
+			return x.Delta
 		}
 	}
 	return nil
 }
 
-func (x *UploadFileResponse) SetMetaAck(v *MetaAck) {
+func (x *UploadFileRequest) GetTrailer() *FileTrailer {
+	if x != nil {
+		if x, ok := x.xxx_hidden_Payload.(*uploadFileRequest_Trailer); ok {
+			return x.Trailer
+		}
+	}
+	return nil
+}
+
+func (x *UploadFileRequest) SetMetadata(v *FileMetadata) {
 	if v == nil {
 		x.xxx_hidden_Payload = nil
 		return
 	}
-	x.xxx_hidden_Payload = &uploadFileResponse_MetaAck{v}
+	x.xxx_hidden_Payload = &uploadFileRequest_Metadata{v}
 }
 
-func (x *UploadFileResponse) SetChunkAck(v *ChunkAck) {
+func (x *UploadFileRequest) SetChunk(v *ChunkData) {
 	if v == nil {
 		x.xxx_hidden_Payload = nil
 		return
 	}
-	x.xxx_hidden_Payload = &uploadFileResponse_ChunkAck{v}
+	x.xxx_hidden_Payload = &uploadFileRequest_Chunk{v}
 }
 
-func (x *UploadFileResponse) SetResult(v *TransferResult) {
+func (x *UploadFileRequest) SetDelta(v *DeltaData) {
 	if v == nil {
 		x.xxx_hidden_Payload = nil
 		return
 	}
-	x.xxx_hidden_Payload = &uploadFileResponse_Result{v}
+	x.xxx_hidden_Payload = &uploadFileRequest_Delta{v}
 }
 
-func (x *UploadFileResponse) HasPayload() bool {
+func (x *UploadFileRequest) SetTrailer(v *FileTrailer) {
+	if v == nil {
+		x.xxx_hidden_Payload = nil
+		return
+	}
+	x.xxx_hidden_Payload = &uploadFileRequest_Trailer{v}
+}
+
+func (x *UploadFileRequest) HasPayload() bool {
 	if x == nil {
 		return false
 	}
 	return x.xxx_hidden_Payload != nil
 }
 
-func (x *UploadFileResponse) HasMetaAck() bool {
+func (x *UploadFileRequest) HasMetadata() bool {
 	if x == nil {
 		return false
 	}
-	_, ok := x.xxx_hidden_Payload.(*uploadFileResponse_MetaAck)
+	_, ok := x.xxx_hidden_Payload.(*uploadFileRequest_Metadata)
 	return ok
 }
 
-func (x *UploadFileResponse) HasChunkAck() bool {
+func (x *UploadFileRequest) HasChunk() bool {
 	if x == nil {
 		return false
 	}
-	_, ok := x.xxx_hidden_Payload.(*uploadFileResponse_ChunkAck)
+	_, ok := x.xxx_hidden_Payload.(*uploadFileRequest_Chunk)
 	return ok
 }
 
-func (x *UploadFileResponse) HasResult() bool {
+func (x *UploadFileRequest) HasDelta() bool {
 	if x == nil {
 		return false
 	}
-	_, ok := x.xxx_hidden_Payload.(*uploadFileResponse_Result)
+	_, ok := x.xxx_hidden_Payload.(*uploadFileRequest_Delta)
 	return ok
 }
 
-func (x *UploadFileResponse) ClearPayload() {
+func (x *UploadFileRequest) HasTrailer() bool {
+	if x == nil {
+		return false
+	}
+	_, ok := x.xxx_hidden_Payload.(*uploadFileRequest_Trailer)
+	return ok
+}
+
+func (x *UploadFileRequest) ClearPayload() {
 	x.xxx_hidden_Payload = nil
 }
 
-func (x *UploadFileResponse) ClearMetaAck() {
-	if _, ok := x.xxx_hidden_Payload.(*uploadFileResponse_MetaAck); ok {
+func (x *UploadFileRequest) ClearMetadata() {
+	if _, ok := x.xxx_hidden_Payload.(*uploadFileRequest_Metadata); ok {
 		x.xxx_hidden_Payload = nil
 	}
 }
 
-func (x *UploadFileResponse) ClearChunkAck() {
+func (x *UploadFileRequest) ClearChunk() {
+	if _, ok := x.xxx_hidden_Payload.(*uploadFileRequest_Chunk); ok {
+		x.xxx_hidden_Payload = nil
+	}
+}
+
+func (x *UploadFileRequest) ClearDelta() {
+	if _, ok := x.xxx_hidden_Payload.(*uploadFileRequest_Delta); ok {
+		x.xxx_hidden_Payload = nil
+	}
+}
+
+func (x *UploadFileRequest) ClearTrailer() {
+	if _, ok := x.xxx_hidden_Payload.(*uploadFileRequest_Trailer); ok {
+		x.xxx_hidden_Payload = nil
+	}
+}
+
+const UploadFileRequest_Payload_not_set_case case_UploadFileRequest_Payload = 0
+const UploadFileRequest_Metadata_case case_UploadFileRequest_Payload = 1
+const UploadFileRequest_Chunk_case case_UploadFileRequest_Payload = 2
+const UploadFileRequest_Delta_case case_UploadFileRequest_Payload = 3
+const UploadFileRequest_Trailer_case case_UploadFileRequest_Payload = 4
+
+func (x *UploadFileRequest) WhichPayload() case_UploadFileRequest_Payload {
+	if x == nil {
+		return UploadFileRequest_Payload_not_set_case
+	}
+	switch x.xxx_hidden_Payload.(type) {
+	case *uploadFileRequest_Metadata:
+		return UploadFileRequest_Metadata_case
+	case *uploadFileRequest_Chunk:
+		return UploadFileRequest_Chunk_case
+	case *uploadFileRequest_Delta:
+		return UploadFileRequest_Delta_case
+	case *uploadFileRequest_Trailer:
+		return UploadFileRequest_Trailer_case
+	default:
+		return UploadFileRequest_Payload_not_set_case
+	}
+}
+
+type UploadFileRequest_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Fields of oneof xxx_hidden_Payload:
+	Metadata *FileMetadata
+	Chunk    *ChunkData
+	Delta    *DeltaData
+	Trailer  *FileTrailer
+	// -- end of xxx_hidden_Payload
+}
+
+func (b0 UploadFileRequest_builder) Build() *UploadFileRequest {
+	m0 := &UploadFileRequest{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Metadata != nil {
+		x.xxx_hidden_Payload = &uploadFileRequest_Metadata{b.Metadata}
+	}
+	if b.Chunk != nil {
+		x.xxx_hidden_Payload = &uploadFileRequest_Chunk{b.Chunk}
+	}
+	if b.Delta != nil {
+		x.xxx_hidden_Payload = &uploadFileRequest_Delta{b.Delta}
+	}
+	if b.Trailer != nil {
+		x.xxx_hidden_Payload = &uploadFileRequest_Trailer{b.Trailer}
+	}
+	return m0
+}
+
+type case_UploadFileRequest_Payload protoreflect.FieldNumber
+
+func (x case_UploadFileRequest_Payload) String() string {
+	md := file_qmeta_transfer_v1_transfer_proto_msgTypes[7].Descriptor()
+	if x == 0 {
+		return "not set"
+	}
+	return protoimpl.X.MessageFieldStringOf(md, protoreflect.FieldNumber(x))
+}
+
+type isUploadFileRequest_Payload interface {
+	isUploadFileRequest_Payload()
+}
+
+type uploadFileRequest_Metadata struct {
+	Metadata *FileMetadata `protobuf:"bytes,1,opt,name=metadata,oneof"`
+}
+
+type uploadFileRequest_Chunk struct {
+	Chunk *ChunkData `protobuf:"bytes,2,opt,name=chunk,oneof"`
+}
+
+type uploadFileRequest_Delta struct {
+	Delta *DeltaData `protobuf:"bytes,3,opt,name=delta,oneof"`
+}
+
+type uploadFileRequest_Trailer struct {
+	Trailer *FileTrailer `protobuf:"bytes,4,opt,name=trailer,oneof"`
+}
+
+func (*uploadFileRequest_Metadata) isUploadFileRequest_Payload() {}
+
+func (*uploadFileRequest_Chunk) isUploadFileRequest_Payload() {}
+
+func (*uploadFileRequest_Delta) isUploadFileRequest_Payload() {}
+
+func (*uploadFileRequest_Trailer) isUploadFileRequest_Payload() {}
+
+// UploadFileResponse 上传文件响应，包含元数据确认、块确认和传输结果
+type UploadFileResponse struct {
+	state              protoimpl.MessageState       `protogen:"opaque.v1"`
+	xxx_hidden_Payload isUploadFileResponse_Payload `protobuf_oneof:"payload"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *UploadFileResponse) Reset() {
+	*x = UploadFileResponse{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadFileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadFileResponse) ProtoMessage() {}
+
+func (x *UploadFileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *UploadFileResponse) GetMetaAck() *MetaAck {
+	if x != nil {
+		if x, ok := x.xxx_hidden_Payload.(*uploadFileResponse_MetaAck); ok {
+			return x.MetaAck
+		}
+	}
+	return nil
+}
+
+func (x *UploadFileResponse) GetChunkAck() *ChunkAck {
+	if x != nil {
+		if x, ok := x.xxx_hidden_Payload.(*uploadFileResponse_ChunkAck); ok {
+			return x.ChunkAck
+		}
+	}
+	return nil
+}
+
+func (x *UploadFileResponse) GetResult() *TransferResult {
+	if x != nil {
+		if x, ok := x.xxx_hidden_Payload.(*uploadFileResponse_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+func (x *UploadFileResponse) SetMetaAck(v *MetaAck) {
+	if v == nil {
+		x.xxx_hidden_Payload = nil
+		return
+	}
+	x.xxx_hidden_Payload = &uploadFileResponse_MetaAck{v}
+}
+
+func (x *UploadFileResponse) SetChunkAck(v *ChunkAck) {
+	if v == nil {
+		x.xxx_hidden_Payload = nil
+		return
+	}
+	x.xxx_hidden_Payload = &uploadFileResponse_ChunkAck{v}
+}
+
+func (x *UploadFileResponse) SetResult(v *TransferResult) {
+	if v == nil {
+		x.xxx_hidden_Payload = nil
+		return
+	}
+	x.xxx_hidden_Payload = &uploadFileResponse_Result{v}
+}
+
+func (x *UploadFileResponse) HasPayload() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_Payload != nil
+}
+
+func (x *UploadFileResponse) HasMetaAck() bool {
+	if x == nil {
+		return false
+	}
+	_, ok := x.xxx_hidden_Payload.(*uploadFileResponse_MetaAck)
+	return ok
+}
+
+func (x *UploadFileResponse) HasChunkAck() bool {
+	if x == nil {
+		return false
+	}
+	_, ok := x.xxx_hidden_Payload.(*uploadFileResponse_ChunkAck)
+	return ok
+}
+
+func (x *UploadFileResponse) HasResult() bool {
+	if x == nil {
+		return false
+	}
+	_, ok := x.xxx_hidden_Payload.(*uploadFileResponse_Result)
+	return ok
+}
+
+func (x *UploadFileResponse) ClearPayload() {
+	x.xxx_hidden_Payload = nil
+}
+
+func (x *UploadFileResponse) ClearMetaAck() {
+	if _, ok := x.xxx_hidden_Payload.(*uploadFileResponse_MetaAck); ok {
+		x.xxx_hidden_Payload = nil
+	}
+}
+
+func (x *UploadFileResponse) ClearChunkAck() {
 	if _, ok := x.xxx_hidden_Payload.(*uploadFileResponse_ChunkAck); ok {
 		x.xxx_hidden_Payload = nil
 	}
@@ -869,67 +1569,2062 @@ func (b0 UploadFileResponse_builder) Build() *UploadFileResponse {
 	if b.Result != nil {
 		x.xxx_hidden_Payload = &uploadFileResponse_Result{b.Result}
 	}
-	return m0
+	return m0
+}
+
+type case_UploadFileResponse_Payload protoreflect.FieldNumber
+
+func (x case_UploadFileResponse_Payload) String() string {
+	md := file_qmeta_transfer_v1_transfer_proto_msgTypes[8].Descriptor()
+	if x == 0 {
+		return "not set"
+	}
+	return protoimpl.X.MessageFieldStringOf(md, protoreflect.FieldNumber(x))
+}
+
+type isUploadFileResponse_Payload interface {
+	isUploadFileResponse_Payload()
+}
+
+type uploadFileResponse_MetaAck struct {
+	MetaAck *MetaAck `protobuf:"bytes,1,opt,name=meta_ack,json=metaAck,oneof"`
+}
+
+type uploadFileResponse_ChunkAck struct {
+	ChunkAck *ChunkAck `protobuf:"bytes,2,opt,name=chunk_ack,json=chunkAck,oneof"`
+}
+
+type uploadFileResponse_Result struct {
+	Result *TransferResult `protobuf:"bytes,3,opt,name=result,oneof"`
+}
+
+func (*uploadFileResponse_MetaAck) isUploadFileResponse_Payload() {}
+
+func (*uploadFileResponse_ChunkAck) isUploadFileResponse_Payload() {}
+
+func (*uploadFileResponse_Result) isUploadFileResponse_Payload() {}
+
+// MetaAck 元数据确认，服务器对文件元数据的响应
+// completed 表示服务端已有相同内容的文件，无需传输数据
+// signatures 为服务端现有版本的块签名，客户端可据此发送增量数据
+// outcome 为同名文件冲突的处理结果，name 为最终保存的文件名
+// queue_position 大于 0 表示上传正在排队，获得名额后会再发送一个 MetaAck
+// error_code 为拒绝上传的原因
+type MetaAck struct {
+	state                    protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_AllowUpload   bool                   `protobuf:"varint,1,opt,name=allow_upload,json=allowUpload"`
+	xxx_hidden_Message       *string                `protobuf:"bytes,2,opt,name=message"`
+	xxx_hidden_Completed     bool                   `protobuf:"varint,3,opt,name=completed"`
+	xxx_hidden_Signatures    *BlockSignatures       `protobuf:"bytes,4,opt,name=signatures"`
+	xxx_hidden_Outcome       ConflictOutcome        `protobuf:"varint,5,opt,name=outcome,enum=qmeta.transfer.v1.ConflictOutcome"`
+	xxx_hidden_Name          *string                `protobuf:"bytes,6,opt,name=name"`
+	xxx_hidden_QueuePosition int32                  `protobuf:"varint,7,opt,name=queue_position,json=queuePosition"`
+	xxx_hidden_ErrorCode     ErrorCode              `protobuf:"varint,8,opt,name=error_code,json=errorCode,enum=qmeta.transfer.v1.ErrorCode"`
+	XXX_raceDetectHookData   protoimpl.RaceDetectHookData
+	XXX_presence             [1]uint32
+	unknownFields            protoimpl.UnknownFields
+	sizeCache                protoimpl.SizeCache
+}
+
+func (x *MetaAck) Reset() {
+	*x = MetaAck{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetaAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetaAck) ProtoMessage() {}
+
+func (x *MetaAck) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *MetaAck) GetAllowUpload() bool {
+	if x != nil {
+		return x.xxx_hidden_AllowUpload
+	}
+	return false
+}
+
+func (x *MetaAck) GetMessage() string {
+	if x != nil {
+		if x.xxx_hidden_Message != nil {
+			return *x.xxx_hidden_Message
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *MetaAck) GetCompleted() bool {
+	if x != nil {
+		return x.xxx_hidden_Completed
+	}
+	return false
+}
+
+func (x *MetaAck) GetSignatures() *BlockSignatures {
+	if x != nil {
+		return x.xxx_hidden_Signatures
+	}
+	return nil
+}
+
+func (x *MetaAck) GetOutcome() ConflictOutcome {
+	if x != nil {
+		if protoimpl.X.Present(&(x.XXX_presence[0]), 4) {
+			return x.xxx_hidden_Outcome
+		}
+	}
+	return ConflictOutcome_CONFLICT_OUTCOME_UNSPECIFIED
+}
+
+func (x *MetaAck) GetName() string {
+	if x != nil {
+		if x.xxx_hidden_Name != nil {
+			return *x.xxx_hidden_Name
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *MetaAck) GetQueuePosition() int32 {
+	if x != nil {
+		return x.xxx_hidden_QueuePosition
+	}
+	return 0
+}
+
+func (x *MetaAck) GetErrorCode() ErrorCode {
+	if x != nil {
+		if protoimpl.X.Present(&(x.XXX_presence[0]), 7) {
+			return x.xxx_hidden_ErrorCode
+		}
+	}
+	return ErrorCode_ERROR_CODE_UNSPECIFIED
+}
+
+func (x *MetaAck) SetAllowUpload(v bool) {
+	x.xxx_hidden_AllowUpload = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 8)
+}
+
+func (x *MetaAck) SetMessage(v string) {
+	x.xxx_hidden_Message = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 8)
+}
+
+func (x *MetaAck) SetCompleted(v bool) {
+	x.xxx_hidden_Completed = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 8)
+}
+
+func (x *MetaAck) SetSignatures(v *BlockSignatures) {
+	x.xxx_hidden_Signatures = v
+}
+
+func (x *MetaAck) SetOutcome(v ConflictOutcome) {
+	x.xxx_hidden_Outcome = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 4, 8)
+}
+
+func (x *MetaAck) SetName(v string) {
+	x.xxx_hidden_Name = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 5, 8)
+}
+
+func (x *MetaAck) SetQueuePosition(v int32) {
+	x.xxx_hidden_QueuePosition = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 6, 8)
+}
+
+func (x *MetaAck) SetErrorCode(v ErrorCode) {
+	x.xxx_hidden_ErrorCode = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 7, 8)
+}
+
+func (x *MetaAck) HasAllowUpload() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *MetaAck) HasMessage() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *MetaAck) HasCompleted() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
+}
+
+func (x *MetaAck) HasSignatures() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_Signatures != nil
+}
+
+func (x *MetaAck) HasOutcome() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 4)
+}
+
+func (x *MetaAck) HasName() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 5)
+}
+
+func (x *MetaAck) HasQueuePosition() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 6)
+}
+
+func (x *MetaAck) HasErrorCode() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 7)
+}
+
+func (x *MetaAck) ClearAllowUpload() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_AllowUpload = false
+}
+
+func (x *MetaAck) ClearMessage() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Message = nil
+}
+
+func (x *MetaAck) ClearCompleted() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_Completed = false
+}
+
+func (x *MetaAck) ClearSignatures() {
+	x.xxx_hidden_Signatures = nil
+}
+
+func (x *MetaAck) ClearOutcome() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 4)
+	x.xxx_hidden_Outcome = ConflictOutcome_CONFLICT_OUTCOME_UNSPECIFIED
+}
+
+func (x *MetaAck) ClearName() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 5)
+	x.xxx_hidden_Name = nil
+}
+
+func (x *MetaAck) ClearQueuePosition() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 6)
+	x.xxx_hidden_QueuePosition = 0
+}
+
+func (x *MetaAck) ClearErrorCode() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 7)
+	x.xxx_hidden_ErrorCode = ErrorCode_ERROR_CODE_UNSPECIFIED
+}
+
+type MetaAck_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	AllowUpload   *bool
+	Message       *string
+	Completed     *bool
+	Signatures    *BlockSignatures
+	Outcome       *ConflictOutcome
+	Name          *string
+	QueuePosition *int32
+	ErrorCode     *ErrorCode
+}
+
+func (b0 MetaAck_builder) Build() *MetaAck {
+	m0 := &MetaAck{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.AllowUpload != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 8)
+		x.xxx_hidden_AllowUpload = *b.AllowUpload
+	}
+	if b.Message != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 8)
+		x.xxx_hidden_Message = b.Message
+	}
+	if b.Completed != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 8)
+		x.xxx_hidden_Completed = *b.Completed
+	}
+	x.xxx_hidden_Signatures = b.Signatures
+	if b.Outcome != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 4, 8)
+		x.xxx_hidden_Outcome = *b.Outcome
+	}
+	if b.Name != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 5, 8)
+		x.xxx_hidden_Name = b.Name
+	}
+	if b.QueuePosition != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 6, 8)
+		x.xxx_hidden_QueuePosition = *b.QueuePosition
+	}
+	if b.ErrorCode != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 7, 8)
+		x.xxx_hidden_ErrorCode = *b.ErrorCode
+	}
+	return m0
+}
+
+// BlockSignature 块签名，包含滚动弱校验和与强校验和
+type BlockSignature struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Weak        uint32                 `protobuf:"varint,1,opt,name=weak"`
+	xxx_hidden_Strong      *string                `protobuf:"bytes,2,opt,name=strong"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *BlockSignature) Reset() {
+	*x = BlockSignature{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockSignature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockSignature) ProtoMessage() {}
+
+func (x *BlockSignature) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *BlockSignature) GetWeak() uint32 {
+	if x != nil {
+		return x.xxx_hidden_Weak
+	}
+	return 0
+}
+
+func (x *BlockSignature) GetStrong() string {
+	if x != nil {
+		if x.xxx_hidden_Strong != nil {
+			return *x.xxx_hidden_Strong
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *BlockSignature) SetWeak(v uint32) {
+	x.xxx_hidden_Weak = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 2)
+}
+
+func (x *BlockSignature) SetStrong(v string) {
+	x.xxx_hidden_Strong = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 2)
+}
+
+func (x *BlockSignature) HasWeak() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *BlockSignature) HasStrong() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *BlockSignature) ClearWeak() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Weak = 0
+}
+
+func (x *BlockSignature) ClearStrong() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Strong = nil
+}
+
+type BlockSignature_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Weak   *uint32
+	Strong *string
+}
+
+func (b0 BlockSignature_builder) Build() *BlockSignature {
+	m0 := &BlockSignature{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Weak != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 2)
+		x.xxx_hidden_Weak = *b.Weak
+	}
+	if b.Strong != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 2)
+		x.xxx_hidden_Strong = b.Strong
+	}
+	return m0
+}
+
+// BlockSignatures 文件的块签名列表
+type BlockSignatures struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_BlockSize   int64                  `protobuf:"varint,1,opt,name=block_size,json=blockSize"`
+	xxx_hidden_FileSize    int64                  `protobuf:"varint,2,opt,name=file_size,json=fileSize"`
+	xxx_hidden_Blocks      *[]*BlockSignature     `protobuf:"bytes,3,rep,name=blocks"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *BlockSignatures) Reset() {
+	*x = BlockSignatures{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockSignatures) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockSignatures) ProtoMessage() {}
+
+func (x *BlockSignatures) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *BlockSignatures) GetBlockSize() int64 {
+	if x != nil {
+		return x.xxx_hidden_BlockSize
+	}
+	return 0
+}
+
+func (x *BlockSignatures) GetFileSize() int64 {
+	if x != nil {
+		return x.xxx_hidden_FileSize
+	}
+	return 0
+}
+
+func (x *BlockSignatures) GetBlocks() []*BlockSignature {
+	if x != nil {
+		if x.xxx_hidden_Blocks != nil {
+			return *x.xxx_hidden_Blocks
+		}
+	}
+	return nil
+}
+
+func (x *BlockSignatures) SetBlockSize(v int64) {
+	x.xxx_hidden_BlockSize = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 3)
+}
+
+func (x *BlockSignatures) SetFileSize(v int64) {
+	x.xxx_hidden_FileSize = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 3)
+}
+
+func (x *BlockSignatures) SetBlocks(v []*BlockSignature) {
+	x.xxx_hidden_Blocks = &v
+}
+
+func (x *BlockSignatures) HasBlockSize() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *BlockSignatures) HasFileSize() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *BlockSignatures) ClearBlockSize() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_BlockSize = 0
+}
+
+func (x *BlockSignatures) ClearFileSize() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_FileSize = 0
+}
+
+type BlockSignatures_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	BlockSize *int64
+	FileSize  *int64
+	Blocks    []*BlockSignature
+}
+
+func (b0 BlockSignatures_builder) Build() *BlockSignatures {
+	m0 := &BlockSignatures{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.BlockSize != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 3)
+		x.xxx_hidden_BlockSize = *b.BlockSize
+	}
+	if b.FileSize != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 3)
+		x.xxx_hidden_FileSize = *b.FileSize
+	}
+	x.xxx_hidden_Blocks = &b.Blocks
+	return m0
+}
+
+// ChunkAck 块确认，服务器对文件块的响应
+type ChunkAck struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Chunk       int64                  `protobuf:"varint,1,opt,name=chunk"`
+	xxx_hidden_Received    bool                   `protobuf:"varint,2,opt,name=received"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *ChunkAck) Reset() {
+	*x = ChunkAck{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChunkAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChunkAck) ProtoMessage() {}
+
+func (x *ChunkAck) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *ChunkAck) GetChunk() int64 {
+	if x != nil {
+		return x.xxx_hidden_Chunk
+	}
+	return 0
+}
+
+func (x *ChunkAck) GetReceived() bool {
+	if x != nil {
+		return x.xxx_hidden_Received
+	}
+	return false
+}
+
+func (x *ChunkAck) SetChunk(v int64) {
+	x.xxx_hidden_Chunk = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 2)
+}
+
+func (x *ChunkAck) SetReceived(v bool) {
+	x.xxx_hidden_Received = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 2)
+}
+
+func (x *ChunkAck) HasChunk() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *ChunkAck) HasReceived() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *ChunkAck) ClearChunk() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Chunk = 0
+}
+
+func (x *ChunkAck) ClearReceived() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Received = false
+}
+
+type ChunkAck_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Chunk    *int64
+	Received *bool
+}
+
+func (b0 ChunkAck_builder) Build() *ChunkAck {
+	m0 := &ChunkAck{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Chunk != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 2)
+		x.xxx_hidden_Chunk = *b.Chunk
+	}
+	if b.Received != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 2)
+		x.xxx_hidden_Received = *b.Received
+	}
+	return m0
+}
+
+// TransferResult 传输结果，表示上传或下载的最终状态
+type TransferResult struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Status      bool                   `protobuf:"varint,1,opt,name=status"`
+	xxx_hidden_Message     *string                `protobuf:"bytes,2,opt,name=message"`
+	xxx_hidden_TransferId  *string                `protobuf:"bytes,3,opt,name=transfer_id,json=transferId"`
+	xxx_hidden_ErrorCode   ErrorCode              `protobuf:"varint,4,opt,name=error_code,json=errorCode,enum=qmeta.transfer.v1.ErrorCode"`
+	xxx_hidden_Outcome     ConflictOutcome        `protobuf:"varint,5,opt,name=outcome,enum=qmeta.transfer.v1.ConflictOutcome"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *TransferResult) Reset() {
+	*x = TransferResult{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferResult) ProtoMessage() {}
+
+func (x *TransferResult) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *TransferResult) GetStatus() bool {
+	if x != nil {
+		return x.xxx_hidden_Status
+	}
+	return false
+}
+
+func (x *TransferResult) GetMessage() string {
+	if x != nil {
+		if x.xxx_hidden_Message != nil {
+			return *x.xxx_hidden_Message
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *TransferResult) GetTransferId() string {
+	if x != nil {
+		if x.xxx_hidden_TransferId != nil {
+			return *x.xxx_hidden_TransferId
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *TransferResult) GetErrorCode() ErrorCode {
+	if x != nil {
+		if protoimpl.X.Present(&(x.XXX_presence[0]), 3) {
+			return x.xxx_hidden_ErrorCode
+		}
+	}
+	return ErrorCode_ERROR_CODE_UNSPECIFIED
+}
+
+func (x *TransferResult) GetOutcome() ConflictOutcome {
+	if x != nil {
+		if protoimpl.X.Present(&(x.XXX_presence[0]), 4) {
+			return x.xxx_hidden_Outcome
+		}
+	}
+	return ConflictOutcome_CONFLICT_OUTCOME_UNSPECIFIED
+}
+
+func (x *TransferResult) SetStatus(v bool) {
+	x.xxx_hidden_Status = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 5)
+}
+
+func (x *TransferResult) SetMessage(v string) {
+	x.xxx_hidden_Message = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 5)
+}
+
+func (x *TransferResult) SetTransferId(v string) {
+	x.xxx_hidden_TransferId = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 5)
+}
+
+func (x *TransferResult) SetErrorCode(v ErrorCode) {
+	x.xxx_hidden_ErrorCode = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 5)
+}
+
+func (x *TransferResult) SetOutcome(v ConflictOutcome) {
+	x.xxx_hidden_Outcome = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 4, 5)
+}
+
+func (x *TransferResult) HasStatus() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *TransferResult) HasMessage() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *TransferResult) HasTransferId() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
+}
+
+func (x *TransferResult) HasErrorCode() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
+}
+
+func (x *TransferResult) HasOutcome() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 4)
+}
+
+func (x *TransferResult) ClearStatus() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Status = false
+}
+
+func (x *TransferResult) ClearMessage() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Message = nil
+}
+
+func (x *TransferResult) ClearTransferId() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_TransferId = nil
+}
+
+func (x *TransferResult) ClearErrorCode() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
+	x.xxx_hidden_ErrorCode = ErrorCode_ERROR_CODE_UNSPECIFIED
+}
+
+func (x *TransferResult) ClearOutcome() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 4)
+	x.xxx_hidden_Outcome = ConflictOutcome_CONFLICT_OUTCOME_UNSPECIFIED
+}
+
+type TransferResult_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Status  *bool
+	Message *string
+	// transfer_id 本次传输的 ID，与请求头 x-transfer-id 相同，客户端未提供时由服务端生成
+	TransferId *string
+	// error_code 失败时的错误类型
+	ErrorCode *ErrorCode
+	// outcome 流式上传在结尾才确定的冲突结果，如内容相同而跳过
+	Outcome *ConflictOutcome
+}
+
+func (b0 TransferResult_builder) Build() *TransferResult {
+	m0 := &TransferResult{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Status != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 5)
+		x.xxx_hidden_Status = *b.Status
+	}
+	if b.Message != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 5)
+		x.xxx_hidden_Message = b.Message
+	}
+	if b.TransferId != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 5)
+		x.xxx_hidden_TransferId = b.TransferId
+	}
+	if b.ErrorCode != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 5)
+		x.xxx_hidden_ErrorCode = *b.ErrorCode
+	}
+	if b.Outcome != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 4, 5)
+		x.xxx_hidden_Outcome = *b.Outcome
+	}
+	return m0
+}
+
+// DownloadFileRequest 下载文件请求，包含文件标识和块大小，version_id 为空时下载当前版本
+type DownloadFileRequest struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Tag         *string                `protobuf:"bytes,1,opt,name=tag"`
+	xxx_hidden_Name        *string                `protobuf:"bytes,2,opt,name=name"`
+	xxx_hidden_Chunksize   int64                  `protobuf:"varint,3,opt,name=chunksize"`
+	xxx_hidden_VersionId   *string                `protobuf:"bytes,4,opt,name=version_id,json=versionId"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *DownloadFileRequest) Reset() {
+	*x = DownloadFileRequest{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadFileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadFileRequest) ProtoMessage() {}
+
+func (x *DownloadFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *DownloadFileRequest) GetTag() string {
+	if x != nil {
+		if x.xxx_hidden_Tag != nil {
+			return *x.xxx_hidden_Tag
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *DownloadFileRequest) GetName() string {
+	if x != nil {
+		if x.xxx_hidden_Name != nil {
+			return *x.xxx_hidden_Name
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *DownloadFileRequest) GetChunksize() int64 {
+	if x != nil {
+		return x.xxx_hidden_Chunksize
+	}
+	return 0
+}
+
+func (x *DownloadFileRequest) GetVersionId() string {
+	if x != nil {
+		if x.xxx_hidden_VersionId != nil {
+			return *x.xxx_hidden_VersionId
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *DownloadFileRequest) SetTag(v string) {
+	x.xxx_hidden_Tag = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 4)
+}
+
+func (x *DownloadFileRequest) SetName(v string) {
+	x.xxx_hidden_Name = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 4)
+}
+
+func (x *DownloadFileRequest) SetChunksize(v int64) {
+	x.xxx_hidden_Chunksize = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 4)
+}
+
+func (x *DownloadFileRequest) SetVersionId(v string) {
+	x.xxx_hidden_VersionId = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 4)
+}
+
+func (x *DownloadFileRequest) HasTag() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *DownloadFileRequest) HasName() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *DownloadFileRequest) HasChunksize() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
+}
+
+func (x *DownloadFileRequest) HasVersionId() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
+}
+
+func (x *DownloadFileRequest) ClearTag() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Tag = nil
+}
+
+func (x *DownloadFileRequest) ClearName() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Name = nil
+}
+
+func (x *DownloadFileRequest) ClearChunksize() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_Chunksize = 0
+}
+
+func (x *DownloadFileRequest) ClearVersionId() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
+	x.xxx_hidden_VersionId = nil
+}
+
+type DownloadFileRequest_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Tag       *string
+	Name      *string
+	Chunksize *int64
+	VersionId *string
+}
+
+func (b0 DownloadFileRequest_builder) Build() *DownloadFileRequest {
+	m0 := &DownloadFileRequest{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Tag != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 4)
+		x.xxx_hidden_Tag = b.Tag
+	}
+	if b.Name != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 4)
+		x.xxx_hidden_Name = b.Name
+	}
+	if b.Chunksize != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 4)
+		x.xxx_hidden_Chunksize = *b.Chunksize
+	}
+	if b.VersionId != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 4)
+		x.xxx_hidden_VersionId = b.VersionId
+	}
+	return m0
+}
+
+// DownloadFileResponse 下载文件响应，包含文件元数据、块数据和传输结果
+type DownloadFileResponse struct {
+	state              protoimpl.MessageState         `protogen:"opaque.v1"`
+	xxx_hidden_Payload isDownloadFileResponse_Payload `protobuf_oneof:"payload"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *DownloadFileResponse) Reset() {
+	*x = DownloadFileResponse{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadFileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadFileResponse) ProtoMessage() {}
+
+func (x *DownloadFileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *DownloadFileResponse) GetMetadata() *FileMetadata {
+	if x != nil {
+		if x, ok := x.xxx_hidden_Payload.(*downloadFileResponse_Metadata); ok {
+			return x.Metadata
+		}
+	}
+	return nil
+}
+
+func (x *DownloadFileResponse) GetChunk() *ChunkData {
+	if x != nil {
+		if x, ok := x.xxx_hidden_Payload.(*downloadFileResponse_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+func (x *DownloadFileResponse) GetResult() *TransferResult {
+	if x != nil {
+		if x, ok := x.xxx_hidden_Payload.(*downloadFileResponse_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+func (x *DownloadFileResponse) SetMetadata(v *FileMetadata) {
+	if v == nil {
+		x.xxx_hidden_Payload = nil
+		return
+	}
+	x.xxx_hidden_Payload = &downloadFileResponse_Metadata{v}
+}
+
+func (x *DownloadFileResponse) SetChunk(v *ChunkData) {
+	if v == nil {
+		x.xxx_hidden_Payload = nil
+		return
+	}
+	x.xxx_hidden_Payload = &downloadFileResponse_Chunk{v}
+}
+
+func (x *DownloadFileResponse) SetResult(v *TransferResult) {
+	if v == nil {
+		x.xxx_hidden_Payload = nil
+		return
+	}
+	x.xxx_hidden_Payload = &downloadFileResponse_Result{v}
+}
+
+func (x *DownloadFileResponse) HasPayload() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_Payload != nil
+}
+
+func (x *DownloadFileResponse) HasMetadata() bool {
+	if x == nil {
+		return false
+	}
+	_, ok := x.xxx_hidden_Payload.(*downloadFileResponse_Metadata)
+	return ok
+}
+
+func (x *DownloadFileResponse) HasChunk() bool {
+	if x == nil {
+		return false
+	}
+	_, ok := x.xxx_hidden_Payload.(*downloadFileResponse_Chunk)
+	return ok
+}
+
+func (x *DownloadFileResponse) HasResult() bool {
+	if x == nil {
+		return false
+	}
+	_, ok := x.xxx_hidden_Payload.(*downloadFileResponse_Result)
+	return ok
+}
+
+func (x *DownloadFileResponse) ClearPayload() {
+	x.xxx_hidden_Payload = nil
+}
+
+func (x *DownloadFileResponse) ClearMetadata() {
+	if _, ok := x.xxx_hidden_Payload.(*downloadFileResponse_Metadata); ok {
+		x.xxx_hidden_Payload = nil
+	}
+}
+
+func (x *DownloadFileResponse) ClearChunk() {
+	if _, ok := x.xxx_hidden_Payload.(*downloadFileResponse_Chunk); ok {
+		x.xxx_hidden_Payload = nil
+	}
+}
+
+func (x *DownloadFileResponse) ClearResult() {
+	if _, ok := x.xxx_hidden_Payload.(*downloadFileResponse_Result); ok {
+		x.xxx_hidden_Payload = nil
+	}
+}
+
+const DownloadFileResponse_Payload_not_set_case case_DownloadFileResponse_Payload = 0
+const DownloadFileResponse_Metadata_case case_DownloadFileResponse_Payload = 1
+const DownloadFileResponse_Chunk_case case_DownloadFileResponse_Payload = 2
+const DownloadFileResponse_Result_case case_DownloadFileResponse_Payload = 3
+
+func (x *DownloadFileResponse) WhichPayload() case_DownloadFileResponse_Payload {
+	if x == nil {
+		return DownloadFileResponse_Payload_not_set_case
+	}
+	switch x.xxx_hidden_Payload.(type) {
+	case *downloadFileResponse_Metadata:
+		return DownloadFileResponse_Metadata_case
+	case *downloadFileResponse_Chunk:
+		return DownloadFileResponse_Chunk_case
+	case *downloadFileResponse_Result:
+		return DownloadFileResponse_Result_case
+	default:
+		return DownloadFileResponse_Payload_not_set_case
+	}
+}
+
+type DownloadFileResponse_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Fields of oneof xxx_hidden_Payload:
+	Metadata *FileMetadata
+	Chunk    *ChunkData
+	Result   *TransferResult
+	// -- end of xxx_hidden_Payload
+}
+
+func (b0 DownloadFileResponse_builder) Build() *DownloadFileResponse {
+	m0 := &DownloadFileResponse{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Metadata != nil {
+		x.xxx_hidden_Payload = &downloadFileResponse_Metadata{b.Metadata}
+	}
+	if b.Chunk != nil {
+		x.xxx_hidden_Payload = &downloadFileResponse_Chunk{b.Chunk}
+	}
+	if b.Result != nil {
+		x.xxx_hidden_Payload = &downloadFileResponse_Result{b.Result}
+	}
+	return m0
+}
+
+type case_DownloadFileResponse_Payload protoreflect.FieldNumber
+
+func (x case_DownloadFileResponse_Payload) String() string {
+	md := file_qmeta_transfer_v1_transfer_proto_msgTypes[15].Descriptor()
+	if x == 0 {
+		return "not set"
+	}
+	return protoimpl.X.MessageFieldStringOf(md, protoreflect.FieldNumber(x))
+}
+
+type isDownloadFileResponse_Payload interface {
+	isDownloadFileResponse_Payload()
+}
+
+type downloadFileResponse_Metadata struct {
+	Metadata *FileMetadata `protobuf:"bytes,1,opt,name=metadata,oneof"`
+}
+
+type downloadFileResponse_Chunk struct {
+	Chunk *ChunkData `protobuf:"bytes,2,opt,name=chunk,oneof"`
+}
+
+type downloadFileResponse_Result struct {
+	Result *TransferResult `protobuf:"bytes,3,opt,name=result,oneof"`
+}
+
+func (*downloadFileResponse_Metadata) isDownloadFileResponse_Payload() {}
+
+func (*downloadFileResponse_Chunk) isDownloadFileResponse_Payload() {}
+
+func (*downloadFileResponse_Result) isDownloadFileResponse_Payload() {}
+
+// FileVersion 文件历史版本，version_id 为归档时间
+type FileVersion struct {
+	state                   protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_VersionId    *string                `protobuf:"bytes,1,opt,name=version_id,json=versionId"`
+	xxx_hidden_Size         int64                  `protobuf:"varint,2,opt,name=size"`
+	xxx_hidden_Hash         *string                `protobuf:"bytes,3,opt,name=hash"`
+	xxx_hidden_ModifiedTime int64                  `protobuf:"varint,4,opt,name=modified_time,json=modifiedTime"`
+	XXX_raceDetectHookData  protoimpl.RaceDetectHookData
+	XXX_presence            [1]uint32
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *FileVersion) Reset() {
+	*x = FileVersion{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileVersion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileVersion) ProtoMessage() {}
+
+func (x *FileVersion) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *FileVersion) GetVersionId() string {
+	if x != nil {
+		if x.xxx_hidden_VersionId != nil {
+			return *x.xxx_hidden_VersionId
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *FileVersion) GetSize() int64 {
+	if x != nil {
+		return x.xxx_hidden_Size
+	}
+	return 0
+}
+
+func (x *FileVersion) GetHash() string {
+	if x != nil {
+		if x.xxx_hidden_Hash != nil {
+			return *x.xxx_hidden_Hash
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *FileVersion) GetModifiedTime() int64 {
+	if x != nil {
+		return x.xxx_hidden_ModifiedTime
+	}
+	return 0
+}
+
+func (x *FileVersion) SetVersionId(v string) {
+	x.xxx_hidden_VersionId = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 4)
+}
+
+func (x *FileVersion) SetSize(v int64) {
+	x.xxx_hidden_Size = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 4)
+}
+
+func (x *FileVersion) SetHash(v string) {
+	x.xxx_hidden_Hash = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 4)
+}
+
+func (x *FileVersion) SetModifiedTime(v int64) {
+	x.xxx_hidden_ModifiedTime = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 4)
+}
+
+func (x *FileVersion) HasVersionId() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *FileVersion) HasSize() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *FileVersion) HasHash() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
+}
+
+func (x *FileVersion) HasModifiedTime() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
+}
+
+func (x *FileVersion) ClearVersionId() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_VersionId = nil
+}
+
+func (x *FileVersion) ClearSize() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Size = 0
+}
+
+func (x *FileVersion) ClearHash() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_Hash = nil
+}
+
+func (x *FileVersion) ClearModifiedTime() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
+	x.xxx_hidden_ModifiedTime = 0
+}
+
+type FileVersion_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	VersionId    *string
+	Size         *int64
+	Hash         *string
+	ModifiedTime *int64
+}
+
+func (b0 FileVersion_builder) Build() *FileVersion {
+	m0 := &FileVersion{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.VersionId != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 4)
+		x.xxx_hidden_VersionId = b.VersionId
+	}
+	if b.Size != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 4)
+		x.xxx_hidden_Size = *b.Size
+	}
+	if b.Hash != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 4)
+		x.xxx_hidden_Hash = b.Hash
+	}
+	if b.ModifiedTime != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 4)
+		x.xxx_hidden_ModifiedTime = *b.ModifiedTime
+	}
+	return m0
+}
+
+// ListFileItem 列出文件项，包含文件名、大小、哈希值、修改时间和历史版本
+type ListFileItem struct {
+	state                   protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Name         *string                `protobuf:"bytes,1,opt,name=name"`
+	xxx_hidden_Size         int64                  `protobuf:"varint,2,opt,name=size"`
+	xxx_hidden_Hash         *string                `protobuf:"bytes,3,opt,name=hash"`
+	xxx_hidden_ModifiedTime int64                  `protobuf:"varint,4,opt,name=modified_time,json=modifiedTime"`
+	xxx_hidden_Versions     *[]*FileVersion        `protobuf:"bytes,5,rep,name=versions"`
+	XXX_raceDetectHookData  protoimpl.RaceDetectHookData
+	XXX_presence            [1]uint32
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *ListFileItem) Reset() {
+	*x = ListFileItem{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFileItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFileItem) ProtoMessage() {}
+
+func (x *ListFileItem) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *ListFileItem) GetName() string {
+	if x != nil {
+		if x.xxx_hidden_Name != nil {
+			return *x.xxx_hidden_Name
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *ListFileItem) GetSize() int64 {
+	if x != nil {
+		return x.xxx_hidden_Size
+	}
+	return 0
+}
+
+func (x *ListFileItem) GetHash() string {
+	if x != nil {
+		if x.xxx_hidden_Hash != nil {
+			return *x.xxx_hidden_Hash
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *ListFileItem) GetModifiedTime() int64 {
+	if x != nil {
+		return x.xxx_hidden_ModifiedTime
+	}
+	return 0
+}
+
+func (x *ListFileItem) GetVersions() []*FileVersion {
+	if x != nil {
+		if x.xxx_hidden_Versions != nil {
+			return *x.xxx_hidden_Versions
+		}
+	}
+	return nil
+}
+
+func (x *ListFileItem) SetName(v string) {
+	x.xxx_hidden_Name = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 5)
+}
+
+func (x *ListFileItem) SetSize(v int64) {
+	x.xxx_hidden_Size = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 5)
+}
+
+func (x *ListFileItem) SetHash(v string) {
+	x.xxx_hidden_Hash = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 5)
+}
+
+func (x *ListFileItem) SetModifiedTime(v int64) {
+	x.xxx_hidden_ModifiedTime = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 5)
+}
+
+func (x *ListFileItem) SetVersions(v []*FileVersion) {
+	x.xxx_hidden_Versions = &v
+}
+
+func (x *ListFileItem) HasName() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *ListFileItem) HasSize() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *ListFileItem) HasHash() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
+}
+
+func (x *ListFileItem) HasModifiedTime() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
+}
+
+func (x *ListFileItem) ClearName() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Name = nil
+}
+
+func (x *ListFileItem) ClearSize() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Size = 0
+}
+
+func (x *ListFileItem) ClearHash() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_Hash = nil
+}
+
+func (x *ListFileItem) ClearModifiedTime() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
+	x.xxx_hidden_ModifiedTime = 0
+}
+
+type ListFileItem_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Name         *string
+	Size         *int64
+	Hash         *string
+	ModifiedTime *int64
+	Versions     []*FileVersion
+}
+
+func (b0 ListFileItem_builder) Build() *ListFileItem {
+	m0 := &ListFileItem{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Name != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 5)
+		x.xxx_hidden_Name = b.Name
+	}
+	if b.Size != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 5)
+		x.xxx_hidden_Size = *b.Size
+	}
+	if b.Hash != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 5)
+		x.xxx_hidden_Hash = b.Hash
+	}
+	if b.ModifiedTime != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 5)
+		x.xxx_hidden_ModifiedTime = *b.ModifiedTime
+	}
+	x.xxx_hidden_Versions = &b.Versions
+	return m0
+}
+
+// ListFilesRequest 列出文件请求，包含文件标签，versions 为 true 时返回历史版本
+type ListFilesRequest struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Tag         *string                `protobuf:"bytes,1,opt,name=tag"`
+	xxx_hidden_Versions    bool                   `protobuf:"varint,2,opt,name=versions"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *ListFilesRequest) Reset() {
+	*x = ListFilesRequest{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFilesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFilesRequest) ProtoMessage() {}
+
+func (x *ListFilesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *ListFilesRequest) GetTag() string {
+	if x != nil {
+		if x.xxx_hidden_Tag != nil {
+			return *x.xxx_hidden_Tag
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *ListFilesRequest) GetVersions() bool {
+	if x != nil {
+		return x.xxx_hidden_Versions
+	}
+	return false
+}
+
+func (x *ListFilesRequest) SetTag(v string) {
+	x.xxx_hidden_Tag = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 2)
+}
+
+func (x *ListFilesRequest) SetVersions(v bool) {
+	x.xxx_hidden_Versions = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 2)
+}
+
+func (x *ListFilesRequest) HasTag() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *ListFilesRequest) HasVersions() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *ListFilesRequest) ClearTag() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Tag = nil
+}
+
+func (x *ListFilesRequest) ClearVersions() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Versions = false
+}
+
+type ListFilesRequest_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Tag      *string
+	Versions *bool
+}
+
+func (b0 ListFilesRequest_builder) Build() *ListFilesRequest {
+	m0 := &ListFilesRequest{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Tag != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 2)
+		x.xxx_hidden_Tag = b.Tag
+	}
+	if b.Versions != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 2)
+		x.xxx_hidden_Versions = *b.Versions
+	}
+	return m0
+}
+
+// ListFilesResponse 列出文件响应，包含状态、消息和文件列表
+type ListFilesResponse struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Status      bool                   `protobuf:"varint,1,opt,name=status"`
+	xxx_hidden_Message     *string                `protobuf:"bytes,2,opt,name=message"`
+	xxx_hidden_Files       *[]*ListFileItem       `protobuf:"bytes,3,rep,name=files"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *ListFilesResponse) Reset() {
+	*x = ListFilesResponse{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFilesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFilesResponse) ProtoMessage() {}
+
+func (x *ListFilesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *ListFilesResponse) GetStatus() bool {
+	if x != nil {
+		return x.xxx_hidden_Status
+	}
+	return false
+}
+
+func (x *ListFilesResponse) GetMessage() string {
+	if x != nil {
+		if x.xxx_hidden_Message != nil {
+			return *x.xxx_hidden_Message
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *ListFilesResponse) GetFiles() []*ListFileItem {
+	if x != nil {
+		if x.xxx_hidden_Files != nil {
+			return *x.xxx_hidden_Files
+		}
+	}
+	return nil
+}
+
+func (x *ListFilesResponse) SetStatus(v bool) {
+	x.xxx_hidden_Status = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 3)
+}
+
+func (x *ListFilesResponse) SetMessage(v string) {
+	x.xxx_hidden_Message = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 3)
+}
+
+func (x *ListFilesResponse) SetFiles(v []*ListFileItem) {
+	x.xxx_hidden_Files = &v
+}
+
+func (x *ListFilesResponse) HasStatus() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *ListFilesResponse) HasMessage() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *ListFilesResponse) ClearStatus() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Status = false
+}
+
+func (x *ListFilesResponse) ClearMessage() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Message = nil
+}
+
+type ListFilesResponse_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Status  *bool
+	Message *string
+	Files   []*ListFileItem
+}
+
+func (b0 ListFilesResponse_builder) Build() *ListFilesResponse {
+	m0 := &ListFilesResponse{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Status != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 3)
+		x.xxx_hidden_Status = *b.Status
+	}
+	if b.Message != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 3)
+		x.xxx_hidden_Message = b.Message
+	}
+	x.xxx_hidden_Files = &b.Files
+	return m0
+}
+
+// TagUsage 标签存储使用情况，quota 为 0 表示不限制
+type TagUsage struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Tag         *string                `protobuf:"bytes,1,opt,name=tag"`
+	xxx_hidden_Used        int64                  `protobuf:"varint,2,opt,name=used"`
+	xxx_hidden_Files       int64                  `protobuf:"varint,3,opt,name=files"`
+	xxx_hidden_Quota       int64                  `protobuf:"varint,4,opt,name=quota"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *TagUsage) Reset() {
+	*x = TagUsage{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TagUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TagUsage) ProtoMessage() {}
+
+func (x *TagUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *TagUsage) GetTag() string {
+	if x != nil {
+		if x.xxx_hidden_Tag != nil {
+			return *x.xxx_hidden_Tag
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *TagUsage) GetUsed() int64 {
+	if x != nil {
+		return x.xxx_hidden_Used
+	}
+	return 0
+}
+
+func (x *TagUsage) GetFiles() int64 {
+	if x != nil {
+		return x.xxx_hidden_Files
+	}
+	return 0
+}
+
+func (x *TagUsage) GetQuota() int64 {
+	if x != nil {
+		return x.xxx_hidden_Quota
+	}
+	return 0
+}
+
+func (x *TagUsage) SetTag(v string) {
+	x.xxx_hidden_Tag = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 4)
+}
+
+func (x *TagUsage) SetUsed(v int64) {
+	x.xxx_hidden_Used = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 4)
+}
+
+func (x *TagUsage) SetFiles(v int64) {
+	x.xxx_hidden_Files = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 4)
+}
+
+func (x *TagUsage) SetQuota(v int64) {
+	x.xxx_hidden_Quota = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 4)
+}
+
+func (x *TagUsage) HasTag() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *TagUsage) HasUsed() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
 }
 
-type case_UploadFileResponse_Payload protoreflect.FieldNumber
+func (x *TagUsage) HasFiles() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
+}
 
-func (x case_UploadFileResponse_Payload) String() string {
-	md := file_qmeta_transfer_v1_transfer_proto_msgTypes[5].Descriptor()
-	if x == 0 {
-		return "not set"
+func (x *TagUsage) HasQuota() bool {
+	if x == nil {
+		return false
 	}
-	return protoimpl.X.MessageFieldStringOf(md, protoreflect.FieldNumber(x))
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
 }
 
-type isUploadFileResponse_Payload interface {
-	isUploadFileResponse_Payload()
+func (x *TagUsage) ClearTag() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Tag = nil
 }
 
-type uploadFileResponse_MetaAck struct {
-	MetaAck *MetaAck `protobuf:"bytes,1,opt,name=meta_ack,json=metaAck,oneof"`
+func (x *TagUsage) ClearUsed() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Used = 0
 }
 
-type uploadFileResponse_ChunkAck struct {
-	ChunkAck *ChunkAck `protobuf:"bytes,2,opt,name=chunk_ack,json=chunkAck,oneof"`
+func (x *TagUsage) ClearFiles() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_Files = 0
 }
 
-type uploadFileResponse_Result struct {
-	Result *TransferResult `protobuf:"bytes,3,opt,name=result,oneof"`
+func (x *TagUsage) ClearQuota() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
+	x.xxx_hidden_Quota = 0
 }
 
-func (*uploadFileResponse_MetaAck) isUploadFileResponse_Payload() {}
+type TagUsage_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-func (*uploadFileResponse_ChunkAck) isUploadFileResponse_Payload() {}
+	Tag   *string
+	Used  *int64
+	Files *int64
+	Quota *int64
+}
 
-func (*uploadFileResponse_Result) isUploadFileResponse_Payload() {}
+func (b0 TagUsage_builder) Build() *TagUsage {
+	m0 := &TagUsage{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Tag != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 4)
+		x.xxx_hidden_Tag = b.Tag
+	}
+	if b.Used != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 4)
+		x.xxx_hidden_Used = *b.Used
+	}
+	if b.Files != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 4)
+		x.xxx_hidden_Files = *b.Files
+	}
+	if b.Quota != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 4)
+		x.xxx_hidden_Quota = *b.Quota
+	}
+	return m0
+}
 
-// MetaAck 元数据确认，服务器对文件元数据的响应
-type MetaAck struct {
+// StorageStatsRequest 存储统计请求，标签为空时返回所有标签
+type StorageStatsRequest struct {
 	state                  protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_AllowUpload bool                   `protobuf:"varint,1,opt,name=allow_upload,json=allowUpload"`
-	xxx_hidden_Message     *string                `protobuf:"bytes,2,opt,name=message"`
+	xxx_hidden_Tag         *string                `protobuf:"bytes,1,opt,name=tag"`
 	XXX_raceDetectHookData protoimpl.RaceDetectHookData
 	XXX_presence           [1]uint32
 	unknownFields          protoimpl.UnknownFields
 	sizeCache              protoimpl.SizeCache
 }
 
-func (x *MetaAck) Reset() {
-	*x = MetaAck{}
-	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[6]
+func (x *StorageStatsRequest) Reset() {
+	*x = StorageStatsRequest{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MetaAck) String() string {
+func (x *StorageStatsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MetaAck) ProtoMessage() {}
+func (*StorageStatsRequest) ProtoMessage() {}
 
-func (x *MetaAck) ProtoReflect() protoreflect.Message {
-	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[6]
+func (x *StorageStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -940,105 +3635,79 @@ func (x *MetaAck) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *MetaAck) GetAllowUpload() bool {
-	if x != nil {
-		return x.xxx_hidden_AllowUpload
-	}
-	return false
-}
-
-func (x *MetaAck) GetMessage() string {
+func (x *StorageStatsRequest) GetTag() string {
 	if x != nil {
-		if x.xxx_hidden_Message != nil {
-			return *x.xxx_hidden_Message
+		if x.xxx_hidden_Tag != nil {
+			return *x.xxx_hidden_Tag
 		}
 		return ""
 	}
 	return ""
 }
 
-func (x *MetaAck) SetAllowUpload(v bool) {
-	x.xxx_hidden_AllowUpload = v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 2)
-}
-
-func (x *MetaAck) SetMessage(v string) {
-	x.xxx_hidden_Message = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 2)
+func (x *StorageStatsRequest) SetTag(v string) {
+	x.xxx_hidden_Tag = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 1)
 }
 
-func (x *MetaAck) HasAllowUpload() bool {
+func (x *StorageStatsRequest) HasTag() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
 }
 
-func (x *MetaAck) HasMessage() bool {
-	if x == nil {
-		return false
-	}
-	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
-}
-
-func (x *MetaAck) ClearAllowUpload() {
+func (x *StorageStatsRequest) ClearTag() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
-	x.xxx_hidden_AllowUpload = false
-}
-
-func (x *MetaAck) ClearMessage() {
-	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
-	x.xxx_hidden_Message = nil
+	x.xxx_hidden_Tag = nil
 }
 
-type MetaAck_builder struct {
+type StorageStatsRequest_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-	AllowUpload *bool
-	Message     *string
+	Tag *string
 }
 
-func (b0 MetaAck_builder) Build() *MetaAck {
-	m0 := &MetaAck{}
+func (b0 StorageStatsRequest_builder) Build() *StorageStatsRequest {
+	m0 := &StorageStatsRequest{}
 	b, x := &b0, m0
 	_, _ = b, x
-	if b.AllowUpload != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 2)
-		x.xxx_hidden_AllowUpload = *b.AllowUpload
-	}
-	if b.Message != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 2)
-		x.xxx_hidden_Message = b.Message
+	if b.Tag != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 1)
+		x.xxx_hidden_Tag = b.Tag
 	}
 	return m0
 }
 
-// ChunkAck 块确认，服务器对文件块的响应
-type ChunkAck struct {
+// StorageStatsResponse 存储统计响应，包含磁盘容量和各标签使用情况
+type StorageStatsResponse struct {
 	state                  protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_Chunk       int64                  `protobuf:"varint,1,opt,name=chunk"`
-	xxx_hidden_Received    bool                   `protobuf:"varint,2,opt,name=received"`
+	xxx_hidden_Status      bool                   `protobuf:"varint,1,opt,name=status"`
+	xxx_hidden_Message     *string                `protobuf:"bytes,2,opt,name=message"`
+	xxx_hidden_DiskTotal   int64                  `protobuf:"varint,3,opt,name=disk_total,json=diskTotal"`
+	xxx_hidden_DiskFree    int64                  `protobuf:"varint,4,opt,name=disk_free,json=diskFree"`
+	xxx_hidden_Tags        *[]*TagUsage           `protobuf:"bytes,5,rep,name=tags"`
 	XXX_raceDetectHookData protoimpl.RaceDetectHookData
 	XXX_presence           [1]uint32
 	unknownFields          protoimpl.UnknownFields
 	sizeCache              protoimpl.SizeCache
 }
 
-func (x *ChunkAck) Reset() {
-	*x = ChunkAck{}
-	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[7]
+func (x *StorageStatsResponse) Reset() {
+	*x = StorageStatsResponse{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[22]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ChunkAck) String() string {
+func (x *StorageStatsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ChunkAck) ProtoMessage() {}
+func (*StorageStatsResponse) ProtoMessage() {}
 
-func (x *ChunkAck) ProtoReflect() protoreflect.Message {
-	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[7]
+func (x *StorageStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[22]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1049,102 +3718,178 @@ func (x *ChunkAck) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *ChunkAck) GetChunk() int64 {
+func (x *StorageStatsResponse) GetStatus() bool {
 	if x != nil {
-		return x.xxx_hidden_Chunk
+		return x.xxx_hidden_Status
+	}
+	return false
+}
+
+func (x *StorageStatsResponse) GetMessage() string {
+	if x != nil {
+		if x.xxx_hidden_Message != nil {
+			return *x.xxx_hidden_Message
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *StorageStatsResponse) GetDiskTotal() int64 {
+	if x != nil {
+		return x.xxx_hidden_DiskTotal
 	}
 	return 0
 }
 
-func (x *ChunkAck) GetReceived() bool {
+func (x *StorageStatsResponse) GetDiskFree() int64 {
 	if x != nil {
-		return x.xxx_hidden_Received
+		return x.xxx_hidden_DiskFree
 	}
-	return false
+	return 0
 }
 
-func (x *ChunkAck) SetChunk(v int64) {
-	x.xxx_hidden_Chunk = v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 2)
+func (x *StorageStatsResponse) GetTags() []*TagUsage {
+	if x != nil {
+		if x.xxx_hidden_Tags != nil {
+			return *x.xxx_hidden_Tags
+		}
+	}
+	return nil
 }
 
-func (x *ChunkAck) SetReceived(v bool) {
-	x.xxx_hidden_Received = v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 2)
+func (x *StorageStatsResponse) SetStatus(v bool) {
+	x.xxx_hidden_Status = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 5)
 }
 
-func (x *ChunkAck) HasChunk() bool {
+func (x *StorageStatsResponse) SetMessage(v string) {
+	x.xxx_hidden_Message = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 5)
+}
+
+func (x *StorageStatsResponse) SetDiskTotal(v int64) {
+	x.xxx_hidden_DiskTotal = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 5)
+}
+
+func (x *StorageStatsResponse) SetDiskFree(v int64) {
+	x.xxx_hidden_DiskFree = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 5)
+}
+
+func (x *StorageStatsResponse) SetTags(v []*TagUsage) {
+	x.xxx_hidden_Tags = &v
+}
+
+func (x *StorageStatsResponse) HasStatus() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
 }
 
-func (x *ChunkAck) HasReceived() bool {
+func (x *StorageStatsResponse) HasMessage() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
 }
 
-func (x *ChunkAck) ClearChunk() {
+func (x *StorageStatsResponse) HasDiskTotal() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
+}
+
+func (x *StorageStatsResponse) HasDiskFree() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
+}
+
+func (x *StorageStatsResponse) ClearStatus() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
-	x.xxx_hidden_Chunk = 0
+	x.xxx_hidden_Status = false
 }
 
-func (x *ChunkAck) ClearReceived() {
+func (x *StorageStatsResponse) ClearMessage() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
-	x.xxx_hidden_Received = false
+	x.xxx_hidden_Message = nil
 }
 
-type ChunkAck_builder struct {
+func (x *StorageStatsResponse) ClearDiskTotal() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_DiskTotal = 0
+}
+
+func (x *StorageStatsResponse) ClearDiskFree() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
+	x.xxx_hidden_DiskFree = 0
+}
+
+type StorageStatsResponse_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-	Chunk    *int64
-	Received *bool
+	Status    *bool
+	Message   *string
+	DiskTotal *int64
+	DiskFree  *int64
+	Tags      []*TagUsage
 }
 
-func (b0 ChunkAck_builder) Build() *ChunkAck {
-	m0 := &ChunkAck{}
+func (b0 StorageStatsResponse_builder) Build() *StorageStatsResponse {
+	m0 := &StorageStatsResponse{}
 	b, x := &b0, m0
 	_, _ = b, x
-	if b.Chunk != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 2)
-		x.xxx_hidden_Chunk = *b.Chunk
+	if b.Status != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 5)
+		x.xxx_hidden_Status = *b.Status
 	}
-	if b.Received != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 2)
-		x.xxx_hidden_Received = *b.Received
+	if b.Message != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 5)
+		x.xxx_hidden_Message = b.Message
 	}
+	if b.DiskTotal != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 5)
+		x.xxx_hidden_DiskTotal = *b.DiskTotal
+	}
+	if b.DiskFree != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 5)
+		x.xxx_hidden_DiskFree = *b.DiskFree
+	}
+	x.xxx_hidden_Tags = &b.Tags
 	return m0
 }
 
-// TransferResult 传输结果，表示上传或下载的最终状态
-type TransferResult struct {
+// DeleteFileRequest 删除文件请求，包含文件标签和文件名
+type DeleteFileRequest struct {
 	state                  protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_Status      bool                   `protobuf:"varint,1,opt,name=status"`
-	xxx_hidden_Message     *string                `protobuf:"bytes,2,opt,name=message"`
+	xxx_hidden_Tag         *string                `protobuf:"bytes,1,opt,name=tag"`
+	xxx_hidden_Name        *string                `protobuf:"bytes,2,opt,name=name"`
 	XXX_raceDetectHookData protoimpl.RaceDetectHookData
 	XXX_presence           [1]uint32
 	unknownFields          protoimpl.UnknownFields
 	sizeCache              protoimpl.SizeCache
 }
 
-func (x *TransferResult) Reset() {
-	*x = TransferResult{}
-	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[8]
+func (x *DeleteFileRequest) Reset() {
+	*x = DeleteFileRequest{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *TransferResult) String() string {
+func (x *DeleteFileRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TransferResult) ProtoMessage() {}
+func (*DeleteFileRequest) ProtoMessage() {}
 
-func (x *TransferResult) ProtoReflect() protoreflect.Message {
-	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[8]
+func (x *DeleteFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1155,106 +3900,109 @@ func (x *TransferResult) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *TransferResult) GetStatus() bool {
+func (x *DeleteFileRequest) GetTag() string {
 	if x != nil {
-		return x.xxx_hidden_Status
+		if x.xxx_hidden_Tag != nil {
+			return *x.xxx_hidden_Tag
+		}
+		return ""
 	}
-	return false
+	return ""
 }
 
-func (x *TransferResult) GetMessage() string {
+func (x *DeleteFileRequest) GetName() string {
 	if x != nil {
-		if x.xxx_hidden_Message != nil {
-			return *x.xxx_hidden_Message
+		if x.xxx_hidden_Name != nil {
+			return *x.xxx_hidden_Name
 		}
 		return ""
 	}
 	return ""
 }
 
-func (x *TransferResult) SetStatus(v bool) {
-	x.xxx_hidden_Status = v
+func (x *DeleteFileRequest) SetTag(v string) {
+	x.xxx_hidden_Tag = &v
 	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 2)
 }
 
-func (x *TransferResult) SetMessage(v string) {
-	x.xxx_hidden_Message = &v
+func (x *DeleteFileRequest) SetName(v string) {
+	x.xxx_hidden_Name = &v
 	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 2)
 }
 
-func (x *TransferResult) HasStatus() bool {
+func (x *DeleteFileRequest) HasTag() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
 }
 
-func (x *TransferResult) HasMessage() bool {
+func (x *DeleteFileRequest) HasName() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
 }
 
-func (x *TransferResult) ClearStatus() {
+func (x *DeleteFileRequest) ClearTag() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
-	x.xxx_hidden_Status = false
+	x.xxx_hidden_Tag = nil
 }
 
-func (x *TransferResult) ClearMessage() {
+func (x *DeleteFileRequest) ClearName() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
-	x.xxx_hidden_Message = nil
+	x.xxx_hidden_Name = nil
 }
 
-type TransferResult_builder struct {
+type DeleteFileRequest_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-	Status  *bool
-	Message *string
+	Tag  *string
+	Name *string
 }
 
-func (b0 TransferResult_builder) Build() *TransferResult {
-	m0 := &TransferResult{}
+func (b0 DeleteFileRequest_builder) Build() *DeleteFileRequest {
+	m0 := &DeleteFileRequest{}
 	b, x := &b0, m0
 	_, _ = b, x
-	if b.Status != nil {
+	if b.Tag != nil {
 		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 2)
-		x.xxx_hidden_Status = *b.Status
+		x.xxx_hidden_Tag = b.Tag
 	}
-	if b.Message != nil {
+	if b.Name != nil {
 		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 2)
-		x.xxx_hidden_Message = b.Message
+		x.xxx_hidden_Name = b.Name
 	}
 	return m0
 }
 
-// DownloadFileRequest 下载文件请求，包含文件标识和块大小
-type DownloadFileRequest struct {
+// DeleteFileResponse 删除文件响应，包含状态和消息
+type DeleteFileResponse struct {
 	state                  protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_Tag         *string                `protobuf:"bytes,1,opt,name=tag"`
-	xxx_hidden_Name        *string                `protobuf:"bytes,2,opt,name=name"`
-	xxx_hidden_Chunksize   int64                  `protobuf:"varint,3,opt,name=chunksize"`
+	xxx_hidden_Status      bool                   `protobuf:"varint,1,opt,name=status"`
+	xxx_hidden_Message     *string                `protobuf:"bytes,2,opt,name=message"`
+	xxx_hidden_ErrorCode   ErrorCode              `protobuf:"varint,3,opt,name=error_code,json=errorCode,enum=qmeta.transfer.v1.ErrorCode"`
 	XXX_raceDetectHookData protoimpl.RaceDetectHookData
 	XXX_presence           [1]uint32
 	unknownFields          protoimpl.UnknownFields
 	sizeCache              protoimpl.SizeCache
 }
 
-func (x *DownloadFileRequest) Reset() {
-	*x = DownloadFileRequest{}
-	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[9]
+func (x *DeleteFileResponse) Reset() {
+	*x = DeleteFileResponse{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DownloadFileRequest) String() string {
+func (x *DeleteFileResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DownloadFileRequest) ProtoMessage() {}
+func (*DeleteFileResponse) ProtoMessage() {}
 
-func (x *DownloadFileRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[9]
+func (x *DeleteFileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1265,134 +4013,133 @@ func (x *DownloadFileRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *DownloadFileRequest) GetTag() string {
+func (x *DeleteFileResponse) GetStatus() bool {
 	if x != nil {
-		if x.xxx_hidden_Tag != nil {
-			return *x.xxx_hidden_Tag
-		}
-		return ""
+		return x.xxx_hidden_Status
 	}
-	return ""
+	return false
 }
 
-func (x *DownloadFileRequest) GetName() string {
+func (x *DeleteFileResponse) GetMessage() string {
 	if x != nil {
-		if x.xxx_hidden_Name != nil {
-			return *x.xxx_hidden_Name
+		if x.xxx_hidden_Message != nil {
+			return *x.xxx_hidden_Message
 		}
 		return ""
 	}
 	return ""
 }
 
-func (x *DownloadFileRequest) GetChunksize() int64 {
+func (x *DeleteFileResponse) GetErrorCode() ErrorCode {
 	if x != nil {
-		return x.xxx_hidden_Chunksize
+		if protoimpl.X.Present(&(x.XXX_presence[0]), 2) {
+			return x.xxx_hidden_ErrorCode
+		}
 	}
-	return 0
+	return ErrorCode_ERROR_CODE_UNSPECIFIED
 }
 
-func (x *DownloadFileRequest) SetTag(v string) {
-	x.xxx_hidden_Tag = &v
+func (x *DeleteFileResponse) SetStatus(v bool) {
+	x.xxx_hidden_Status = v
 	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 3)
 }
 
-func (x *DownloadFileRequest) SetName(v string) {
-	x.xxx_hidden_Name = &v
+func (x *DeleteFileResponse) SetMessage(v string) {
+	x.xxx_hidden_Message = &v
 	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 3)
 }
 
-func (x *DownloadFileRequest) SetChunksize(v int64) {
-	x.xxx_hidden_Chunksize = v
+func (x *DeleteFileResponse) SetErrorCode(v ErrorCode) {
+	x.xxx_hidden_ErrorCode = v
 	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 3)
 }
 
-func (x *DownloadFileRequest) HasTag() bool {
+func (x *DeleteFileResponse) HasStatus() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
 }
 
-func (x *DownloadFileRequest) HasName() bool {
+func (x *DeleteFileResponse) HasMessage() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
 }
 
-func (x *DownloadFileRequest) HasChunksize() bool {
+func (x *DeleteFileResponse) HasErrorCode() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
 }
 
-func (x *DownloadFileRequest) ClearTag() {
+func (x *DeleteFileResponse) ClearStatus() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
-	x.xxx_hidden_Tag = nil
+	x.xxx_hidden_Status = false
 }
 
-func (x *DownloadFileRequest) ClearName() {
+func (x *DeleteFileResponse) ClearMessage() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
-	x.xxx_hidden_Name = nil
+	x.xxx_hidden_Message = nil
 }
 
-func (x *DownloadFileRequest) ClearChunksize() {
+func (x *DeleteFileResponse) ClearErrorCode() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
-	x.xxx_hidden_Chunksize = 0
+	x.xxx_hidden_ErrorCode = ErrorCode_ERROR_CODE_UNSPECIFIED
 }
 
-type DownloadFileRequest_builder struct {
+type DeleteFileResponse_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-	Tag       *string
-	Name      *string
-	Chunksize *int64
+	Status    *bool
+	Message   *string
+	ErrorCode *ErrorCode
 }
 
-func (b0 DownloadFileRequest_builder) Build() *DownloadFileRequest {
-	m0 := &DownloadFileRequest{}
+func (b0 DeleteFileResponse_builder) Build() *DeleteFileResponse {
+	m0 := &DeleteFileResponse{}
 	b, x := &b0, m0
 	_, _ = b, x
-	if b.Tag != nil {
+	if b.Status != nil {
 		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 3)
-		x.xxx_hidden_Tag = b.Tag
+		x.xxx_hidden_Status = *b.Status
 	}
-	if b.Name != nil {
+	if b.Message != nil {
 		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 3)
-		x.xxx_hidden_Name = b.Name
+		x.xxx_hidden_Message = b.Message
 	}
-	if b.Chunksize != nil {
+	if b.ErrorCode != nil {
 		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 3)
-		x.xxx_hidden_Chunksize = *b.Chunksize
+		x.xxx_hidden_ErrorCode = *b.ErrorCode
 	}
 	return m0
 }
 
-// DownloadFileResponse 下载文件响应，包含文件元数据、块数据和传输结果
-type DownloadFileResponse struct {
-	state              protoimpl.MessageState         `protogen:"opaque.v1"`
-	xxx_hidden_Payload isDownloadFileResponse_Payload `protobuf_oneof:"payload"`
-	unknownFields      protoimpl.UnknownFields
-	sizeCache          protoimpl.SizeCache
+// QueryChunksRequest 查询分片请求，包含分片哈希列表
+type QueryChunksRequest struct {
+	state             protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Hashes []string               `protobuf:"bytes,1,rep,name=hashes"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
-func (x *DownloadFileResponse) Reset() {
-	*x = DownloadFileResponse{}
-	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[10]
+func (x *QueryChunksRequest) Reset() {
+	*x = QueryChunksRequest{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DownloadFileResponse) String() string {
+func (x *QueryChunksRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DownloadFileResponse) ProtoMessage() {}
+func (*QueryChunksRequest) ProtoMessage() {}
 
-func (x *DownloadFileResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[10]
+func (x *QueryChunksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1403,217 +4150,187 @@ func (x *DownloadFileResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *DownloadFileResponse) GetMetadata() *FileMetadata {
+func (x *QueryChunksRequest) GetHashes() []string {
 	if x != nil {
-		if x, ok := x.xxx_hidden_Payload.(*downloadFileResponse_Metadata); ok {
-			return x.Metadata
-		}
+		return x.xxx_hidden_Hashes
 	}
 	return nil
 }
 
-func (x *DownloadFileResponse) GetChunk() *ChunkData {
-	if x != nil {
-		if x, ok := x.xxx_hidden_Payload.(*downloadFileResponse_Chunk); ok {
-			return x.Chunk
-		}
-	}
-	return nil
+func (x *QueryChunksRequest) SetHashes(v []string) {
+	x.xxx_hidden_Hashes = v
 }
 
-func (x *DownloadFileResponse) GetResult() *TransferResult {
-	if x != nil {
-		if x, ok := x.xxx_hidden_Payload.(*downloadFileResponse_Result); ok {
-			return x.Result
-		}
-	}
-	return nil
-}
+type QueryChunksRequest_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-func (x *DownloadFileResponse) SetMetadata(v *FileMetadata) {
-	if v == nil {
-		x.xxx_hidden_Payload = nil
-		return
-	}
-	x.xxx_hidden_Payload = &downloadFileResponse_Metadata{v}
+	Hashes []string
 }
 
-func (x *DownloadFileResponse) SetChunk(v *ChunkData) {
-	if v == nil {
-		x.xxx_hidden_Payload = nil
-		return
-	}
-	x.xxx_hidden_Payload = &downloadFileResponse_Chunk{v}
+func (b0 QueryChunksRequest_builder) Build() *QueryChunksRequest {
+	m0 := &QueryChunksRequest{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Hashes = b.Hashes
+	return m0
 }
 
-func (x *DownloadFileResponse) SetResult(v *TransferResult) {
-	if v == nil {
-		x.xxx_hidden_Payload = nil
-		return
-	}
-	x.xxx_hidden_Payload = &downloadFileResponse_Result{v}
+// QueryChunksResponse 查询分片响应，包含服务端缺少的分片哈希
+type QueryChunksResponse struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Status      bool                   `protobuf:"varint,1,opt,name=status"`
+	xxx_hidden_Message     *string                `protobuf:"bytes,2,opt,name=message"`
+	xxx_hidden_Missing     []string               `protobuf:"bytes,3,rep,name=missing"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
 }
 
-func (x *DownloadFileResponse) HasPayload() bool {
-	if x == nil {
-		return false
-	}
-	return x.xxx_hidden_Payload != nil
+func (x *QueryChunksResponse) Reset() {
+	*x = QueryChunksResponse{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *DownloadFileResponse) HasMetadata() bool {
-	if x == nil {
-		return false
-	}
-	_, ok := x.xxx_hidden_Payload.(*downloadFileResponse_Metadata)
-	return ok
+func (x *QueryChunksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *DownloadFileResponse) HasChunk() bool {
-	if x == nil {
-		return false
-	}
-	_, ok := x.xxx_hidden_Payload.(*downloadFileResponse_Chunk)
-	return ok
-}
+func (*QueryChunksResponse) ProtoMessage() {}
 
-func (x *DownloadFileResponse) HasResult() bool {
-	if x == nil {
-		return false
+func (x *QueryChunksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	_, ok := x.xxx_hidden_Payload.(*downloadFileResponse_Result)
-	return ok
-}
-
-func (x *DownloadFileResponse) ClearPayload() {
-	x.xxx_hidden_Payload = nil
+	return mi.MessageOf(x)
 }
 
-func (x *DownloadFileResponse) ClearMetadata() {
-	if _, ok := x.xxx_hidden_Payload.(*downloadFileResponse_Metadata); ok {
-		x.xxx_hidden_Payload = nil
+func (x *QueryChunksResponse) GetStatus() bool {
+	if x != nil {
+		return x.xxx_hidden_Status
 	}
+	return false
 }
 
-func (x *DownloadFileResponse) ClearChunk() {
-	if _, ok := x.xxx_hidden_Payload.(*downloadFileResponse_Chunk); ok {
-		x.xxx_hidden_Payload = nil
+func (x *QueryChunksResponse) GetMessage() string {
+	if x != nil {
+		if x.xxx_hidden_Message != nil {
+			return *x.xxx_hidden_Message
+		}
+		return ""
 	}
+	return ""
 }
 
-func (x *DownloadFileResponse) ClearResult() {
-	if _, ok := x.xxx_hidden_Payload.(*downloadFileResponse_Result); ok {
-		x.xxx_hidden_Payload = nil
+func (x *QueryChunksResponse) GetMissing() []string {
+	if x != nil {
+		return x.xxx_hidden_Missing
 	}
+	return nil
 }
 
-const DownloadFileResponse_Payload_not_set_case case_DownloadFileResponse_Payload = 0
-const DownloadFileResponse_Metadata_case case_DownloadFileResponse_Payload = 1
-const DownloadFileResponse_Chunk_case case_DownloadFileResponse_Payload = 2
-const DownloadFileResponse_Result_case case_DownloadFileResponse_Payload = 3
-
-func (x *DownloadFileResponse) WhichPayload() case_DownloadFileResponse_Payload {
-	if x == nil {
-		return DownloadFileResponse_Payload_not_set_case
-	}
-	switch x.xxx_hidden_Payload.(type) {
-	case *downloadFileResponse_Metadata:
-		return DownloadFileResponse_Metadata_case
-	case *downloadFileResponse_Chunk:
-		return DownloadFileResponse_Chunk_case
-	case *downloadFileResponse_Result:
-		return DownloadFileResponse_Result_case
-	default:
-		return DownloadFileResponse_Payload_not_set_case
-	}
+func (x *QueryChunksResponse) SetStatus(v bool) {
+	x.xxx_hidden_Status = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 3)
 }
 
-type DownloadFileResponse_builder struct {
-	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
-
-	// Fields of oneof xxx_hidden_Payload:
-	Metadata *FileMetadata
-	Chunk    *ChunkData
-	Result   *TransferResult
-	// -- end of xxx_hidden_Payload
+func (x *QueryChunksResponse) SetMessage(v string) {
+	x.xxx_hidden_Message = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 3)
 }
 
-func (b0 DownloadFileResponse_builder) Build() *DownloadFileResponse {
-	m0 := &DownloadFileResponse{}
-	b, x := &b0, m0
-	_, _ = b, x
-	if b.Metadata != nil {
-		x.xxx_hidden_Payload = &downloadFileResponse_Metadata{b.Metadata}
-	}
-	if b.Chunk != nil {
-		x.xxx_hidden_Payload = &downloadFileResponse_Chunk{b.Chunk}
-	}
-	if b.Result != nil {
-		x.xxx_hidden_Payload = &downloadFileResponse_Result{b.Result}
-	}
-	return m0
+func (x *QueryChunksResponse) SetMissing(v []string) {
+	x.xxx_hidden_Missing = v
 }
 
-type case_DownloadFileResponse_Payload protoreflect.FieldNumber
-
-func (x case_DownloadFileResponse_Payload) String() string {
-	md := file_qmeta_transfer_v1_transfer_proto_msgTypes[10].Descriptor()
-	if x == 0 {
-		return "not set"
+func (x *QueryChunksResponse) HasStatus() bool {
+	if x == nil {
+		return false
 	}
-	return protoimpl.X.MessageFieldStringOf(md, protoreflect.FieldNumber(x))
-}
-
-type isDownloadFileResponse_Payload interface {
-	isDownloadFileResponse_Payload()
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
 }
 
-type downloadFileResponse_Metadata struct {
-	Metadata *FileMetadata `protobuf:"bytes,1,opt,name=metadata,oneof"`
+func (x *QueryChunksResponse) HasMessage() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
 }
 
-type downloadFileResponse_Chunk struct {
-	Chunk *ChunkData `protobuf:"bytes,2,opt,name=chunk,oneof"`
+func (x *QueryChunksResponse) ClearStatus() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Status = false
 }
 
-type downloadFileResponse_Result struct {
-	Result *TransferResult `protobuf:"bytes,3,opt,name=result,oneof"`
+func (x *QueryChunksResponse) ClearMessage() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Message = nil
 }
 
-func (*downloadFileResponse_Metadata) isDownloadFileResponse_Payload() {}
+type QueryChunksResponse_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-func (*downloadFileResponse_Chunk) isDownloadFileResponse_Payload() {}
+	Status  *bool
+	Message *string
+	Missing []string
+}
 
-func (*downloadFileResponse_Result) isDownloadFileResponse_Payload() {}
+func (b0 QueryChunksResponse_builder) Build() *QueryChunksResponse {
+	m0 := &QueryChunksResponse{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Status != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 3)
+		x.xxx_hidden_Status = *b.Status
+	}
+	if b.Message != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 3)
+		x.xxx_hidden_Message = b.Message
+	}
+	x.xxx_hidden_Missing = b.Missing
+	return m0
+}
 
-// ListFileItem 列出文件项，包含文件名、大小、哈希值和修改时间
-type ListFileItem struct {
-	state                   protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_Name         *string                `protobuf:"bytes,1,opt,name=name"`
-	xxx_hidden_Size         int64                  `protobuf:"varint,2,opt,name=size"`
-	xxx_hidden_Hash         *string                `protobuf:"bytes,3,opt,name=hash"`
-	xxx_hidden_ModifiedTime int64                  `protobuf:"varint,4,opt,name=modified_time,json=modifiedTime"`
-	XXX_raceDetectHookData  protoimpl.RaceDetectHookData
-	XXX_presence            [1]uint32
-	unknownFields           protoimpl.UnknownFields
-	sizeCache               protoimpl.SizeCache
+// TransferStatus 正在进行的传输，total 为 0 表示大小未知，rate 单位字节每秒
+type TransferStatus struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_TransferId  *string                `protobuf:"bytes,1,opt,name=transfer_id,json=transferId"`
+	xxx_hidden_Op          *string                `protobuf:"bytes,2,opt,name=op"`
+	xxx_hidden_Tag         *string                `protobuf:"bytes,3,opt,name=tag"`
+	xxx_hidden_Name        *string                `protobuf:"bytes,4,opt,name=name"`
+	xxx_hidden_Peer        *string                `protobuf:"bytes,5,opt,name=peer"`
+	xxx_hidden_Done        int64                  `protobuf:"varint,6,opt,name=done"`
+	xxx_hidden_Total       int64                  `protobuf:"varint,7,opt,name=total"`
+	xxx_hidden_Rate        float64                `protobuf:"fixed64,8,opt,name=rate"`
+	xxx_hidden_StartTime   int64                  `protobuf:"varint,9,opt,name=start_time,json=startTime"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
 }
 
-func (x *ListFileItem) Reset() {
-	*x = ListFileItem{}
-	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[11]
+func (x *TransferStatus) Reset() {
+	*x = TransferStatus{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListFileItem) String() string {
+func (x *TransferStatus) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListFileItem) ProtoMessage() {}
+func (*TransferStatus) ProtoMessage() {}
 
-func (x *ListFileItem) ProtoReflect() protoreflect.Message {
-	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[11]
+func (x *TransferStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1624,142 +4341,296 @@ func (x *ListFileItem) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *ListFileItem) GetName() string {
+func (x *TransferStatus) GetTransferId() string {
 	if x != nil {
-		if x.xxx_hidden_Name != nil {
-			return *x.xxx_hidden_Name
+		if x.xxx_hidden_TransferId != nil {
+			return *x.xxx_hidden_TransferId
 		}
 		return ""
 	}
 	return ""
 }
 
-func (x *ListFileItem) GetSize() int64 {
+func (x *TransferStatus) GetOp() string {
 	if x != nil {
-		return x.xxx_hidden_Size
+		if x.xxx_hidden_Op != nil {
+			return *x.xxx_hidden_Op
+		}
+		return ""
 	}
-	return 0
+	return ""
 }
 
-func (x *ListFileItem) GetHash() string {
+func (x *TransferStatus) GetTag() string {
 	if x != nil {
-		if x.xxx_hidden_Hash != nil {
-			return *x.xxx_hidden_Hash
+		if x.xxx_hidden_Tag != nil {
+			return *x.xxx_hidden_Tag
 		}
 		return ""
 	}
 	return ""
 }
 
-func (x *ListFileItem) GetModifiedTime() int64 {
+func (x *TransferStatus) GetName() string {
 	if x != nil {
-		return x.xxx_hidden_ModifiedTime
+		if x.xxx_hidden_Name != nil {
+			return *x.xxx_hidden_Name
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *TransferStatus) GetPeer() string {
+	if x != nil {
+		if x.xxx_hidden_Peer != nil {
+			return *x.xxx_hidden_Peer
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *TransferStatus) GetDone() int64 {
+	if x != nil {
+		return x.xxx_hidden_Done
 	}
 	return 0
 }
 
-func (x *ListFileItem) SetName(v string) {
+func (x *TransferStatus) GetTotal() int64 {
+	if x != nil {
+		return x.xxx_hidden_Total
+	}
+	return 0
+}
+
+func (x *TransferStatus) GetRate() float64 {
+	if x != nil {
+		return x.xxx_hidden_Rate
+	}
+	return 0
+}
+
+func (x *TransferStatus) GetStartTime() int64 {
+	if x != nil {
+		return x.xxx_hidden_StartTime
+	}
+	return 0
+}
+
+func (x *TransferStatus) SetTransferId(v string) {
+	x.xxx_hidden_TransferId = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 9)
+}
+
+func (x *TransferStatus) SetOp(v string) {
+	x.xxx_hidden_Op = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 9)
+}
+
+func (x *TransferStatus) SetTag(v string) {
+	x.xxx_hidden_Tag = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 9)
+}
+
+func (x *TransferStatus) SetName(v string) {
 	x.xxx_hidden_Name = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 4)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 9)
 }
 
-func (x *ListFileItem) SetSize(v int64) {
-	x.xxx_hidden_Size = v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 4)
+func (x *TransferStatus) SetPeer(v string) {
+	x.xxx_hidden_Peer = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 4, 9)
 }
 
-func (x *ListFileItem) SetHash(v string) {
-	x.xxx_hidden_Hash = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 4)
+func (x *TransferStatus) SetDone(v int64) {
+	x.xxx_hidden_Done = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 5, 9)
 }
 
-func (x *ListFileItem) SetModifiedTime(v int64) {
-	x.xxx_hidden_ModifiedTime = v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 4)
+func (x *TransferStatus) SetTotal(v int64) {
+	x.xxx_hidden_Total = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 6, 9)
 }
 
-func (x *ListFileItem) HasName() bool {
+func (x *TransferStatus) SetRate(v float64) {
+	x.xxx_hidden_Rate = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 7, 9)
+}
+
+func (x *TransferStatus) SetStartTime(v int64) {
+	x.xxx_hidden_StartTime = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 8, 9)
+}
+
+func (x *TransferStatus) HasTransferId() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
 }
 
-func (x *ListFileItem) HasSize() bool {
+func (x *TransferStatus) HasOp() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
 }
 
-func (x *ListFileItem) HasHash() bool {
+func (x *TransferStatus) HasTag() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
 }
 
-func (x *ListFileItem) HasModifiedTime() bool {
+func (x *TransferStatus) HasName() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
 }
 
-func (x *ListFileItem) ClearName() {
+func (x *TransferStatus) HasPeer() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 4)
+}
+
+func (x *TransferStatus) HasDone() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 5)
+}
+
+func (x *TransferStatus) HasTotal() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 6)
+}
+
+func (x *TransferStatus) HasRate() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 7)
+}
+
+func (x *TransferStatus) HasStartTime() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 8)
+}
+
+func (x *TransferStatus) ClearTransferId() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
-	x.xxx_hidden_Name = nil
+	x.xxx_hidden_TransferId = nil
 }
 
-func (x *ListFileItem) ClearSize() {
+func (x *TransferStatus) ClearOp() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
-	x.xxx_hidden_Size = 0
+	x.xxx_hidden_Op = nil
 }
 
-func (x *ListFileItem) ClearHash() {
+func (x *TransferStatus) ClearTag() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
-	x.xxx_hidden_Hash = nil
+	x.xxx_hidden_Tag = nil
 }
 
-func (x *ListFileItem) ClearModifiedTime() {
+func (x *TransferStatus) ClearName() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
-	x.xxx_hidden_ModifiedTime = 0
+	x.xxx_hidden_Name = nil
 }
 
-type ListFileItem_builder struct {
+func (x *TransferStatus) ClearPeer() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 4)
+	x.xxx_hidden_Peer = nil
+}
+
+func (x *TransferStatus) ClearDone() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 5)
+	x.xxx_hidden_Done = 0
+}
+
+func (x *TransferStatus) ClearTotal() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 6)
+	x.xxx_hidden_Total = 0
+}
+
+func (x *TransferStatus) ClearRate() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 7)
+	x.xxx_hidden_Rate = 0
+}
+
+func (x *TransferStatus) ClearStartTime() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 8)
+	x.xxx_hidden_StartTime = 0
+}
+
+type TransferStatus_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-	Name         *string
-	Size         *int64
-	Hash         *string
-	ModifiedTime *int64
+	TransferId *string
+	Op         *string
+	Tag        *string
+	Name       *string
+	Peer       *string
+	Done       *int64
+	Total      *int64
+	Rate       *float64
+	StartTime  *int64
 }
 
-func (b0 ListFileItem_builder) Build() *ListFileItem {
-	m0 := &ListFileItem{}
+func (b0 TransferStatus_builder) Build() *TransferStatus {
+	m0 := &TransferStatus{}
 	b, x := &b0, m0
 	_, _ = b, x
+	if b.TransferId != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 9)
+		x.xxx_hidden_TransferId = b.TransferId
+	}
+	if b.Op != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 9)
+		x.xxx_hidden_Op = b.Op
+	}
+	if b.Tag != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 9)
+		x.xxx_hidden_Tag = b.Tag
+	}
 	if b.Name != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 4)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 9)
 		x.xxx_hidden_Name = b.Name
 	}
-	if b.Size != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 4)
-		x.xxx_hidden_Size = *b.Size
+	if b.Peer != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 4, 9)
+		x.xxx_hidden_Peer = b.Peer
 	}
-	if b.Hash != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 4)
-		x.xxx_hidden_Hash = b.Hash
+	if b.Done != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 5, 9)
+		x.xxx_hidden_Done = *b.Done
 	}
-	if b.ModifiedTime != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 4)
-		x.xxx_hidden_ModifiedTime = *b.ModifiedTime
+	if b.Total != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 6, 9)
+		x.xxx_hidden_Total = *b.Total
+	}
+	if b.Rate != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 7, 9)
+		x.xxx_hidden_Rate = *b.Rate
+	}
+	if b.StartTime != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 8, 9)
+		x.xxx_hidden_StartTime = *b.StartTime
 	}
 	return m0
 }
 
-// ListFilesRequest 列出文件请求，包含文件标签
-type ListFilesRequest struct {
+// ListTransfersRequest 查询传输请求，标签为空时返回所有标签
+type ListTransfersRequest struct {
 	state                  protoimpl.MessageState `protogen:"opaque.v1"`
 	xxx_hidden_Tag         *string                `protobuf:"bytes,1,opt,name=tag"`
 	XXX_raceDetectHookData protoimpl.RaceDetectHookData
@@ -1768,21 +4639,21 @@ type ListFilesRequest struct {
 	sizeCache              protoimpl.SizeCache
 }
 
-func (x *ListFilesRequest) Reset() {
-	*x = ListFilesRequest{}
-	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[12]
+func (x *ListTransfersRequest) Reset() {
+	*x = ListTransfersRequest{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[28]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListFilesRequest) String() string {
+func (x *ListTransfersRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListFilesRequest) ProtoMessage() {}
+func (*ListTransfersRequest) ProtoMessage() {}
 
-func (x *ListFilesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[12]
+func (x *ListTransfersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[28]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1793,7 +4664,7 @@ func (x *ListFilesRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *ListFilesRequest) GetTag() string {
+func (x *ListTransfersRequest) GetTag() string {
 	if x != nil {
 		if x.xxx_hidden_Tag != nil {
 			return *x.xxx_hidden_Tag
@@ -1803,31 +4674,31 @@ func (x *ListFilesRequest) GetTag() string {
 	return ""
 }
 
-func (x *ListFilesRequest) SetTag(v string) {
+func (x *ListTransfersRequest) SetTag(v string) {
 	x.xxx_hidden_Tag = &v
 	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 1)
 }
 
-func (x *ListFilesRequest) HasTag() bool {
+func (x *ListTransfersRequest) HasTag() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
 }
 
-func (x *ListFilesRequest) ClearTag() {
+func (x *ListTransfersRequest) ClearTag() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
 	x.xxx_hidden_Tag = nil
 }
 
-type ListFilesRequest_builder struct {
+type ListTransfersRequest_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
 	Tag *string
 }
 
-func (b0 ListFilesRequest_builder) Build() *ListFilesRequest {
-	m0 := &ListFilesRequest{}
+func (b0 ListTransfersRequest_builder) Build() *ListTransfersRequest {
+	m0 := &ListTransfersRequest{}
 	b, x := &b0, m0
 	_, _ = b, x
 	if b.Tag != nil {
@@ -1837,33 +4708,33 @@ func (b0 ListFilesRequest_builder) Build() *ListFilesRequest {
 	return m0
 }
 
-// ListFilesResponse 列出文件响应，包含状态、消息和文件列表
-type ListFilesResponse struct {
+// ListTransfersResponse 查询传输响应，按开始时间排列
+type ListTransfersResponse struct {
 	state                  protoimpl.MessageState `protogen:"opaque.v1"`
 	xxx_hidden_Status      bool                   `protobuf:"varint,1,opt,name=status"`
 	xxx_hidden_Message     *string                `protobuf:"bytes,2,opt,name=message"`
-	xxx_hidden_Files       *[]*ListFileItem       `protobuf:"bytes,3,rep,name=files"`
+	xxx_hidden_Transfers   *[]*TransferStatus     `protobuf:"bytes,3,rep,name=transfers"`
 	XXX_raceDetectHookData protoimpl.RaceDetectHookData
 	XXX_presence           [1]uint32
 	unknownFields          protoimpl.UnknownFields
 	sizeCache              protoimpl.SizeCache
 }
 
-func (x *ListFilesResponse) Reset() {
-	*x = ListFilesResponse{}
-	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[13]
+func (x *ListTransfersResponse) Reset() {
+	*x = ListTransfersResponse{}
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListFilesResponse) String() string {
+func (x *ListTransfersResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListFilesResponse) ProtoMessage() {}
+func (*ListTransfersResponse) ProtoMessage() {}
 
-func (x *ListFilesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[13]
+func (x *ListTransfersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_qmeta_transfer_v1_transfer_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1874,14 +4745,14 @@ func (x *ListFilesResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *ListFilesResponse) GetStatus() bool {
+func (x *ListTransfersResponse) GetStatus() bool {
 	if x != nil {
 		return x.xxx_hidden_Status
 	}
 	return false
 }
 
-func (x *ListFilesResponse) GetMessage() string {
+func (x *ListTransfersResponse) GetMessage() string {
 	if x != nil {
 		if x.xxx_hidden_Message != nil {
 			return *x.xxx_hidden_Message
@@ -1891,63 +4762,63 @@ func (x *ListFilesResponse) GetMessage() string {
 	return ""
 }
 
-func (x *ListFilesResponse) GetFiles() []*ListFileItem {
+func (x *ListTransfersResponse) GetTransfers() []*TransferStatus {
 	if x != nil {
-		if x.xxx_hidden_Files != nil {
-			return *x.xxx_hidden_Files
+		if x.xxx_hidden_Transfers != nil {
+			return *x.xxx_hidden_Transfers
 		}
 	}
 	return nil
 }
 
-func (x *ListFilesResponse) SetStatus(v bool) {
+func (x *ListTransfersResponse) SetStatus(v bool) {
 	x.xxx_hidden_Status = v
 	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 3)
 }
 
-func (x *ListFilesResponse) SetMessage(v string) {
+func (x *ListTransfersResponse) SetMessage(v string) {
 	x.xxx_hidden_Message = &v
 	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 3)
 }
 
-func (x *ListFilesResponse) SetFiles(v []*ListFileItem) {
-	x.xxx_hidden_Files = &v
+func (x *ListTransfersResponse) SetTransfers(v []*TransferStatus) {
+	x.xxx_hidden_Transfers = &v
 }
 
-func (x *ListFilesResponse) HasStatus() bool {
+func (x *ListTransfersResponse) HasStatus() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
 }
 
-func (x *ListFilesResponse) HasMessage() bool {
+func (x *ListTransfersResponse) HasMessage() bool {
 	if x == nil {
 		return false
 	}
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
 }
 
-func (x *ListFilesResponse) ClearStatus() {
+func (x *ListTransfersResponse) ClearStatus() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
 	x.xxx_hidden_Status = false
 }
 
-func (x *ListFilesResponse) ClearMessage() {
+func (x *ListTransfersResponse) ClearMessage() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
 	x.xxx_hidden_Message = nil
 }
 
-type ListFilesResponse_builder struct {
+type ListTransfersResponse_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-	Status  *bool
-	Message *string
-	Files   []*ListFileItem
+	Status    *bool
+	Message   *string
+	Transfers []*TransferStatus
 }
 
-func (b0 ListFilesResponse_builder) Build() *ListFilesResponse {
-	m0 := &ListFilesResponse{}
+func (b0 ListTransfersResponse_builder) Build() *ListTransfersResponse {
+	m0 := &ListTransfersResponse{}
 	b, x := &b0, m0
 	_, _ = b, x
 	if b.Status != nil {
@@ -1958,7 +4829,7 @@ func (b0 ListFilesResponse_builder) Build() *ListFilesResponse {
 		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 3)
 		x.xxx_hidden_Message = b.Message
 	}
-	x.xxx_hidden_Files = &b.Files
+	x.xxx_hidden_Transfers = &b.Transfers
 	return m0
 }
 
@@ -1970,103 +4841,265 @@ const file_qmeta_transfer_v1_transfer_proto_rawDesc = "" +
 	"\x12ServerCheckRequest\x12\x16\n" +
 	"\x06status\x18\x01 \x01(\bR\x06status\"-\n" +
 	"\x13ServerCheckResponse\x12\x16\n" +
-	"\x06status\x18\x01 \x01(\bR\x06status\"\x92\x01\n" +
+	"\x06status\x18\x01 \x01(\bR\x06status\"\xa8\x02\n" +
 	"\fFileMetadata\x12\x10\n" +
 	"\x03tag\x18\x01 \x01(\tR\x03tag\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
 	"\x04size\x18\x03 \x01(\x03R\x04size\x12\x16\n" +
 	"\x06chunks\x18\x04 \x01(\x03R\x06chunks\x12\x1c\n" +
 	"\tchunksize\x18\x05 \x01(\x03R\tchunksize\x12\x12\n" +
-	"\x04hash\x18\x06 \x01(\tR\x04hash\"5\n" +
+	"\x04hash\x18\x06 \x01(\tR\x04hash\x12!\n" +
+	"\fchunk_hashes\x18\a \x03(\tR\vchunkHashes\x12\x14\n" +
+	"\x05delta\x18\b \x01(\bR\x05delta\x12=\n" +
+	"\bconflict\x18\t \x01(\x0e2!.qmeta.transfer.v1.ConflictPolicyR\bconflict\x12\x1c\n" +
+	"\tstreaming\x18\n" +
+	" \x01(\bR\tstreaming\"5\n" +
+	"\vFileTrailer\x12\x12\n" +
+	"\x04size\x18\x01 \x01(\x03R\x04size\x12\x12\n" +
+	"\x04hash\x18\x02 \x01(\tR\x04hash\"I\n" +
 	"\tChunkData\x12\x12\n" +
 	"\x04data\x18\x01 \x01(\fR\x04data\x12\x14\n" +
-	"\x05chunk\x18\x02 \x01(\x03R\x05chunk\"\x93\x01\n" +
+	"\x05chunk\x18\x02 \x01(\x03R\x05chunk\x12\x12\n" +
+	"\x04hash\x18\x03 \x01(\tR\x04hash\"I\n" +
+	"\aDeltaOp\x12\x14\n" +
+	"\x05block\x18\x01 \x01(\x03R\x05block\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\"9\n" +
+	"\tDeltaData\x12,\n" +
+	"\x03ops\x18\x01 \x03(\v2\x1a.qmeta.transfer.v1.DeltaOpR\x03ops\"\x85\x02\n" +
 	"\x11UploadFileRequest\x12=\n" +
 	"\bmetadata\x18\x01 \x01(\v2\x1f.qmeta.transfer.v1.FileMetadataH\x00R\bmetadata\x124\n" +
-	"\x05chunk\x18\x02 \x01(\v2\x1c.qmeta.transfer.v1.ChunkDataH\x00R\x05chunkB\t\n" +
+	"\x05chunk\x18\x02 \x01(\v2\x1c.qmeta.transfer.v1.ChunkDataH\x00R\x05chunk\x124\n" +
+	"\x05delta\x18\x03 \x01(\v2\x1c.qmeta.transfer.v1.DeltaDataH\x00R\x05delta\x12:\n" +
+	"\atrailer\x18\x04 \x01(\v2\x1e.qmeta.transfer.v1.FileTrailerH\x00R\atrailerB\t\n" +
 	"\apayload\"\xd1\x01\n" +
 	"\x12UploadFileResponse\x127\n" +
 	"\bmeta_ack\x18\x01 \x01(\v2\x1a.qmeta.transfer.v1.MetaAckH\x00R\ametaAck\x12:\n" +
 	"\tchunk_ack\x18\x02 \x01(\v2\x1b.qmeta.transfer.v1.ChunkAckH\x00R\bchunkAck\x12;\n" +
 	"\x06result\x18\x03 \x01(\v2!.qmeta.transfer.v1.TransferResultH\x00R\x06resultB\t\n" +
-	"\apayload\"F\n" +
+	"\apayload\"\xde\x02\n" +
 	"\aMetaAck\x12!\n" +
 	"\fallow_upload\x18\x01 \x01(\bR\vallowUpload\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\"<\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1c\n" +
+	"\tcompleted\x18\x03 \x01(\bR\tcompleted\x12B\n" +
+	"\n" +
+	"signatures\x18\x04 \x01(\v2\".qmeta.transfer.v1.BlockSignaturesR\n" +
+	"signatures\x12<\n" +
+	"\aoutcome\x18\x05 \x01(\x0e2\".qmeta.transfer.v1.ConflictOutcomeR\aoutcome\x12\x12\n" +
+	"\x04name\x18\x06 \x01(\tR\x04name\x12%\n" +
+	"\x0equeue_position\x18\a \x01(\x05R\rqueuePosition\x12;\n" +
+	"\n" +
+	"error_code\x18\b \x01(\x0e2\x1c.qmeta.transfer.v1.ErrorCodeR\terrorCode\"<\n" +
+	"\x0eBlockSignature\x12\x12\n" +
+	"\x04weak\x18\x01 \x01(\rR\x04weak\x12\x16\n" +
+	"\x06strong\x18\x02 \x01(\tR\x06strong\"\x88\x01\n" +
+	"\x0fBlockSignatures\x12\x1d\n" +
+	"\n" +
+	"block_size\x18\x01 \x01(\x03R\tblockSize\x12\x1b\n" +
+	"\tfile_size\x18\x02 \x01(\x03R\bfileSize\x129\n" +
+	"\x06blocks\x18\x03 \x03(\v2!.qmeta.transfer.v1.BlockSignatureR\x06blocks\"<\n" +
 	"\bChunkAck\x12\x14\n" +
 	"\x05chunk\x18\x01 \x01(\x03R\x05chunk\x12\x1a\n" +
-	"\breceived\x18\x02 \x01(\bR\breceived\"B\n" +
+	"\breceived\x18\x02 \x01(\bR\breceived\"\xde\x01\n" +
 	"\x0eTransferResult\x12\x16\n" +
 	"\x06status\x18\x01 \x01(\bR\x06status\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\"Y\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1f\n" +
+	"\vtransfer_id\x18\x03 \x01(\tR\n" +
+	"transferId\x12;\n" +
+	"\n" +
+	"error_code\x18\x04 \x01(\x0e2\x1c.qmeta.transfer.v1.ErrorCodeR\terrorCode\x12<\n" +
+	"\aoutcome\x18\x05 \x01(\x0e2\".qmeta.transfer.v1.ConflictOutcomeR\aoutcome\"x\n" +
 	"\x13DownloadFileRequest\x12\x10\n" +
 	"\x03tag\x18\x01 \x01(\tR\x03tag\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1c\n" +
-	"\tchunksize\x18\x03 \x01(\x03R\tchunksize\"\xd3\x01\n" +
+	"\tchunksize\x18\x03 \x01(\x03R\tchunksize\x12\x1d\n" +
+	"\n" +
+	"version_id\x18\x04 \x01(\tR\tversionId\"\xd3\x01\n" +
 	"\x14DownloadFileResponse\x12=\n" +
 	"\bmetadata\x18\x01 \x01(\v2\x1f.qmeta.transfer.v1.FileMetadataH\x00R\bmetadata\x124\n" +
 	"\x05chunk\x18\x02 \x01(\v2\x1c.qmeta.transfer.v1.ChunkDataH\x00R\x05chunk\x12;\n" +
 	"\x06result\x18\x03 \x01(\v2!.qmeta.transfer.v1.TransferResultH\x00R\x06resultB\t\n" +
-	"\apayload\"o\n" +
+	"\apayload\"y\n" +
+	"\vFileVersion\x12\x1d\n" +
+	"\n" +
+	"version_id\x18\x01 \x01(\tR\tversionId\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x12\n" +
+	"\x04hash\x18\x03 \x01(\tR\x04hash\x12#\n" +
+	"\rmodified_time\x18\x04 \x01(\x03R\fmodifiedTime\"\xab\x01\n" +
 	"\fListFileItem\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
 	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x12\n" +
 	"\x04hash\x18\x03 \x01(\tR\x04hash\x12#\n" +
-	"\rmodified_time\x18\x04 \x01(\x03R\fmodifiedTime\"$\n" +
+	"\rmodified_time\x18\x04 \x01(\x03R\fmodifiedTime\x12:\n" +
+	"\bversions\x18\x05 \x03(\v2\x1e.qmeta.transfer.v1.FileVersionR\bversions\"@\n" +
 	"\x10ListFilesRequest\x12\x10\n" +
-	"\x03tag\x18\x01 \x01(\tR\x03tag\"|\n" +
+	"\x03tag\x18\x01 \x01(\tR\x03tag\x12\x1a\n" +
+	"\bversions\x18\x02 \x01(\bR\bversions\"|\n" +
 	"\x11ListFilesResponse\x12\x16\n" +
 	"\x06status\x18\x01 \x01(\bR\x06status\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x125\n" +
-	"\x05files\x18\x03 \x03(\v2\x1f.qmeta.transfer.v1.ListFileItemR\x05files2\x95\x03\n" +
+	"\x05files\x18\x03 \x03(\v2\x1f.qmeta.transfer.v1.ListFileItemR\x05files\"\\\n" +
+	"\bTagUsage\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\tR\x03tag\x12\x12\n" +
+	"\x04used\x18\x02 \x01(\x03R\x04used\x12\x14\n" +
+	"\x05files\x18\x03 \x01(\x03R\x05files\x12\x14\n" +
+	"\x05quota\x18\x04 \x01(\x03R\x05quota\"'\n" +
+	"\x13StorageStatsRequest\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\tR\x03tag\"\xb5\x01\n" +
+	"\x14StorageStatsResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\bR\x06status\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1d\n" +
+	"\n" +
+	"disk_total\x18\x03 \x01(\x03R\tdiskTotal\x12\x1b\n" +
+	"\tdisk_free\x18\x04 \x01(\x03R\bdiskFree\x12/\n" +
+	"\x04tags\x18\x05 \x03(\v2\x1b.qmeta.transfer.v1.TagUsageR\x04tags\"9\n" +
+	"\x11DeleteFileRequest\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\tR\x03tag\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"\x83\x01\n" +
+	"\x12DeleteFileResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\bR\x06status\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12;\n" +
+	"\n" +
+	"error_code\x18\x03 \x01(\x0e2\x1c.qmeta.transfer.v1.ErrorCodeR\terrorCode\",\n" +
+	"\x12QueryChunksRequest\x12\x16\n" +
+	"\x06hashes\x18\x01 \x03(\tR\x06hashes\"a\n" +
+	"\x13QueryChunksResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\bR\x06status\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x18\n" +
+	"\amissing\x18\x03 \x03(\tR\amissing\"\xd8\x01\n" +
+	"\x0eTransferStatus\x12\x1f\n" +
+	"\vtransfer_id\x18\x01 \x01(\tR\n" +
+	"transferId\x12\x0e\n" +
+	"\x02op\x18\x02 \x01(\tR\x02op\x12\x10\n" +
+	"\x03tag\x18\x03 \x01(\tR\x03tag\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12\x12\n" +
+	"\x04peer\x18\x05 \x01(\tR\x04peer\x12\x12\n" +
+	"\x04done\x18\x06 \x01(\x03R\x04done\x12\x14\n" +
+	"\x05total\x18\a \x01(\x03R\x05total\x12\x12\n" +
+	"\x04rate\x18\b \x01(\x01R\x04rate\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\t \x01(\x03R\tstartTime\"(\n" +
+	"\x14ListTransfersRequest\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\tR\x03tag\"\x8a\x01\n" +
+	"\x15ListTransfersResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\bR\x06status\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12?\n" +
+	"\ttransfers\x18\x03 \x03(\v2!.qmeta.transfer.v1.TransferStatusR\ttransfers*\xb9\x01\n" +
+	"\x0eConflictPolicy\x12\x1f\n" +
+	"\x1bCONFLICT_POLICY_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14CONFLICT_POLICY_FAIL\x10\x01\x12%\n" +
+	"!CONFLICT_POLICY_SKIP_IF_IDENTICAL\x10\x02\x12\x1d\n" +
+	"\x19CONFLICT_POLICY_OVERWRITE\x10\x03\x12&\n" +
+	"\"CONFLICT_POLICY_RENAME_WITH_SUFFIX\x10\x04*\xc9\x01\n" +
+	"\x0fConflictOutcome\x12 \n" +
+	"\x1cCONFLICT_OUTCOME_UNSPECIFIED\x10\x00\x12\x19\n" +
+	"\x15CONFLICT_OUTCOME_NONE\x10\x01\x12\x1b\n" +
+	"\x17CONFLICT_OUTCOME_FAILED\x10\x02\x12\x1c\n" +
+	"\x18CONFLICT_OUTCOME_SKIPPED\x10\x03\x12 \n" +
+	"\x1cCONFLICT_OUTCOME_OVERWRITTEN\x10\x04\x12\x1c\n" +
+	"\x18CONFLICT_OUTCOME_RENAMED\x10\x05*\xa4\x01\n" +
+	"\tErrorCode\x12\x1a\n" +
+	"\x16ERROR_CODE_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13ERROR_CODE_REJECTED\x10\x01\x12\x15\n" +
+	"\x11ERROR_CODE_EXISTS\x10\x02\x12\x18\n" +
+	"\x14ERROR_CODE_NOT_FOUND\x10\x03\x12\x18\n" +
+	"\x14ERROR_CODE_INTEGRITY\x10\x04\x12\x17\n" +
+	"\x13ERROR_CODE_INTERNAL\x10\x052\x9b\x06\n" +
 	"\x13FileTransferService\x12^\n" +
 	"\vServerCheck\x12%.qmeta.transfer.v1.ServerCheckRequest\x1a&.qmeta.transfer.v1.ServerCheckResponse\"\x00\x12X\n" +
 	"\tListFiles\x12#.qmeta.transfer.v1.ListFilesRequest\x1a$.qmeta.transfer.v1.ListFilesResponse\"\x00\x12_\n" +
 	"\n" +
 	"UploadFile\x12$.qmeta.transfer.v1.UploadFileRequest\x1a%.qmeta.transfer.v1.UploadFileResponse\"\x00(\x010\x01\x12c\n" +
-	"\fDownloadFile\x12&.qmeta.transfer.v1.DownloadFileRequest\x1a'.qmeta.transfer.v1.DownloadFileResponse\"\x000\x01B\xb6\x01\n" +
-	"\x15com.qmeta.transfer.v1B\rTransferProtoP\x01Z(internal/pb/qmeta/transfer/v1;transferv1\xa2\x02\x03QTX\xaa\x02\x11Qmeta.Transfer.V1\xca\x02\x11Qmeta\\Transfer\\V1\xe2\x02\x1dQmeta\\Transfer\\V1\\GPBMetadata\xea\x02\x13Qmeta::Transfer::V1b\beditionsp\xe9\a"
+	"\fDownloadFile\x12&.qmeta.transfer.v1.DownloadFileRequest\x1a'.qmeta.transfer.v1.DownloadFileResponse\"\x000\x01\x12a\n" +
+	"\fStorageStats\x12&.qmeta.transfer.v1.StorageStatsRequest\x1a'.qmeta.transfer.v1.StorageStatsResponse\"\x00\x12[\n" +
+	"\n" +
+	"DeleteFile\x12$.qmeta.transfer.v1.DeleteFileRequest\x1a%.qmeta.transfer.v1.DeleteFileResponse\"\x00\x12^\n" +
+	"\vQueryChunks\x12%.qmeta.transfer.v1.QueryChunksRequest\x1a&.qmeta.transfer.v1.QueryChunksResponse\"\x00\x12d\n" +
+	"\rListTransfers\x12'.qmeta.transfer.v1.ListTransfersRequest\x1a(.qmeta.transfer.v1.ListTransfersResponse\"\x00B\xb4\x01\n" +
+	"\x15com.qmeta.transfer.v1B\rTransferProtoZ(internal/pb/qmeta/transfer/v1;transferv1\xa2\x02\x03QTX\xaa\x02\x11Qmeta.Transfer.V1\xca\x02\x11Qmeta\\Transfer\\V1\xe2\x02\x1dQmeta\\Transfer\\V1\\GPBMetadata\xea\x02\x13Qmeta::Transfer::V1b\beditionsp\xe9\a"
 
-var file_qmeta_transfer_v1_transfer_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_qmeta_transfer_v1_transfer_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_qmeta_transfer_v1_transfer_proto_msgTypes = make([]protoimpl.MessageInfo, 30)
 var file_qmeta_transfer_v1_transfer_proto_goTypes = []any{
-	(*ServerCheckRequest)(nil),   // 0: qmeta.transfer.v1.ServerCheckRequest
-	(*ServerCheckResponse)(nil),  // 1: qmeta.transfer.v1.ServerCheckResponse
-	(*FileMetadata)(nil),         // 2: qmeta.transfer.v1.FileMetadata
-	(*ChunkData)(nil),            // 3: qmeta.transfer.v1.ChunkData
-	(*UploadFileRequest)(nil),    // 4: qmeta.transfer.v1.UploadFileRequest
-	(*UploadFileResponse)(nil),   // 5: qmeta.transfer.v1.UploadFileResponse
-	(*MetaAck)(nil),              // 6: qmeta.transfer.v1.MetaAck
-	(*ChunkAck)(nil),             // 7: qmeta.transfer.v1.ChunkAck
-	(*TransferResult)(nil),       // 8: qmeta.transfer.v1.TransferResult
-	(*DownloadFileRequest)(nil),  // 9: qmeta.transfer.v1.DownloadFileRequest
-	(*DownloadFileResponse)(nil), // 10: qmeta.transfer.v1.DownloadFileResponse
-	(*ListFileItem)(nil),         // 11: qmeta.transfer.v1.ListFileItem
-	(*ListFilesRequest)(nil),     // 12: qmeta.transfer.v1.ListFilesRequest
-	(*ListFilesResponse)(nil),    // 13: qmeta.transfer.v1.ListFilesResponse
+	(ConflictPolicy)(0),           // 0: qmeta.transfer.v1.ConflictPolicy
+	(ConflictOutcome)(0),          // 1: qmeta.transfer.v1.ConflictOutcome
+	(ErrorCode)(0),                // 2: qmeta.transfer.v1.ErrorCode
+	(*ServerCheckRequest)(nil),    // 3: qmeta.transfer.v1.ServerCheckRequest
+	(*ServerCheckResponse)(nil),   // 4: qmeta.transfer.v1.ServerCheckResponse
+	(*FileMetadata)(nil),          // 5: qmeta.transfer.v1.FileMetadata
+	(*FileTrailer)(nil),           // 6: qmeta.transfer.v1.FileTrailer
+	(*ChunkData)(nil),             // 7: qmeta.transfer.v1.ChunkData
+	(*DeltaOp)(nil),               // 8: qmeta.transfer.v1.DeltaOp
+	(*DeltaData)(nil),             // 9: qmeta.transfer.v1.DeltaData
+	(*UploadFileRequest)(nil),     // 10: qmeta.transfer.v1.UploadFileRequest
+	(*UploadFileResponse)(nil),    // 11: qmeta.transfer.v1.UploadFileResponse
+	(*MetaAck)(nil),               // 12: qmeta.transfer.v1.MetaAck
+	(*BlockSignature)(nil),        // 13: qmeta.transfer.v1.BlockSignature
+	(*BlockSignatures)(nil),       // 14: qmeta.transfer.v1.BlockSignatures
+	(*ChunkAck)(nil),              // 15: qmeta.transfer.v1.ChunkAck
+	(*TransferResult)(nil),        // 16: qmeta.transfer.v1.TransferResult
+	(*DownloadFileRequest)(nil),   // 17: qmeta.transfer.v1.DownloadFileRequest
+	(*DownloadFileResponse)(nil),  // 18: qmeta.transfer.v1.DownloadFileResponse
+	(*FileVersion)(nil),           // 19: qmeta.transfer.v1.FileVersion
+	(*ListFileItem)(nil),          // 20: qmeta.transfer.v1.ListFileItem
+	(*ListFilesRequest)(nil),      // 21: qmeta.transfer.v1.ListFilesRequest
+	(*ListFilesResponse)(nil),     // 22: qmeta.transfer.v1.ListFilesResponse
+	(*TagUsage)(nil),              // 23: qmeta.transfer.v1.TagUsage
+	(*StorageStatsRequest)(nil),   // 24: qmeta.transfer.v1.StorageStatsRequest
+	(*StorageStatsResponse)(nil),  // 25: qmeta.transfer.v1.StorageStatsResponse
+	(*DeleteFileRequest)(nil),     // 26: qmeta.transfer.v1.DeleteFileRequest
+	(*DeleteFileResponse)(nil),    // 27: qmeta.transfer.v1.DeleteFileResponse
+	(*QueryChunksRequest)(nil),    // 28: qmeta.transfer.v1.QueryChunksRequest
+	(*QueryChunksResponse)(nil),   // 29: qmeta.transfer.v1.QueryChunksResponse
+	(*TransferStatus)(nil),        // 30: qmeta.transfer.v1.TransferStatus
+	(*ListTransfersRequest)(nil),  // 31: qmeta.transfer.v1.ListTransfersRequest
+	(*ListTransfersResponse)(nil), // 32: qmeta.transfer.v1.ListTransfersResponse
 }
 var file_qmeta_transfer_v1_transfer_proto_depIdxs = []int32{
-	2,  // 0: qmeta.transfer.v1.UploadFileRequest.metadata:type_name -> qmeta.transfer.v1.FileMetadata
-	3,  // 1: qmeta.transfer.v1.UploadFileRequest.chunk:type_name -> qmeta.transfer.v1.ChunkData
-	6,  // 2: qmeta.transfer.v1.UploadFileResponse.meta_ack:type_name -> qmeta.transfer.v1.MetaAck
-	7,  // 3: qmeta.transfer.v1.UploadFileResponse.chunk_ack:type_name -> qmeta.transfer.v1.ChunkAck
-	8,  // 4: qmeta.transfer.v1.UploadFileResponse.result:type_name -> qmeta.transfer.v1.TransferResult
-	2,  // 5: qmeta.transfer.v1.DownloadFileResponse.metadata:type_name -> qmeta.transfer.v1.FileMetadata
-	3,  // 6: qmeta.transfer.v1.DownloadFileResponse.chunk:type_name -> qmeta.transfer.v1.ChunkData
-	8,  // 7: qmeta.transfer.v1.DownloadFileResponse.result:type_name -> qmeta.transfer.v1.TransferResult
-	11, // 8: qmeta.transfer.v1.ListFilesResponse.files:type_name -> qmeta.transfer.v1.ListFileItem
-	0,  // 9: qmeta.transfer.v1.FileTransferService.ServerCheck:input_type -> qmeta.transfer.v1.ServerCheckRequest
-	12, // 10: qmeta.transfer.v1.FileTransferService.ListFiles:input_type -> qmeta.transfer.v1.ListFilesRequest
-	4,  // 11: qmeta.transfer.v1.FileTransferService.UploadFile:input_type -> qmeta.transfer.v1.UploadFileRequest
-	9,  // 12: qmeta.transfer.v1.FileTransferService.DownloadFile:input_type -> qmeta.transfer.v1.DownloadFileRequest
-	1,  // 13: qmeta.transfer.v1.FileTransferService.ServerCheck:output_type -> qmeta.transfer.v1.ServerCheckResponse
-	13, // 14: qmeta.transfer.v1.FileTransferService.ListFiles:output_type -> qmeta.transfer.v1.ListFilesResponse
-	5,  // 15: qmeta.transfer.v1.FileTransferService.UploadFile:output_type -> qmeta.transfer.v1.UploadFileResponse
-	10, // 16: qmeta.transfer.v1.FileTransferService.DownloadFile:output_type -> qmeta.transfer.v1.DownloadFileResponse
-	13, // [13:17] is the sub-list for method output_type
-	9,  // [9:13] is the sub-list for method input_type
-	9,  // [9:9] is the sub-list for extension type_name
-	9,  // [9:9] is the sub-list for extension extendee
-	0,  // [0:9] is the sub-list for field type_name
+	0,  // 0: qmeta.transfer.v1.FileMetadata.conflict:type_name -> qmeta.transfer.v1.ConflictPolicy
+	8,  // 1: qmeta.transfer.v1.DeltaData.ops:type_name -> qmeta.transfer.v1.DeltaOp
+	5,  // 2: qmeta.transfer.v1.UploadFileRequest.metadata:type_name -> qmeta.transfer.v1.FileMetadata
+	7,  // 3: qmeta.transfer.v1.UploadFileRequest.chunk:type_name -> qmeta.transfer.v1.ChunkData
+	9,  // 4: qmeta.transfer.v1.UploadFileRequest.delta:type_name -> qmeta.transfer.v1.DeltaData
+	6,  // 5: qmeta.transfer.v1.UploadFileRequest.trailer:type_name -> qmeta.transfer.v1.FileTrailer
+	12, // 6: qmeta.transfer.v1.UploadFileResponse.meta_ack:type_name -> qmeta.transfer.v1.MetaAck
+	15, // 7: qmeta.transfer.v1.UploadFileResponse.chunk_ack:type_name -> qmeta.transfer.v1.ChunkAck
+	16, // 8: qmeta.transfer.v1.UploadFileResponse.result:type_name -> qmeta.transfer.v1.TransferResult
+	14, // 9: qmeta.transfer.v1.MetaAck.signatures:type_name -> qmeta.transfer.v1.BlockSignatures
+	1,  // 10: qmeta.transfer.v1.MetaAck.outcome:type_name -> qmeta.transfer.v1.ConflictOutcome
+	2,  // 11: qmeta.transfer.v1.MetaAck.error_code:type_name -> qmeta.transfer.v1.ErrorCode
+	13, // 12: qmeta.transfer.v1.BlockSignatures.blocks:type_name -> qmeta.transfer.v1.BlockSignature
+	2,  // 13: qmeta.transfer.v1.TransferResult.error_code:type_name -> qmeta.transfer.v1.ErrorCode
+	1,  // 14: qmeta.transfer.v1.TransferResult.outcome:type_name -> qmeta.transfer.v1.ConflictOutcome
+	5,  // 15: qmeta.transfer.v1.DownloadFileResponse.metadata:type_name -> qmeta.transfer.v1.FileMetadata
+	7,  // 16: qmeta.transfer.v1.DownloadFileResponse.chunk:type_name -> qmeta.transfer.v1.ChunkData
+	16, // 17: qmeta.transfer.v1.DownloadFileResponse.result:type_name -> qmeta.transfer.v1.TransferResult
+	19, // 18: qmeta.transfer.v1.ListFileItem.versions:type_name -> qmeta.transfer.v1.FileVersion
+	20, // 19: qmeta.transfer.v1.ListFilesResponse.files:type_name -> qmeta.transfer.v1.ListFileItem
+	23, // 20: qmeta.transfer.v1.StorageStatsResponse.tags:type_name -> qmeta.transfer.v1.TagUsage
+	2,  // 21: qmeta.transfer.v1.DeleteFileResponse.error_code:type_name -> qmeta.transfer.v1.ErrorCode
+	30, // 22: qmeta.transfer.v1.ListTransfersResponse.transfers:type_name -> qmeta.transfer.v1.TransferStatus
+	3,  // 23: qmeta.transfer.v1.FileTransferService.ServerCheck:input_type -> qmeta.transfer.v1.ServerCheckRequest
+	21, // 24: qmeta.transfer.v1.FileTransferService.ListFiles:input_type -> qmeta.transfer.v1.ListFilesRequest
+	10, // 25: qmeta.transfer.v1.FileTransferService.UploadFile:input_type -> qmeta.transfer.v1.UploadFileRequest
+	17, // 26: qmeta.transfer.v1.FileTransferService.DownloadFile:input_type -> qmeta.transfer.v1.DownloadFileRequest
+	24, // 27: qmeta.transfer.v1.FileTransferService.StorageStats:input_type -> qmeta.transfer.v1.StorageStatsRequest
+	26, // 28: qmeta.transfer.v1.FileTransferService.DeleteFile:input_type -> qmeta.transfer.v1.DeleteFileRequest
+	28, // 29: qmeta.transfer.v1.FileTransferService.QueryChunks:input_type -> qmeta.transfer.v1.QueryChunksRequest
+	31, // 30: qmeta.transfer.v1.FileTransferService.ListTransfers:input_type -> qmeta.transfer.v1.ListTransfersRequest
+	4,  // 31: qmeta.transfer.v1.FileTransferService.ServerCheck:output_type -> qmeta.transfer.v1.ServerCheckResponse
+	22, // 32: qmeta.transfer.v1.FileTransferService.ListFiles:output_type -> qmeta.transfer.v1.ListFilesResponse
+	11, // 33: qmeta.transfer.v1.FileTransferService.UploadFile:output_type -> qmeta.transfer.v1.UploadFileResponse
+	18, // 34: qmeta.transfer.v1.FileTransferService.DownloadFile:output_type -> qmeta.transfer.v1.DownloadFileResponse
+	25, // 35: qmeta.transfer.v1.FileTransferService.StorageStats:output_type -> qmeta.transfer.v1.StorageStatsResponse
+	27, // 36: qmeta.transfer.v1.FileTransferService.DeleteFile:output_type -> qmeta.transfer.v1.DeleteFileResponse
+	29, // 37: qmeta.transfer.v1.FileTransferService.QueryChunks:output_type -> qmeta.transfer.v1.QueryChunksResponse
+	32, // 38: qmeta.transfer.v1.FileTransferService.ListTransfers:output_type -> qmeta.transfer.v1.ListTransfersResponse
+	31, // [31:39] is the sub-list for method output_type
+	23, // [23:31] is the sub-list for method input_type
+	23, // [23:23] is the sub-list for extension type_name
+	23, // [23:23] is the sub-list for extension extendee
+	0,  // [0:23] is the sub-list for field type_name
 }
 
 func init() { file_qmeta_transfer_v1_transfer_proto_init() }
@@ -2074,16 +5107,18 @@ func file_qmeta_transfer_v1_transfer_proto_init() {
 	if File_qmeta_transfer_v1_transfer_proto != nil {
 		return
 	}
-	file_qmeta_transfer_v1_transfer_proto_msgTypes[4].OneofWrappers = []any{
+	file_qmeta_transfer_v1_transfer_proto_msgTypes[7].OneofWrappers = []any{
 		(*uploadFileRequest_Metadata)(nil),
 		(*uploadFileRequest_Chunk)(nil),
+		(*uploadFileRequest_Delta)(nil),
+		(*uploadFileRequest_Trailer)(nil),
 	}
-	file_qmeta_transfer_v1_transfer_proto_msgTypes[5].OneofWrappers = []any{
+	file_qmeta_transfer_v1_transfer_proto_msgTypes[8].OneofWrappers = []any{
 		(*uploadFileResponse_MetaAck)(nil),
 		(*uploadFileResponse_ChunkAck)(nil),
 		(*uploadFileResponse_Result)(nil),
 	}
-	file_qmeta_transfer_v1_transfer_proto_msgTypes[10].OneofWrappers = []any{
+	file_qmeta_transfer_v1_transfer_proto_msgTypes[15].OneofWrappers = []any{
 		(*downloadFileResponse_Metadata)(nil),
 		(*downloadFileResponse_Chunk)(nil),
 		(*downloadFileResponse_Result)(nil),
@@ -2093,13 +5128,14 @@ func file_qmeta_transfer_v1_transfer_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_qmeta_transfer_v1_transfer_proto_rawDesc), len(file_qmeta_transfer_v1_transfer_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   14,
+			NumEnums:      3,
+			NumMessages:   30,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_qmeta_transfer_v1_transfer_proto_goTypes,
 		DependencyIndexes: file_qmeta_transfer_v1_transfer_proto_depIdxs,
+		EnumInfos:         file_qmeta_transfer_v1_transfer_proto_enumTypes,
 		MessageInfos:      file_qmeta_transfer_v1_transfer_proto_msgTypes,
 	}.Build()
 	File_qmeta_transfer_v1_transfer_proto = out.File
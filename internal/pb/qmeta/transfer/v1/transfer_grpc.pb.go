@@ -19,10 +19,14 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	FileTransferService_ServerCheck_FullMethodName  = "/qmeta.transfer.v1.FileTransferService/ServerCheck"
-	FileTransferService_ListFiles_FullMethodName    = "/qmeta.transfer.v1.FileTransferService/ListFiles"
-	FileTransferService_UploadFile_FullMethodName   = "/qmeta.transfer.v1.FileTransferService/UploadFile"
-	FileTransferService_DownloadFile_FullMethodName = "/qmeta.transfer.v1.FileTransferService/DownloadFile"
+	FileTransferService_ServerCheck_FullMethodName   = "/qmeta.transfer.v1.FileTransferService/ServerCheck"
+	FileTransferService_ListFiles_FullMethodName     = "/qmeta.transfer.v1.FileTransferService/ListFiles"
+	FileTransferService_UploadFile_FullMethodName    = "/qmeta.transfer.v1.FileTransferService/UploadFile"
+	FileTransferService_DownloadFile_FullMethodName  = "/qmeta.transfer.v1.FileTransferService/DownloadFile"
+	FileTransferService_StorageStats_FullMethodName  = "/qmeta.transfer.v1.FileTransferService/StorageStats"
+	FileTransferService_DeleteFile_FullMethodName    = "/qmeta.transfer.v1.FileTransferService/DeleteFile"
+	FileTransferService_QueryChunks_FullMethodName   = "/qmeta.transfer.v1.FileTransferService/QueryChunks"
+	FileTransferService_ListTransfers_FullMethodName = "/qmeta.transfer.v1.FileTransferService/ListTransfers"
 )
 
 // FileTransferServiceClient is the client API for FileTransferService service.
@@ -39,6 +43,14 @@ type FileTransferServiceClient interface {
 	UploadFile(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[UploadFileRequest, UploadFileResponse], error)
 	// DownloadFile 下载文件，使用流式传输
 	DownloadFile(ctx context.Context, in *DownloadFileRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DownloadFileResponse], error)
+	// StorageStats 获取存储使用情况
+	StorageStats(ctx context.Context, in *StorageStatsRequest, opts ...grpc.CallOption) (*StorageStatsResponse, error)
+	// DeleteFile 删除指定标签下的文件
+	DeleteFile(ctx context.Context, in *DeleteFileRequest, opts ...grpc.CallOption) (*DeleteFileResponse, error)
+	// QueryChunks 查询服务端缺少的内容定义分片
+	QueryChunks(ctx context.Context, in *QueryChunksRequest, opts ...grpc.CallOption) (*QueryChunksResponse, error)
+	// ListTransfers 列出正在进行的上传和下载
+	ListTransfers(ctx context.Context, in *ListTransfersRequest, opts ...grpc.CallOption) (*ListTransfersResponse, error)
 }
 
 type fileTransferServiceClient struct {
@@ -101,6 +113,46 @@ func (c *fileTransferServiceClient) DownloadFile(ctx context.Context, in *Downlo
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type FileTransferService_DownloadFileClient = grpc.ServerStreamingClient[DownloadFileResponse]
 
+func (c *fileTransferServiceClient) StorageStats(ctx context.Context, in *StorageStatsRequest, opts ...grpc.CallOption) (*StorageStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StorageStatsResponse)
+	err := c.cc.Invoke(ctx, FileTransferService_StorageStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileTransferServiceClient) DeleteFile(ctx context.Context, in *DeleteFileRequest, opts ...grpc.CallOption) (*DeleteFileResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteFileResponse)
+	err := c.cc.Invoke(ctx, FileTransferService_DeleteFile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileTransferServiceClient) QueryChunks(ctx context.Context, in *QueryChunksRequest, opts ...grpc.CallOption) (*QueryChunksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QueryChunksResponse)
+	err := c.cc.Invoke(ctx, FileTransferService_QueryChunks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileTransferServiceClient) ListTransfers(ctx context.Context, in *ListTransfersRequest, opts ...grpc.CallOption) (*ListTransfersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTransfersResponse)
+	err := c.cc.Invoke(ctx, FileTransferService_ListTransfers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // FileTransferServiceServer is the server API for FileTransferService service.
 // All implementations must embed UnimplementedFileTransferServiceServer
 // for forward compatibility.
@@ -115,6 +167,14 @@ type FileTransferServiceServer interface {
 	UploadFile(grpc.BidiStreamingServer[UploadFileRequest, UploadFileResponse]) error
 	// DownloadFile 下载文件，使用流式传输
 	DownloadFile(*DownloadFileRequest, grpc.ServerStreamingServer[DownloadFileResponse]) error
+	// StorageStats 获取存储使用情况
+	StorageStats(context.Context, *StorageStatsRequest) (*StorageStatsResponse, error)
+	// DeleteFile 删除指定标签下的文件
+	DeleteFile(context.Context, *DeleteFileRequest) (*DeleteFileResponse, error)
+	// QueryChunks 查询服务端缺少的内容定义分片
+	QueryChunks(context.Context, *QueryChunksRequest) (*QueryChunksResponse, error)
+	// ListTransfers 列出正在进行的上传和下载
+	ListTransfers(context.Context, *ListTransfersRequest) (*ListTransfersResponse, error)
 	mustEmbedUnimplementedFileTransferServiceServer()
 }
 
@@ -137,6 +197,18 @@ func (UnimplementedFileTransferServiceServer) UploadFile(grpc.BidiStreamingServe
 func (UnimplementedFileTransferServiceServer) DownloadFile(*DownloadFileRequest, grpc.ServerStreamingServer[DownloadFileResponse]) error {
 	return status.Error(codes.Unimplemented, "method DownloadFile not implemented")
 }
+func (UnimplementedFileTransferServiceServer) StorageStats(context.Context, *StorageStatsRequest) (*StorageStatsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method StorageStats not implemented")
+}
+func (UnimplementedFileTransferServiceServer) DeleteFile(context.Context, *DeleteFileRequest) (*DeleteFileResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteFile not implemented")
+}
+func (UnimplementedFileTransferServiceServer) QueryChunks(context.Context, *QueryChunksRequest) (*QueryChunksResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method QueryChunks not implemented")
+}
+func (UnimplementedFileTransferServiceServer) ListTransfers(context.Context, *ListTransfersRequest) (*ListTransfersResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListTransfers not implemented")
+}
 func (UnimplementedFileTransferServiceServer) mustEmbedUnimplementedFileTransferServiceServer() {}
 func (UnimplementedFileTransferServiceServer) testEmbeddedByValue()                             {}
 
@@ -212,6 +284,78 @@ func _FileTransferService_DownloadFile_Handler(srv interface{}, stream grpc.Serv
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type FileTransferService_DownloadFileServer = grpc.ServerStreamingServer[DownloadFileResponse]
 
+func _FileTransferService_StorageStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StorageStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileTransferServiceServer).StorageStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileTransferService_StorageStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileTransferServiceServer).StorageStats(ctx, req.(*StorageStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileTransferService_DeleteFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileTransferServiceServer).DeleteFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileTransferService_DeleteFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileTransferServiceServer).DeleteFile(ctx, req.(*DeleteFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileTransferService_QueryChunks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryChunksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileTransferServiceServer).QueryChunks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileTransferService_QueryChunks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileTransferServiceServer).QueryChunks(ctx, req.(*QueryChunksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileTransferService_ListTransfers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTransfersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileTransferServiceServer).ListTransfers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileTransferService_ListTransfers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileTransferServiceServer).ListTransfers(ctx, req.(*ListTransfersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // FileTransferService_ServiceDesc is the grpc.ServiceDesc for FileTransferService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -227,6 +371,22 @@ var FileTransferService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListFiles",
 			Handler:    _FileTransferService_ListFiles_Handler,
 		},
+		{
+			MethodName: "StorageStats",
+			Handler:    _FileTransferService_StorageStats_Handler,
+		},
+		{
+			MethodName: "DeleteFile",
+			Handler:    _FileTransferService_DeleteFile_Handler,
+		},
+		{
+			MethodName: "QueryChunks",
+			Handler:    _FileTransferService_QueryChunks_Handler,
+		},
+		{
+			MethodName: "ListTransfers",
+			Handler:    _FileTransferService_ListTransfers_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
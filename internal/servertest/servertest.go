@@ -0,0 +1,68 @@
+// Package servertest 在测试中启动嵌入式文件服务
+package servertest
+
+import (
+	"net"
+	"testing"
+
+	"qback/grpc/common"
+	"qback/grpc/server"
+	transferv1 "qback/internal/pb/qmeta/transfer/v1"
+	"qback/pkg/qback"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Service 可以注册到 gRPC 服务端的服务，如 *server.FileService 或测试中的假服务端
+type Service interface {
+	Register(r grpc.ServiceRegistrar)
+}
+
+// Listen 在随机端口上运行 service，测试结束时停止，返回监听地址
+func Listen(t testing.TB, service Service, opts ...grpc.ServerOption) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts = append([]grpc.ServerOption{grpc.MaxRecvMsgSize(common.MaxMsgSize), grpc.MaxSendMsgSize(common.MaxMsgSize)}, opts...)
+	grpcServer := grpc.NewServer(opts...)
+	service.Register(grpcServer)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+	return listener.Addr().String()
+}
+
+// Start 启动保存到临时目录的服务端，返回监听地址和保存目录
+func Start(t testing.TB, opts ...server.Option) (string, string) {
+	t.Helper()
+	savePath := t.TempDir()
+	fileService, err := server.NewFileService(append([]server.Option{server.WithSavePath(savePath)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Listen(t, fileService), savePath
+}
+
+// Dial 创建连接到 address 的客户端，测试结束时关闭
+func Dial(t testing.TB, address string, opts ...qback.Option) *qback.Client {
+	t.Helper()
+	client, err := qback.New(address, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// RPC 创建连接到 address 的原始 gRPC 客户端，用于发送 SDK 不会产生的请求
+func RPC(t testing.TB, address string) transferv1.FileTransferServiceClient {
+	t.Helper()
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return transferv1.NewFileTransferServiceClient(conn)
+}
@@ -0,0 +1,202 @@
+// Package qback 提供 qback 服务的 Go 客户端 SDK
+//
+// Client 可以并发使用，所有操作都接受 context.Context，只通过 WithLogger 设置的日志输出调试信息，不会输出到终端。
+package qback
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"qback/grpc/common"
+	transferv1 "qback/internal/pb/qmeta/transfer/v1"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// DefaultChunkSize 默认分片大小
+	DefaultChunkSize = 1024 * 1024
+	// DefaultChunkTimeout 默认单个分片的收发超时
+	DefaultChunkTimeout = 30 * time.Second
+)
+
+// Client qback 客户端，持有一个 gRPC 连接
+type Client struct {
+	conn *grpc.ClientConn
+	rpc  transferv1.FileTransferServiceClient
+	cfg  config
+}
+
+type config struct {
+	tlsConfig    *tls.Config
+	chunkSize    int
+	chunkTimeout time.Duration
+	logger       *slog.Logger
+	dialOptions  []grpc.DialOption
+	limiter      Limiter
+}
+
+// Limiter 限速器，每发送或接收 n 字节数据前调用 WaitN
+// common.RateLimiter 满足该接口，*rate.Limiter 也可以使用，但 n 可能超过其桶容量
+type Limiter interface {
+	WaitN(ctx context.Context, n int) error
+}
+
+// Option 客户端选项
+type Option func(*config)
+
+// WithTLS 使用 TLS 连接服务端，为 nil 时使用明文连接
+func WithTLS(tlsConfig *tls.Config) Option {
+	return func(c *config) {
+		c.tlsConfig = tlsConfig
+	}
+}
+
+// WithChunkSize 设置上传和下载的分片大小
+func WithChunkSize(size int) Option {
+	return func(c *config) {
+		if size > 0 {
+			c.chunkSize = size
+		}
+	}
+}
+
+// WithChunkTimeout 设置单个分片的收发超时
+func WithChunkTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		if timeout > 0 {
+			c.chunkTimeout = timeout
+		}
+	}
+}
+
+// WithLogger 设置日志，默认不输出
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// WithTracerProvider 记录 gRPC 请求的链路，并通过 W3C trace context 传递给服务端
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.dialOptions = append(c.dialOptions, grpc.WithStatsHandler(otelgrpc.NewClientHandler(
+			otelgrpc.WithTracerProvider(tp),
+			otelgrpc.WithPropagators(propagation.TraceContext{}),
+		)))
+	}
+}
+
+// WithRateLimiter 限制该客户端所有上传和下载的数据速率
+func WithRateLimiter(limiter Limiter) Option {
+	return func(c *config) {
+		c.limiter = limiter
+	}
+}
+
+// WithDialOptions 追加 gRPC 连接选项
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(c *config) {
+		c.dialOptions = append(c.dialOptions, opts...)
+	}
+}
+
+// New 创建连接到 address 的客户端，连接在第一次调用时建立
+func New(address string, opts ...Option) (*Client, error) {
+	cfg := config{
+		chunkSize:    DefaultChunkSize,
+		chunkTimeout: DefaultChunkTimeout,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.logger == nil {
+		cfg.logger = slog.New(slog.DiscardHandler)
+	}
+	if cfg.chunkSize > common.MaxMsgSize/2 {
+		return nil, fmt.Errorf("chunk size %d exceeds limit %d", cfg.chunkSize, common.MaxMsgSize/2)
+	}
+
+	cred := insecure.NewCredentials()
+	if cfg.tlsConfig != nil {
+		cred = credentials.NewTLS(cfg.tlsConfig)
+	}
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(cred),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(common.MaxMsgSize),
+			grpc.MaxCallSendMsgSize(common.MaxMsgSize),
+		),
+		grpc.WithDefaultServiceConfig(common.RetryPolicy),
+	}
+	dialOpts = append(dialOpts, cfg.dialOptions...)
+
+	conn, err := grpc.NewClient(address, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("qback: connect %s: %w", address, err)
+	}
+
+	c := &Client{
+		conn: conn,
+		rpc:  transferv1.NewFileTransferServiceClient(conn),
+		cfg:  cfg,
+	}
+	c.cfg.logger.Debug("client ready", "address", address, "tls", cfg.tlsConfig != nil, "chunksize", cfg.chunkSize, "chunk_timeout", cfg.chunkTimeout)
+	return c, nil
+}
+
+// Close 关闭连接
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// ChunkSize 返回分片大小
+func (c *Client) ChunkSize() int {
+	return c.cfg.chunkSize
+}
+
+// throttle 按限速等待 n 字节的配额，未设置限速时直接返回
+func (c *Client) throttle(ctx context.Context, n int) error {
+	if c.cfg.limiter == nil || n == 0 {
+		return nil
+	}
+	if err := c.cfg.limiter.WaitN(ctx, n); err != nil {
+		return fmt.Errorf("qback: rate limit: %w", err)
+	}
+	return nil
+}
+
+// withTransferID 在请求头中附带传输 ID，未指定时生成
+func (c *Client) withTransferID(ctx context.Context, cfg *callConfig) (context.Context, error) {
+	if cfg.transferID == "" {
+		cfg.transferID = common.NewTransferID()
+	} else if !common.ValidTransferID(cfg.transferID) {
+		return nil, fmt.Errorf("qback: invalid transfer id %q", cfg.transferID)
+	}
+	return metadata.AppendToOutgoingContext(ctx, common.TransferIDHeader, cfg.transferID), nil
+}
+
+// Ping 检查服务端是否可用
+func (c *Client) Ping(ctx context.Context) error {
+	req := &transferv1.ServerCheckRequest{}
+	req.SetStatus(true)
+
+	resp, err := c.rpc.ServerCheck(ctx, req)
+	if err != nil {
+		return fmt.Errorf("qback: ping: %w", err)
+	}
+	if !resp.GetStatus() {
+		return &Error{Op: "ping", Kind: ErrServer, Message: "server check failed"}
+	}
+	return nil
+}
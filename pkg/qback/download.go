@@ -0,0 +1,138 @@
+package qback
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"qback/grpc/common"
+	transferv1 "qback/internal/pb/qmeta/transfer/v1"
+)
+
+// DownloadResult 下载结果
+type DownloadResult struct {
+	Size int64
+	Hash string
+	// Skipped 服务端文件哈希与 WithSkipIfHash 相同，没有写入数据
+	Skipped bool
+	Message string
+	// TransferID 本次传输的 ID
+	TransferID string
+}
+
+// Download 下载 tag 下的文件并写入 w，数据全部写入后校验大小和哈希
+// 校验失败时 w 中已有数据，由调用方决定如何丢弃
+func (c *Client) Download(ctx context.Context, tag, name string, w io.Writer, opts ...CallOption) (*DownloadResult, error) {
+	cfg := newCallConfig(opts)
+	ctx, err := c.withTransferID(ctx, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	req := &transferv1.DownloadFileRequest{}
+	req.SetTag(tag)
+	req.SetName(name)
+	req.SetChunksize(int64(c.cfg.chunkSize))
+	req.SetVersionId(cfg.versionID)
+
+	stream, err := c.rpc.DownloadFile(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("qback: open download stream: %w", err)
+	}
+
+	resp, err := stream.Recv()
+	if err != nil {
+		return nil, rpcError("download", "receive metadata", err)
+	}
+	meta := resp.GetMetadata()
+	if meta == nil {
+		result := resp.GetResult()
+		if result == nil {
+			return nil, &Error{Op: "download", Kind: ErrServer, Message: "missing metadata"}
+		}
+		return nil, serverError("download", result.GetErrorCode(), result.GetMessage(), ErrServer)
+	}
+
+	result := &DownloadResult{Size: meta.GetSize(), Hash: meta.GetHash(), TransferID: cfg.transferID}
+	c.cfg.logger.Debug("download metadata", "transfer_id", cfg.transferID, "tag", tag, "name", name, "version", cfg.versionID, "size", result.Size, "chunks", meta.GetChunks(), "hash", result.Hash)
+
+	if cfg.skipIfHash != "" && cfg.skipIfHash == result.Hash {
+		result.Skipped = true
+		return result, nil
+	}
+
+	hasher := common.NewHasher()
+	writer := bufio.NewWriterSize(io.MultiWriter(w, hasher), 64*1024)
+	var received int64
+	for {
+		resp, err := c.recv(stream)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if transfer := resp.GetResult(); transfer != nil {
+			if !transfer.GetStatus() {
+				return nil, serverError("download", transfer.GetErrorCode(), transfer.GetMessage(), ErrServer)
+			}
+			result.Message = transfer.GetMessage()
+			break
+		}
+
+		data := resp.GetChunk().GetData()
+		if len(data) == 0 {
+			continue
+		}
+		if err := c.throttle(ctx, len(data)); err != nil {
+			return nil, err
+		}
+		if _, err := writer.Write(data); err != nil {
+			return nil, fmt.Errorf("qback: write: %w", err)
+		}
+		received += int64(len(data))
+		cfg.report(received, result.Size)
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, fmt.Errorf("qback: write: %w", err)
+	}
+
+	if received != result.Size {
+		return nil, &Error{Op: "download", Kind: ErrIntegrity, Message: fmt.Sprintf("size mismatch: expected=%d got=%d", result.Size, received)}
+	}
+	if hash := hasher.SumStream().ToHex(); hash != result.Hash {
+		return nil, &Error{Op: "download", Kind: ErrIntegrity, Message: fmt.Sprintf("hash mismatch: expected=%s got=%s", result.Hash, hash)}
+	}
+	return result, nil
+}
+
+// recv 在分片超时时间内接收下载响应
+func (c *Client) recv(stream transferv1.FileTransferService_DownloadFileClient) (*transferv1.DownloadFileResponse, error) {
+	type received struct {
+		resp *transferv1.DownloadFileResponse
+		err  error
+	}
+	ch := make(chan received, 1)
+	go func() {
+		resp, err := stream.Recv()
+		ch <- received{resp, err}
+	}()
+
+	select {
+	case <-stream.Context().Done():
+		return nil, stream.Context().Err()
+	case <-time.After(c.cfg.chunkTimeout):
+		return nil, &Error{Op: "download", Kind: ErrTimeout, Message: fmt.Sprintf("receive timeout after %s", c.cfg.chunkTimeout)}
+	case r := <-ch:
+		if r.err != nil && r.err != io.EOF {
+			return nil, fmt.Errorf("qback: receive chunk: %w", r.err)
+		}
+		return r.resp, r.err
+	}
+}
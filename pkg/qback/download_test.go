@@ -0,0 +1,68 @@
+package qback_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"qback/pkg/qback"
+)
+
+func TestDownload(t *testing.T) {
+	client, _ := startServer(t)
+	ctx := context.Background()
+	data := randomData(3, 15000)
+
+	uploaded, err := client.Upload(ctx, "docs", "a.bin", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	result, err := client.Download(ctx, "docs", "a.bin", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) || result.Size != int64(len(data)) || result.Hash != uploaded.Hash || result.Skipped {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	buf.Reset()
+	result, err = client.Download(ctx, "docs", "a.bin", &buf, qback.WithSkipIfHash(uploaded.Hash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Skipped || buf.Len() != 0 {
+		t.Fatalf("expected skipped download, got %+v with %d bytes", result, buf.Len())
+	}
+}
+
+func TestDownloadErrors(t *testing.T) {
+	client, _ := startServer(t)
+	ctx := context.Background()
+
+	cases := []struct {
+		name string
+		tag  string
+		file string
+		opts []qback.CallOption
+		kind error
+	}{
+		{"missing file", "docs", "missing.bin", nil, qback.ErrNotFound},
+		{"missing version", "docs", "missing.bin", []qback.CallOption{qback.WithVersion("20260101T000000.000000000Z")}, qback.ErrNotFound},
+		{"invalid tag", "..", "a.bin", nil, qback.ErrRejected},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := client.Download(ctx, tc.tag, tc.file, &bytes.Buffer{}, tc.opts...)
+			if !errors.Is(err, tc.kind) {
+				t.Fatalf("expected %v, got %v", tc.kind, err)
+			}
+		})
+	}
+
+	if err := client.Delete(ctx, "docs", "missing.bin"); !errors.Is(err, qback.ErrNotFound) {
+		t.Fatalf("expected qback.ErrNotFound from delete, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package qback
+
+import (
+	"errors"
+	"fmt"
+
+	transferv1 "qback/internal/pb/qmeta/transfer/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 错误类型，可以用 errors.Is 判断
+var (
+	// ErrRejected 服务端拒绝请求，例如配额不足或标签无效
+	ErrRejected = errors.New("rejected by server")
+	// ErrExists 同名文件已存在
+	ErrExists = errors.New("file already exists")
+	// ErrNotFound 文件或版本不存在
+	ErrNotFound = errors.New("file not found")
+	// ErrIntegrity 大小或哈希校验失败
+	ErrIntegrity = errors.New("integrity check failed")
+	// ErrTimeout 分片收发超时
+	ErrTimeout = errors.New("chunk timeout")
+	// ErrServer 服务端处理失败
+	ErrServer = errors.New("server error")
+	// ErrBusy 服务端同时进行的传输已达上限，可以稍后重试
+	ErrBusy = errors.New("server busy")
+)
+
+// Error 服务端返回的错误，Kind 为上面的错误类型之一
+type Error struct {
+	Op      string
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return "qback: " + e.Op + ": " + e.Kind.Error()
+	}
+	return "qback: " + e.Op + ": " + e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// serverError 根据服务端返回的错误码判断错误类型，未知错误码使用 fallback
+func serverError(op string, code transferv1.ErrorCode, message string, fallback error) *Error {
+	kind := fallback
+	switch code {
+	case transferv1.ErrorCode_ERROR_CODE_REJECTED:
+		kind = ErrRejected
+	case transferv1.ErrorCode_ERROR_CODE_EXISTS:
+		kind = ErrExists
+	case transferv1.ErrorCode_ERROR_CODE_NOT_FOUND:
+		kind = ErrNotFound
+	case transferv1.ErrorCode_ERROR_CODE_INTEGRITY:
+		kind = ErrIntegrity
+	case transferv1.ErrorCode_ERROR_CODE_INTERNAL:
+		kind = ErrServer
+	}
+	return &Error{Op: op, Kind: kind, Message: message}
+}
+
+// rpcError 包装 gRPC 错误，ResourceExhausted 转为 ErrBusy
+func rpcError(op, step string, err error) error {
+	if status.Code(err) == codes.ResourceExhausted {
+		return &Error{Op: op, Kind: ErrBusy, Message: status.Convert(err).Message()}
+	}
+	return fmt.Errorf("qback: %s: %w", step, err)
+}
@@ -0,0 +1,50 @@
+package qback
+
+import (
+	"errors"
+	"testing"
+
+	transferv1 "qback/internal/pb/qmeta/transfer/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestServerError(t *testing.T) {
+	cases := []struct {
+		code     transferv1.ErrorCode
+		fallback error
+		kind     error
+	}{
+		{transferv1.ErrorCode_ERROR_CODE_REJECTED, ErrServer, ErrRejected},
+		{transferv1.ErrorCode_ERROR_CODE_EXISTS, ErrServer, ErrExists},
+		{transferv1.ErrorCode_ERROR_CODE_NOT_FOUND, ErrServer, ErrNotFound},
+		{transferv1.ErrorCode_ERROR_CODE_INTEGRITY, ErrServer, ErrIntegrity},
+		{transferv1.ErrorCode_ERROR_CODE_INTERNAL, ErrRejected, ErrServer},
+		// 旧版本服务端不返回错误码，不再根据消息内容猜测
+		{transferv1.ErrorCode_ERROR_CODE_UNSPECIFIED, ErrRejected, ErrRejected},
+	}
+	for _, tc := range cases {
+		t.Run(tc.code.String(), func(t *testing.T) {
+			err := serverError("upload", tc.code, "File already exists", tc.fallback)
+			if !errors.Is(err, tc.kind) {
+				t.Fatalf("expected %v, got %v", tc.kind, err.Kind)
+			}
+			if err.Error() != "qback: upload: File already exists" {
+				t.Fatalf("unexpected message: %s", err)
+			}
+		})
+	}
+}
+
+func TestRPCError(t *testing.T) {
+	err := rpcError("upload", "receive ack", status.Error(codes.ResourceExhausted, "too many uploads"))
+	if !errors.Is(err, ErrBusy) {
+		t.Fatalf("expected ErrBusy, got %v", err)
+	}
+
+	err = rpcError("upload", "receive ack", status.Error(codes.Unavailable, "down"))
+	if errors.Is(err, ErrBusy) || status.Code(errors.Unwrap(err)) != codes.Unavailable {
+		t.Fatalf("expected wrapped unavailable error, got %v", err)
+	}
+}
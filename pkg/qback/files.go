@@ -0,0 +1,166 @@
+package qback
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	transferv1 "qback/internal/pb/qmeta/transfer/v1"
+)
+
+// FileInfo 服务端文件信息
+type FileInfo struct {
+	Name    string
+	Size    int64
+	Hash    string
+	ModTime time.Time
+	// Versions 历史版本，仅在使用 WithVersions 时返回
+	Versions []Version
+}
+
+// Version 文件的历史版本
+type Version struct {
+	ID      string
+	Size    int64
+	Hash    string
+	ModTime time.Time
+}
+
+// TagUsage 标签的存储使用情况，Quota 为 0 表示不限制
+type TagUsage struct {
+	Tag   string
+	Used  int64
+	Files int64
+	Quota int64
+}
+
+// Stats 服务端存储统计
+type Stats struct {
+	DiskTotal int64
+	DiskFree  int64
+	Tags      []TagUsage
+}
+
+// Transfer 服务端正在进行的传输，Total 为 0 表示大小未知
+type Transfer struct {
+	ID    string
+	Op    string
+	Tag   string
+	Name  string
+	Peer  string
+	Done  int64
+	Total int64
+	// Rate 开始以来的平均速度，单位字节每秒
+	Rate  float64
+	Start time.Time
+}
+
+// List 列出 tag 下的文件，标签不存在时返回空列表
+func (c *Client) List(ctx context.Context, tag string, opts ...CallOption) ([]FileInfo, error) {
+	cfg := newCallConfig(opts)
+
+	req := &transferv1.ListFilesRequest{}
+	req.SetTag(tag)
+	req.SetVersions(cfg.versions)
+
+	resp, err := c.rpc.ListFiles(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("qback: list: %w", err)
+	}
+	if !resp.GetStatus() {
+		return nil, &Error{Op: "list", Kind: ErrRejected, Message: resp.GetMessage()}
+	}
+
+	files := make([]FileInfo, 0, len(resp.GetFiles()))
+	for _, item := range resp.GetFiles() {
+		file := FileInfo{
+			Name:    item.GetName(),
+			Size:    item.GetSize(),
+			Hash:    item.GetHash(),
+			ModTime: time.Unix(item.GetModifiedTime(), 0),
+		}
+		for _, version := range item.GetVersions() {
+			file.Versions = append(file.Versions, Version{
+				ID:      version.GetVersionId(),
+				Size:    version.GetSize(),
+				Hash:    version.GetHash(),
+				ModTime: time.Unix(version.GetModifiedTime(), 0),
+			})
+		}
+		files = append(files, file)
+	}
+	c.cfg.logger.Debug("list files", "tag", tag, "versions", cfg.versions, "files", len(files))
+	return files, nil
+}
+
+// Delete 删除 tag 下的文件
+func (c *Client) Delete(ctx context.Context, tag, name string) error {
+	req := &transferv1.DeleteFileRequest{}
+	req.SetTag(tag)
+	req.SetName(name)
+
+	resp, err := c.rpc.DeleteFile(ctx, req)
+	if err != nil {
+		return fmt.Errorf("qback: delete: %w", err)
+	}
+	if !resp.GetStatus() {
+		return serverError("delete", resp.GetErrorCode(), resp.GetMessage(), ErrRejected)
+	}
+	c.cfg.logger.Debug("delete file", "tag", tag, "name", name, "message", resp.GetMessage())
+	return nil
+}
+
+// Stats 查询存储统计，tag 为空时返回所有标签
+func (c *Client) Stats(ctx context.Context, tag string) (*Stats, error) {
+	req := &transferv1.StorageStatsRequest{}
+	req.SetTag(tag)
+
+	resp, err := c.rpc.StorageStats(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("qback: stats: %w", err)
+	}
+	if !resp.GetStatus() {
+		return nil, &Error{Op: "stats", Kind: ErrRejected, Message: resp.GetMessage()}
+	}
+
+	stats := &Stats{DiskTotal: resp.GetDiskTotal(), DiskFree: resp.GetDiskFree()}
+	for _, usage := range resp.GetTags() {
+		stats.Tags = append(stats.Tags, TagUsage{
+			Tag:   usage.GetTag(),
+			Used:  usage.GetUsed(),
+			Files: usage.GetFiles(),
+			Quota: usage.GetQuota(),
+		})
+	}
+	return stats, nil
+}
+
+// Transfers 查询服务端正在进行的上传和下载，tag 为空时返回所有标签
+func (c *Client) Transfers(ctx context.Context, tag string) ([]Transfer, error) {
+	req := &transferv1.ListTransfersRequest{}
+	req.SetTag(tag)
+
+	resp, err := c.rpc.ListTransfers(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("qback: transfers: %w", err)
+	}
+	if !resp.GetStatus() {
+		return nil, &Error{Op: "transfers", Kind: ErrRejected, Message: resp.GetMessage()}
+	}
+
+	transfers := make([]Transfer, 0, len(resp.GetTransfers()))
+	for _, item := range resp.GetTransfers() {
+		transfers = append(transfers, Transfer{
+			ID:    item.GetTransferId(),
+			Op:    item.GetOp(),
+			Tag:   item.GetTag(),
+			Name:  item.GetName(),
+			Peer:  item.GetPeer(),
+			Done:  item.GetDone(),
+			Total: item.GetTotal(),
+			Rate:  item.GetRate(),
+			Start: time.Unix(item.GetStartTime(), 0),
+		})
+	}
+	return transfers, nil
+}
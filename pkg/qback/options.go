@@ -0,0 +1,178 @@
+package qback
+
+import (
+	"fmt"
+	"strings"
+
+	transferv1 "qback/internal/pb/qmeta/transfer/v1"
+)
+
+// ConflictPolicy 上传时服务端已有同名文件的处理策略
+type ConflictPolicy int
+
+const (
+	// ConflictDefault 服务端默认行为：相同内容报错，不同内容覆盖
+	ConflictDefault ConflictPolicy = iota
+	// ConflictFail 已存在时报错
+	ConflictFail
+	// ConflictSkipIfIdentical 内容相同时跳过，不同时覆盖
+	ConflictSkipIfIdentical
+	// ConflictOverwrite 总是覆盖
+	ConflictOverwrite
+	// ConflictRename 以 name_N.ext 的形式另存
+	ConflictRename
+)
+
+var conflictPolicyNames = map[string]ConflictPolicy{
+	"fail":               ConflictFail,
+	"skip-if-identical":  ConflictSkipIfIdentical,
+	"overwrite":          ConflictOverwrite,
+	"rename-with-suffix": ConflictRename,
+}
+
+// ParseConflictPolicy 解析冲突策略名称，空字符串为 ConflictDefault
+func ParseConflictPolicy(name string) (ConflictPolicy, error) {
+	if strings.TrimSpace(name) == "" {
+		return ConflictDefault, nil
+	}
+	policy, ok := conflictPolicyNames[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return ConflictDefault, fmt.Errorf("invalid conflict policy %q, use fail, skip-if-identical, overwrite or rename-with-suffix", name)
+	}
+	return policy, nil
+}
+
+func (p ConflictPolicy) proto() transferv1.ConflictPolicy {
+	switch p {
+	case ConflictFail:
+		return transferv1.ConflictPolicy_CONFLICT_POLICY_FAIL
+	case ConflictSkipIfIdentical:
+		return transferv1.ConflictPolicy_CONFLICT_POLICY_SKIP_IF_IDENTICAL
+	case ConflictOverwrite:
+		return transferv1.ConflictPolicy_CONFLICT_POLICY_OVERWRITE
+	case ConflictRename:
+		return transferv1.ConflictPolicy_CONFLICT_POLICY_RENAME_WITH_SUFFIX
+	default:
+		return transferv1.ConflictPolicy_CONFLICT_POLICY_UNSPECIFIED
+	}
+}
+
+// Outcome 上传时同名文件冲突的处理结果
+type Outcome string
+
+const (
+	OutcomeUnknown     Outcome = ""
+	OutcomeCreated     Outcome = "created"
+	OutcomeSkipped     Outcome = "skipped"
+	OutcomeOverwritten Outcome = "overwritten"
+	OutcomeRenamed     Outcome = "renamed"
+)
+
+func outcomeFromProto(outcome transferv1.ConflictOutcome) Outcome {
+	switch outcome {
+	case transferv1.ConflictOutcome_CONFLICT_OUTCOME_NONE:
+		return OutcomeCreated
+	case transferv1.ConflictOutcome_CONFLICT_OUTCOME_SKIPPED:
+		return OutcomeSkipped
+	case transferv1.ConflictOutcome_CONFLICT_OUTCOME_OVERWRITTEN:
+		return OutcomeOverwritten
+	case transferv1.ConflictOutcome_CONFLICT_OUTCOME_RENAMED:
+		return OutcomeRenamed
+	default:
+		return OutcomeUnknown
+	}
+}
+
+// Progress 传输进度，Total 为 0 表示总大小未知
+type Progress struct {
+	Done  int64
+	Total int64
+}
+
+// ProgressFunc 进度回调，在传输所在的 goroutine 中调用
+type ProgressFunc func(Progress)
+
+// CallOption 单次操作的选项，不适用于当前操作的选项会被忽略
+type CallOption func(*callConfig)
+
+type callConfig struct {
+	conflict       ConflictPolicy
+	delta          bool
+	contentDefined bool
+	versionID      string
+	skipIfHash     string
+	versions       bool
+	progress       ProgressFunc
+	transferID     string
+}
+
+func newCallConfig(opts []CallOption) callConfig {
+	var cfg callConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+func (cfg *callConfig) report(done, total int64) {
+	if cfg.progress != nil {
+		cfg.progress(Progress{Done: done, Total: total})
+	}
+}
+
+// WithConflict 上传时同名文件的处理策略
+func WithConflict(policy ConflictPolicy) CallOption {
+	return func(c *callConfig) {
+		c.conflict = policy
+	}
+}
+
+// WithDelta 服务端已有不同版本时只上传增量，仅用于 UploadFile
+func WithDelta() CallOption {
+	return func(c *callConfig) {
+		c.delta = true
+	}
+}
+
+// WithContentDefined 使用内容定义分片，只上传服务端缺少的分片，仅用于 UploadFile
+func WithContentDefined() CallOption {
+	return func(c *callConfig) {
+		c.contentDefined = true
+	}
+}
+
+// WithVersion 下载指定的历史版本
+func WithVersion(versionID string) CallOption {
+	return func(c *callConfig) {
+		c.versionID = versionID
+	}
+}
+
+// WithSkipIfHash 服务端文件哈希与 hash 相同时不下载数据
+func WithSkipIfHash(hash string) CallOption {
+	return func(c *callConfig) {
+		c.skipIfHash = hash
+	}
+}
+
+// WithVersions 列出文件时同时返回历史版本
+func WithVersions() CallOption {
+	return func(c *callConfig) {
+		c.versions = true
+	}
+}
+
+// WithProgress 设置进度回调
+func WithProgress(fn ProgressFunc) CallOption {
+	return func(c *callConfig) {
+		c.progress = fn
+	}
+}
+
+// WithTransferID 指定本次传输的 ID，默认随机生成
+// 服务端的日志和事件回调使用同一个 ID，便于对照两端的记录
+func WithTransferID(id string) CallOption {
+	return func(c *callConfig) {
+		c.transferID = id
+	}
+}
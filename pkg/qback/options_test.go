@@ -0,0 +1,165 @@
+package qback_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"qback/grpc/server"
+	"qback/pkg/qback"
+)
+
+func TestParseConflictPolicy(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy qback.ConflictPolicy
+		ok     bool
+	}{
+		{"", qback.ConflictDefault, true},
+		{"fail", qback.ConflictFail, true},
+		{" Skip-If-Identical ", qback.ConflictSkipIfIdentical, true},
+		{"overwrite", qback.ConflictOverwrite, true},
+		{"rename-with-suffix", qback.ConflictRename, true},
+		{"keep", qback.ConflictDefault, false},
+	}
+	for _, tc := range cases {
+		policy, err := qback.ParseConflictPolicy(tc.name)
+		if (err == nil) != tc.ok || policy != tc.policy {
+			t.Errorf("ParseConflictPolicy(%q) = %v, %v", tc.name, policy, err)
+		}
+	}
+}
+
+func TestConflictOptions(t *testing.T) {
+	client, savePath := startServer(t)
+	ctx := context.Background()
+	first := writeTemp(t, []byte("first"))
+	second := writeTemp(t, []byte("second"))
+
+	if _, err := client.UploadFile(ctx, "docs", "a.txt", first); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := client.UploadFile(ctx, "docs", "a.txt", first, qback.WithConflict(qback.ConflictSkipIfIdentical))
+	if err != nil || !result.Skipped || result.Outcome != qback.OutcomeSkipped {
+		t.Fatalf("expected skipped upload, got %+v, %v", result, err)
+	}
+
+	result, err = client.UploadFile(ctx, "docs", "a.txt", second, qback.WithConflict(qback.ConflictRename))
+	if err != nil || result.Name != "a_1.txt" || result.Outcome != qback.OutcomeRenamed {
+		t.Fatalf("expected renamed upload, got %+v, %v", result, err)
+	}
+
+	result, err = client.UploadFile(ctx, "docs", "a.txt", second, qback.WithConflict(qback.ConflictOverwrite))
+	if err != nil || result.Outcome != qback.OutcomeOverwritten {
+		t.Fatalf("expected overwritten upload, got %+v, %v", result, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(savePath, "docs", "a.txt")); string(data) != "second" {
+		t.Fatalf("unexpected content: %q", data)
+	}
+}
+
+func TestTransferAndProgressOptions(t *testing.T) {
+	var serverID string
+	client, _ := startServer(t, server.WithHooks(server.Hooks{
+		OnUploadComplete: func(_ context.Context, info server.UploadInfo) { serverID = info.TransferID },
+	}))
+	data := randomData(4, 20000)
+
+	var last qback.Progress
+	calls := 0
+	result, err := client.UploadFile(context.Background(), "docs", "a.bin", writeTemp(t, data),
+		qback.WithTransferID("test-transfer"),
+		qback.WithProgress(func(p qback.Progress) {
+			calls++
+			last = p
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TransferID != "test-transfer" || serverID != "test-transfer" {
+		t.Fatalf("transfer id not propagated: client=%q server=%q", result.TransferID, serverID)
+	}
+	if calls < 2 || last.Done != int64(len(data)) || last.Total != int64(len(data)) {
+		t.Fatalf("unexpected progress: calls=%d last=%+v", calls, last)
+	}
+}
+
+func TestDeltaOption(t *testing.T) {
+	client, savePath := startServer(t)
+	ctx := context.Background()
+	data := randomData(5, 64*1024)
+
+	if _, err := client.UploadFile(ctx, "docs", "a.bin", writeTemp(t, data)); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := bytes.Clone(data)
+	copy(changed[1000:], "changed")
+	result, err := client.UploadFile(ctx, "docs", "a.bin", writeTemp(t, changed), qback.WithDelta())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Reused == 0 || result.Sent >= int64(len(changed)) {
+		t.Fatalf("expected delta upload, got %+v", result)
+	}
+	if saved, _ := os.ReadFile(filepath.Join(savePath, "docs", "a.bin")); !bytes.Equal(saved, changed) {
+		t.Fatal("saved file mismatch")
+	}
+}
+
+func TestContentDefinedOption(t *testing.T) {
+	client, _ := startServer(t, server.WithDedup())
+	ctx := context.Background()
+	data := randomData(6, 256*1024)
+
+	if _, err := client.UploadFile(ctx, "docs", "a.bin", writeTemp(t, data), qback.WithContentDefined()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 另一个文件在开头插入数据，后面的分片与已上传的相同
+	changed := append([]byte("prefix"), data...)
+	result, err := client.UploadFile(ctx, "docs", "b.bin", writeTemp(t, changed), qback.WithContentDefined())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Reused == 0 || result.Sent >= int64(len(changed)) {
+		t.Fatalf("expected reused chunks, got %+v", result)
+	}
+
+	var buf bytes.Buffer
+	if _, err := client.Download(ctx, "docs", "b.bin", &buf); err != nil || !bytes.Equal(buf.Bytes(), changed) {
+		t.Fatalf("download mismatch: %v", err)
+	}
+}
+
+func TestVersionOption(t *testing.T) {
+	client, _ := startServer(t, server.WithVersioning(5, 0))
+	ctx := context.Background()
+
+	if _, err := client.Upload(ctx, "docs", "a.txt", bytes.NewReader([]byte("v1"))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Upload(ctx, "docs", "a.txt", bytes.NewReader([]byte("v2")), qback.WithConflict(qback.ConflictOverwrite)); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := client.List(ctx, "docs", qback.WithVersions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || len(files[0].Versions) != 1 {
+		t.Fatalf("expected one archived version, got %+v", files)
+	}
+
+	var buf bytes.Buffer
+	if _, err := client.Download(ctx, "docs", "a.txt", &buf, qback.WithVersion(files[0].Versions[0].ID)); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "v1" {
+		t.Fatalf("expected archived content, got %q", buf.String())
+	}
+}
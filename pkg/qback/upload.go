@@ -0,0 +1,569 @@
+package qback
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"qback/grpc/common"
+	transferv1 "qback/internal/pb/qmeta/transfer/v1"
+)
+
+// deltaOpOverhead 每条增量指令的大致开销
+const deltaOpOverhead = 16
+
+// UploadResult 上传结果
+type UploadResult struct {
+	// Name 服务端保存的文件名，冲突策略为重命名时与请求的不同
+	Name string
+	Size int64
+	Hash string
+	// Sent 实际发送的文件数据字节数
+	Sent int64
+	// Reused 服务端通过增量或分片去重复用的字节数
+	Reused  int64
+	Outcome Outcome
+	// Skipped 服务端已有相同内容，没有传输数据
+	Skipped bool
+	Message string
+	// TransferID 本次传输的 ID
+	TransferID string
+}
+
+type uploadStream = transferv1.FileTransferService_UploadFileClient
+
+// UploadFile 上传本地文件并以 name 保存到 tag 下
+// 可配合 WithDelta 或 WithContentDefined 减少传输量
+func (c *Client) UploadFile(ctx context.Context, tag, name, path string, opts ...CallOption) (*UploadResult, error) {
+	cfg := newCallConfig(opts)
+	if cfg.delta && cfg.contentDefined {
+		return nil, fmt.Errorf("qback: delta and content-defined upload cannot be combined")
+	}
+	ctx, err := c.withTransferID(ctx, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("qback: %s is not a regular file", path)
+	}
+	fileSize := info.Size()
+
+	var fileHash string
+	var cdcChunks []common.CDCChunk
+	if cfg.contentDefined {
+		cdcChunks, fileHash, err = c.splitContentDefined(path)
+	} else {
+		fileHash, err = hashFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fileChunks := (fileSize + int64(c.cfg.chunkSize) - 1) / int64(c.cfg.chunkSize)
+	var chunkHashes []string
+	var missing map[string]bool
+	if len(cdcChunks) > 0 {
+		fileChunks = int64(len(cdcChunks))
+		for _, chunk := range cdcChunks {
+			chunkHashes = append(chunkHashes, chunk.Hash)
+		}
+		missing, err = c.queryMissingChunks(ctx, chunkHashes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	meta := &transferv1.FileMetadata{}
+	meta.SetTag(tag)
+	meta.SetName(name)
+	meta.SetSize(fileSize)
+	meta.SetChunks(fileChunks)
+	meta.SetChunksize(int64(c.cfg.chunkSize))
+	meta.SetHash(fileHash)
+	meta.SetChunkHashes(chunkHashes)
+	meta.SetDelta(cfg.delta)
+	meta.SetConflict(cfg.conflict.proto())
+	c.cfg.logger.Debug("upload metadata", "transfer_id", cfg.transferID, "tag", tag, "name", name, "size", fileSize, "chunks", fileChunks, "hash", fileHash)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, ack, result, err := c.openUpload(ctx, meta)
+	if err != nil {
+		return nil, err
+	}
+	if result != nil {
+		result.TransferID = cfg.transferID
+		return result, nil
+	}
+	result = &UploadResult{
+		Name:       ack.GetName(),
+		Size:       fileSize,
+		Hash:       fileHash,
+		Outcome:    outcomeFromProto(ack.GetOutcome()),
+		TransferID: cfg.transferID,
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var instructions []common.DeltaInstruction
+	if signatures := ack.GetSignatures(); signatures != nil {
+		instructions, err = c.computeDelta(f, fileSize, signatures)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case len(instructions) > 0:
+		err = c.sendDelta(stream, f, fileSize, ack.GetSignatures().GetBlockSize(), instructions, &cfg, result)
+	case len(cdcChunks) > 0:
+		err = c.sendMissingChunks(stream, f, fileSize, cdcChunks, missing, &cfg, result)
+	default:
+		err = c.sendChunks(stream, f, fileSize, &cfg, result)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.finishUpload(stream, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Upload 从 r 流式上传数据并以 name 保存到 tag 下，大小和哈希在数据结束后发送给服务端校验
+func (c *Client) Upload(ctx context.Context, tag, name string, r io.Reader, opts ...CallOption) (*UploadResult, error) {
+	cfg := newCallConfig(opts)
+	ctx, err := c.withTransferID(ctx, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &transferv1.FileMetadata{}
+	meta.SetTag(tag)
+	meta.SetName(name)
+	meta.SetChunksize(int64(c.cfg.chunkSize))
+	meta.SetConflict(cfg.conflict.proto())
+	meta.SetStreaming(true)
+	c.cfg.logger.Debug("streaming upload metadata", "transfer_id", cfg.transferID, "tag", tag, "name", name)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, ack, result, err := c.openUpload(ctx, meta)
+	if err != nil {
+		return nil, err
+	}
+	if result != nil {
+		result.TransferID = cfg.transferID
+		return result, nil
+	}
+	result = &UploadResult{
+		Name:       ack.GetName(),
+		Outcome:    outcomeFromProto(ack.GetOutcome()),
+		TransferID: cfg.transferID,
+	}
+
+	hasher := common.NewHasher()
+	buffer := make([]byte, c.cfg.chunkSize)
+	var chunk int64
+	for {
+		n, readErr := io.ReadFull(r, buffer)
+		if n > 0 {
+			chunk++
+			hasher.Write(buffer[:n])
+			if err := c.sendChunk(stream, chunk, buffer[:n], ""); err != nil {
+				return nil, err
+			}
+			result.Sent += int64(n)
+			cfg.report(result.Sent, 0)
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return nil, fmt.Errorf("qback: read input: %w", readErr)
+		}
+	}
+
+	result.Size = result.Sent
+	result.Hash = hasher.SumStream().ToHex()
+
+	trailer := &transferv1.FileTrailer{}
+	trailer.SetSize(result.Size)
+	trailer.SetHash(result.Hash)
+
+	req := &transferv1.UploadFileRequest{}
+	req.SetTrailer(trailer)
+	if err := c.send(stream, req); err != nil {
+		return nil, err
+	}
+	c.cfg.logger.Debug("streaming upload trailer sent", "size", result.Size, "hash", result.Hash)
+
+	if err := c.finishUpload(stream, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// openUpload 发送元数据并等待服务端确认
+// 服务端已完成上传时返回结果且 stream 为 nil
+func (c *Client) openUpload(ctx context.Context, meta *transferv1.FileMetadata) (uploadStream, *transferv1.MetaAck, *UploadResult, error) {
+	stream, err := c.rpc.UploadFile(ctx)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("qback: open upload stream: %w", err)
+	}
+
+	req := &transferv1.UploadFileRequest{}
+	req.SetMetadata(meta)
+	if err := stream.Send(req); err != nil {
+		return nil, nil, nil, fmt.Errorf("qback: send metadata: %w", err)
+	}
+
+	// 服务端排队时先返回排队位置，获得名额后再发送确认
+	var ack *transferv1.MetaAck
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, nil, nil, rpcError("upload", "receive ack", err)
+		}
+
+		ack = resp.GetMetaAck()
+		if ack == nil {
+			result := resp.GetResult()
+			if result == nil {
+				return nil, nil, nil, &Error{Op: "upload", Kind: ErrServer, Message: "missing ack"}
+			}
+			return nil, nil, nil, serverError("upload", result.GetErrorCode(), result.GetMessage(), ErrServer)
+		}
+		if ack.GetQueuePosition() == 0 {
+			break
+		}
+		c.cfg.logger.Info("upload queued by server", "position", ack.GetQueuePosition())
+	}
+	c.cfg.logger.Debug("upload ack", "allow", ack.GetAllowUpload(), "completed", ack.GetCompleted(), "outcome", ack.GetOutcome(), "name", ack.GetName(), "message", ack.GetMessage())
+
+	if ack.GetOutcome() == transferv1.ConflictOutcome_CONFLICT_OUTCOME_FAILED {
+		return nil, nil, nil, &Error{Op: "upload", Kind: ErrExists, Message: ack.GetMessage()}
+	}
+	if ack.GetCompleted() {
+		stream.CloseSend()
+		return nil, nil, &UploadResult{
+			Name:    ack.GetName(),
+			Size:    meta.GetSize(),
+			Hash:    meta.GetHash(),
+			Outcome: outcomeFromProto(ack.GetOutcome()),
+			Skipped: true,
+			Message: ack.GetMessage(),
+		}, nil
+	}
+	if !ack.GetAllowUpload() {
+		return nil, nil, nil, serverError("upload", ack.GetErrorCode(), ack.GetMessage(), ErrRejected)
+	}
+	return stream, ack, nil, nil
+}
+
+// finishUpload 结束发送并等待服务端的校验结果
+// 流式上传在结尾才发现内容相同时，服务端在结果中返回跳过
+func (c *Client) finishUpload(stream uploadStream, upload *UploadResult) error {
+	if err := stream.CloseSend(); err != nil {
+		return fmt.Errorf("qback: close send: %w", err)
+	}
+
+	resp, err := stream.Recv()
+	if err != nil {
+		return fmt.Errorf("qback: receive result: %w", err)
+	}
+	result := resp.GetResult()
+	if result == nil {
+		return &Error{Op: "upload", Kind: ErrServer, Message: "missing result"}
+	}
+	if !result.GetStatus() {
+		return serverError("upload", result.GetErrorCode(), result.GetMessage(), ErrServer)
+	}
+	upload.Message = result.GetMessage()
+	if result.GetOutcome() == transferv1.ConflictOutcome_CONFLICT_OUTCOME_SKIPPED {
+		upload.Outcome = OutcomeSkipped
+		upload.Skipped = true
+	}
+	return nil
+}
+
+// send 在分片超时时间内发送上传请求
+func (c *Client) send(stream uploadStream, req *transferv1.UploadFileRequest) error {
+	size := len(req.GetChunk().GetData())
+	for _, op := range req.GetDelta().GetOps() {
+		size += len(op.GetData())
+	}
+	if err := c.throttle(stream.Context(), size); err != nil {
+		return err
+	}
+
+	sendErr := make(chan error, 1)
+	go func() {
+		sendErr <- stream.Send(req)
+	}()
+
+	select {
+	case <-stream.Context().Done():
+		return stream.Context().Err()
+	case <-time.After(c.cfg.chunkTimeout):
+		return &Error{Op: "upload", Kind: ErrTimeout, Message: fmt.Sprintf("send timeout after %s", c.cfg.chunkTimeout)}
+	case err := <-sendErr:
+		if err == io.EOF {
+			return c.uploadAborted(stream)
+		}
+		if err != nil {
+			return fmt.Errorf("qback: send: %w", err)
+		}
+		return nil
+	}
+}
+
+// uploadAborted 服务端提前结束上传时发送返回 io.EOF，读取服务端返回的结果作为错误
+func (c *Client) uploadAborted(stream uploadStream) error {
+	resp, err := stream.Recv()
+	if err != nil {
+		return rpcError("upload", "send", err)
+	}
+	if result := resp.GetResult(); result != nil && !result.GetStatus() {
+		return serverError("upload", result.GetErrorCode(), result.GetMessage(), ErrServer)
+	}
+	return fmt.Errorf("qback: send: %w", io.EOF)
+}
+
+func (c *Client) sendChunk(stream uploadStream, index int64, data []byte, hash string) error {
+	chunk := &transferv1.ChunkData{}
+	chunk.SetChunk(index)
+	chunk.SetData(data)
+	chunk.SetHash(hash)
+
+	req := &transferv1.UploadFileRequest{}
+	req.SetChunk(chunk)
+	if err := c.send(stream, req); err != nil {
+		return fmt.Errorf("chunk %d: %w", index, err)
+	}
+	return nil
+}
+
+// sendChunks 按固定大小分片发送整个文件
+func (c *Client) sendChunks(stream uploadStream, f *os.File, fileSize int64, cfg *callConfig, result *UploadResult) error {
+	reader := bufio.NewReaderSize(f, c.cfg.chunkSize)
+	buffer := make([]byte, c.cfg.chunkSize)
+
+	var chunk int64
+	for result.Sent < fileSize {
+		n, err := io.ReadFull(reader, buffer[:min(int64(len(buffer)), fileSize-result.Sent)])
+		if err != nil {
+			return fmt.Errorf("qback: read file at chunk %d: %w", chunk+1, err)
+		}
+		chunk++
+		if err := c.sendChunk(stream, chunk, buffer[:n], ""); err != nil {
+			return err
+		}
+		result.Sent += int64(n)
+		cfg.report(result.Sent, fileSize)
+	}
+	return nil
+}
+
+// sendMissingChunks 只发送服务端缺少的内容定义分片，相同分片只发送一次
+func (c *Client) sendMissingChunks(stream uploadStream, f *os.File, fileSize int64, chunks []common.CDCChunk, missing map[string]bool, cfg *callConfig, result *UploadResult) error {
+	sent := make(map[string]bool)
+	for i, chunk := range chunks {
+		index := int64(i + 1)
+		if !missing[chunk.Hash] || sent[chunk.Hash] {
+			result.Reused += int64(chunk.Size)
+			continue
+		}
+
+		data := make([]byte, chunk.Size)
+		if _, err := f.ReadAt(data, chunk.Offset); err != nil {
+			return fmt.Errorf("qback: read file at chunk %d: %w", index, err)
+		}
+		if err := c.sendChunk(stream, index, data, chunk.Hash); err != nil {
+			return err
+		}
+		sent[chunk.Hash] = true
+		result.Sent += int64(chunk.Size)
+		cfg.report(chunk.Offset+int64(chunk.Size), fileSize)
+	}
+	cfg.report(fileSize, fileSize)
+	return nil
+}
+
+// sendDelta 按增量指令发送复制指令和新数据
+func (c *Client) sendDelta(stream uploadStream, f *os.File, fileSize, blockSize int64, instructions []common.DeltaInstruction, cfg *callConfig, result *UploadResult) error {
+	var ops []*transferv1.DeltaOp
+	var batchSize int
+	var covered int64
+
+	flush := func() error {
+		if len(ops) == 0 {
+			return nil
+		}
+		delta := &transferv1.DeltaData{}
+		delta.SetOps(ops)
+
+		req := &transferv1.UploadFileRequest{}
+		req.SetDelta(delta)
+		if err := c.send(stream, req); err != nil {
+			return fmt.Errorf("delta: %w", err)
+		}
+		c.cfg.logger.Debug("sent delta batch", "ops", len(ops), "bytes", batchSize)
+		ops = nil
+		batchSize = 0
+		cfg.report(min(covered, fileSize), fileSize)
+		return nil
+	}
+
+	for _, instruction := range instructions {
+		if instruction.Count > 0 {
+			op := &transferv1.DeltaOp{}
+			op.SetBlock(instruction.Block)
+			op.SetCount(instruction.Count)
+			ops = append(ops, op)
+			covered += instruction.Count * blockSize
+		}
+
+		end := instruction.Offset + instruction.Length
+		for offset := instruction.Offset; offset < end; offset += int64(c.cfg.chunkSize) {
+			data := make([]byte, min(int64(c.cfg.chunkSize), end-offset))
+			if _, err := f.ReadAt(data, offset); err != nil {
+				return fmt.Errorf("qback: read file at offset %d: %w", offset, err)
+			}
+
+			op := &transferv1.DeltaOp{}
+			op.SetData(data)
+			ops = append(ops, op)
+			batchSize += len(data)
+			result.Sent += int64(len(data))
+			covered += int64(len(data))
+
+			if batchSize >= c.cfg.chunkSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(ops) >= 4096 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	result.Reused = fileSize - result.Sent
+	cfg.report(fileSize, fileSize)
+	return nil
+}
+
+// computeDelta 根据服务端块签名计算增量，增量不小于完整文件时返回 nil 以完整上传
+func (c *Client) computeDelta(f *os.File, fileSize int64, signatures *transferv1.BlockSignatures) ([]common.DeltaInstruction, error) {
+	instructions, err := common.ComputeDelta(bufio.NewReaderSize(io.NewSectionReader(f, 0, fileSize), 1024*1024), signatures)
+	if err != nil {
+		return nil, fmt.Errorf("qback: compute delta: %w", err)
+	}
+
+	var literal int64
+	for _, instruction := range instructions {
+		literal += instruction.Length
+	}
+
+	deltaSize := literal + int64(len(instructions))*deltaOpOverhead
+	if deltaSize >= fileSize {
+		c.cfg.logger.Debug("delta not smaller than file, fallback to full upload", "delta_size", deltaSize)
+		return nil, nil
+	}
+	c.cfg.logger.Debug("delta computed", "ops", len(instructions), "literal", literal)
+	return instructions, nil
+}
+
+// splitContentDefined 按内容定义分片切分文件，同时计算整个文件的哈希
+func (c *Client) splitContentDefined(path string) ([]common.CDCChunk, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	hasher := common.NewHasher()
+	chunker := common.NewChunker(bufio.NewReaderSize(f, 1024*1024), c.cfg.chunkSize)
+
+	var chunks []common.CDCChunk
+	var offset int64
+	for {
+		data, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("qback: split file: %w", err)
+		}
+
+		hash, err := common.CalcBlake3FromBytes(data)
+		if err != nil {
+			return nil, "", err
+		}
+		hasher.Write(data)
+
+		chunks = append(chunks, common.CDCChunk{Offset: offset, Size: len(data), Hash: hash})
+		offset += int64(len(data))
+	}
+
+	c.cfg.logger.Debug("content-defined split finished", "path", path, "chunks", len(chunks), "size", offset)
+	return chunks, hasher.SumStream().ToHex(), nil
+}
+
+// queryMissingChunks 查询服务端缺少的分片
+func (c *Client) queryMissingChunks(ctx context.Context, chunkHashes []string) (map[string]bool, error) {
+	req := &transferv1.QueryChunksRequest{}
+	req.SetHashes(chunkHashes)
+
+	resp, err := c.rpc.QueryChunks(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("qback: query chunks: %w", err)
+	}
+	if !resp.GetStatus() {
+		return nil, &Error{Op: "query chunks", Kind: ErrRejected, Message: resp.GetMessage()}
+	}
+
+	missing := make(map[string]bool)
+	for _, hash := range resp.GetMissing() {
+		missing[hash] = true
+	}
+	c.cfg.logger.Debug("query chunks", "requested", len(chunkHashes), "missing", len(missing))
+	return missing, nil
+}
+
+// hashFile 计算文件哈希
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hasher := common.NewHasher()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", err
+	}
+	return hasher.SumStream().ToHex(), nil
+}
@@ -0,0 +1,111 @@
+package qback_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"qback/grpc/server"
+	"qback/internal/servertest"
+	"qback/pkg/qback"
+)
+
+// startServer 启动嵌入式服务端，返回连接到它的客户端和保存目录
+func startServer(t *testing.T, opts ...server.Option) (*qback.Client, string) {
+	t.Helper()
+	address, savePath := servertest.Start(t, opts...)
+	return servertest.Dial(t, address, qback.WithChunkSize(4096)), savePath
+}
+
+// randomData 返回固定种子的随机数据，内容不可压缩且每次相同
+func randomData(seed uint64, size int) []byte {
+	r := rand.New(rand.NewPCG(seed, seed))
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(r.Uint32())
+	}
+	return data
+}
+
+func writeTemp(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "upload.bin")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUploadFile(t *testing.T) {
+	client, savePath := startServer(t)
+	ctx := context.Background()
+	data := randomData(1, 20000)
+
+	result, err := client.UploadFile(ctx, "docs", "a.bin", writeTemp(t, data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Name != "a.bin" || result.Size != int64(len(data)) || result.Sent != int64(len(data)) || result.Outcome != qback.OutcomeCreated {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	saved, err := os.ReadFile(filepath.Join(savePath, "docs", "a.bin"))
+	if err != nil || !bytes.Equal(saved, data) {
+		t.Fatalf("saved file mismatch: %v", err)
+	}
+}
+
+func TestUploadReader(t *testing.T) {
+	client, savePath := startServer(t)
+	data := randomData(2, 10000)
+
+	result, err := client.Upload(context.Background(), "docs", "stream.bin", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Size != int64(len(data)) || result.Hash == "" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	saved, err := os.ReadFile(filepath.Join(savePath, "docs", "stream.bin"))
+	if err != nil || !bytes.Equal(saved, data) {
+		t.Fatalf("saved file mismatch: %v", err)
+	}
+}
+
+func TestUploadErrors(t *testing.T) {
+	client, _ := startServer(t)
+	ctx := context.Background()
+	path := writeTemp(t, []byte("hello"))
+
+	if _, err := client.UploadFile(ctx, "docs", "a.txt", path); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		tag  string
+		file string
+		opts []qback.CallOption
+		kind error
+	}{
+		{"identical", "docs", "a.txt", nil, qback.ErrExists},
+		{"fail policy", "docs", "a.txt", []qback.CallOption{qback.WithConflict(qback.ConflictFail)}, qback.ErrExists},
+		{"invalid tag", "..", "a.txt", nil, qback.ErrRejected},
+		{"hidden name", "docs", ".a.txt", nil, qback.ErrRejected},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := client.UploadFile(ctx, tc.tag, tc.file, path, tc.opts...)
+			if !errors.Is(err, tc.kind) {
+				t.Fatalf("expected %v, got %v", tc.kind, err)
+			}
+			var qerr *qback.Error
+			if !errors.As(err, &qerr) || qerr.Op != "upload" {
+				t.Fatalf("expected upload error, got %v", err)
+			}
+		})
+	}
+}
@@ -2,7 +2,8 @@ package utils
 
 import (
 	"fmt"
-	"log"
+	"strconv"
+	"strings"
 
 	"github.com/qmaru/minitools/v2/file"
 	"github.com/qmaru/minitools/v2/hashx/blake3"
@@ -26,13 +27,36 @@ func PrettySize(size int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
 }
 
+// ParseSize 解析带单位的大小，例如 512K、10M、1.5G，单位按 1024 进制计算
+func ParseSize(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	str = strings.TrimSuffix(strings.TrimSuffix(str, "IB"), "B")
+	if str == "" {
+		return 0, fmt.Errorf("invalid size: %q", s)
+	}
+
+	multiplier := int64(1)
+	if idx := strings.IndexAny(str, "KMGTPE"); idx >= 0 {
+		if idx != len(str)-1 {
+			return 0, fmt.Errorf("invalid size: %q", s)
+		}
+		for i := 0; i <= strings.IndexByte("KMGTPE", str[idx]); i++ {
+			multiplier *= 1024
+		}
+		str = str[:idx]
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size: %q", s)
+	}
+
+	return int64(value * float64(multiplier)), nil
+}
+
 func PrettyHash(h string) string {
 	if len(h) <= 8 {
 		return h
 	}
 	return h[:8]
 }
-
-func LogDebug(format string, v ...any) {
-	log.Printf("[DEBUG] "+format, v...)
-}
@@ -0,0 +1,35 @@
+package utils
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want int64
+	}{
+		{"0", 0},
+		{"512", 512},
+		{"512B", 512},
+		{"1K", 1024},
+		{"1kb", 1024},
+		{"10M", 10 << 20},
+		{"10MiB", 10 << 20},
+		{" 1.5G ", 3 << 29},
+		{"2T", 2 << 40},
+	} {
+		got, err := ParseSize(tc.in)
+		if err != nil {
+			t.Errorf("ParseSize(%q): %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tc.in, got, tc.want)
+		}
+	}
+
+	for _, in := range []string{"", "B", "M", "abc", "-1M", "1MK", "1X", "1.2.3G"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Errorf("ParseSize(%q) expected error", in)
+		}
+	}
+}